@@ -35,9 +35,17 @@ type accumulatedData struct {
 	pointMap   *sync.Map
 	ttlManager *scheduler.Manager
 	logger     *logh.ContextualLogger
+	observer   accumulatedDataObserver
 	sync.Mutex
 }
 
+// accumulatedDataObserver - implemented by the transports keeping some state by accumulated data hash
+type accumulatedDataObserver interface {
+
+	// accumulatedDataRemoved - releases the state kept for the expired hash
+	accumulatedDataRemoved(hash string)
+}
+
 // Release - releases the resources
 func (ad *accumulatedData) Release() {
 	return
@@ -59,6 +67,10 @@ func (ad *accumulatedData) Execute() {
 		ad.pointMap.Delete(ad.hash)
 		ad.ttlManager.RemoveTask(ad.hash)
 
+		if ad.observer != nil {
+			ad.observer.accumulatedDataRemoved(ad.hash)
+		}
+
 		if logh.InfoEnabled {
 			ad.logger.Info().Str("hash", ad.hash).Msgf("data removed")
 		}
@@ -122,6 +134,10 @@ func (a *Accumulator) ProcessMapEntry(entry DataProcessorEntry) bool {
 			}
 		}
 
+		if a.observer != nil {
+			a.observer.accumulatedDataEmitted(item)
+		}
+
 		a.transport.DataChannel(item)
 
 		atomic.StoreUint64(&data.count, 0)
@@ -209,6 +225,7 @@ func (a *Accumulator) store(hash string, instance Hashable, ttl time.Duration) e
 	data.ttl = ttl
 	data.ttlManager = a.ttlManager
 	data.hash = hash
+	data.observer, _ = a.transport.(accumulatedDataObserver)
 
 	if ttl > 0 {
 		err := a.ttlManager.AddTask(scheduler.NewTask(hash, ttl, data), true)
@@ -0,0 +1,61 @@
+package timeline
+
+/**
+* The running aggregates kept by each flattened series (constant memory, guarded by the entry lock).
+* @author rnojiri
+**/
+
+// runningAggregates - the count, sum, sum of squares, min and max of the values
+type runningAggregates struct {
+	count      float64
+	sum        float64
+	sumSquares float64
+	min        float64
+	max        float64
+}
+
+// newRunningAggregates - creates the aggregates using the first value
+func newRunningAggregates(value float64) runningAggregates {
+
+	return runningAggregates{
+		count:      1,
+		sum:        value,
+		sumSquares: value * value,
+		min:        value,
+		max:        value,
+	}
+}
+
+// add - adds a value to the aggregates
+func (ra *runningAggregates) add(value float64) {
+
+	ra.count++
+	ra.sum += value
+	ra.sumSquares += value * value
+
+	if value < ra.min {
+		ra.min = value
+	}
+
+	if value > ra.max {
+		ra.max = value
+	}
+}
+
+// variance - returns the population variance
+func (ra runningAggregates) variance() float64 {
+
+	if ra.count == 0 {
+		return 0
+	}
+
+	mean := ra.sum / ra.count
+	v := ra.sumSquares/ra.count - mean*mean
+
+	if v < 0 {
+		// rounding errors when all values are almost equal
+		return 0
+	}
+
+	return v
+}
@@ -0,0 +1,328 @@
+package timeline
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uol/logh"
+)
+
+/**
+* Limits the number of distinct series per metric to protect the backend from cardinality explosions.
+* @author rnojiri
+**/
+
+const (
+	// OverflowTagValue - the value used to collapse the offending tag
+	OverflowTagValue string = "__overflow__"
+
+	defaultTopOffenders int = 10
+)
+
+// CardinalityStats - the cardinality limiter statistics
+type CardinalityStats struct {
+	DroppedPoints   uint64
+	CollapsedPoints uint64
+	TopOffenders    []MetricCardinality
+}
+
+// MetricCardinality - the cardinality status of a metric
+type MetricCardinality struct {
+	Metric string
+
+	// Series - the admitted series (exact) or the estimated number of series seen, including the rejected ones (hyperloglog)
+	Series uint64
+
+	Limit           int
+	DroppedPoints   uint64
+	CollapsedPoints uint64
+	OverflowTag     string
+}
+
+// metricCardinality - the series tracking of a single metric
+type metricCardinality struct {
+	limit           int
+	series          map[uint64]struct{}
+	sketch          *hyperLogLog
+	tags            map[string]*hyperLogLog
+	droppedPoints   uint64
+	collapsedPoints uint64
+	overflowTag     string
+	exceeded        bool
+	lastReset       time.Time
+	sync.Mutex
+}
+
+// CardinalityLimiter - a point filter limiting the number of series per metric
+type CardinalityLimiter struct {
+	configuration   *CardinalityLimiterConfig
+	metrics         sync.Map
+	droppedPoints   uint64
+	collapsedPoints uint64
+	loggers         *logh.ContextualLogger
+}
+
+// NewCardinalityLimiter - creates a new cardinality limiter
+func NewCardinalityLimiter(configuration *CardinalityLimiterConfig) (*CardinalityLimiter, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	if configuration.MaxSeriesPerMetric < 0 {
+		return nil, fmt.Errorf("invalid maximum number of series per metric: %d", configuration.MaxSeriesPerMetric)
+	}
+
+	for metric, limit := range configuration.MetricLimits {
+		if limit < 0 {
+			return nil, fmt.Errorf("invalid maximum number of series for metric \"%s\": %d", metric, limit)
+		}
+	}
+
+	switch configuration.Action {
+	case CardinalityDrop, CardinalityCollapse:
+	case empty:
+		configuration.Action = CardinalityDrop
+	default:
+		return nil, fmt.Errorf("invalid cardinality action: %s", configuration.Action)
+	}
+
+	switch configuration.Estimator {
+	case CardinalityExact, CardinalityHyperLogLog:
+	case empty:
+		configuration.Estimator = CardinalityExact
+	default:
+		return nil, fmt.Errorf("invalid cardinality estimator: %s", configuration.Estimator)
+	}
+
+	if configuration.TopOffenders <= 0 {
+		configuration.TopOffenders = defaultTopOffenders
+	}
+
+	l := &CardinalityLimiter{
+		configuration: configuration,
+	}
+
+	l.BuildContextualLogger()
+
+	return l, nil
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (l *CardinalityLimiter) BuildContextualLogger(path ...string) {
+
+	logContext := []string{"pkg", "timeline/cardinality"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	l.loggers = logh.CreateContextualLogger(logContext...)
+}
+
+// getLimit - returns the series limit of the metric (zero means no limit)
+func (l *CardinalityLimiter) getLimit(metric string) int {
+
+	if limit, ok := l.configuration.MetricLimits[metric]; ok {
+		return limit
+	}
+
+	return l.configuration.MaxSeriesPerMetric
+}
+
+// Filter - tracks the point's series and applies the configured action if the metric's budget was exceeded
+func (l *CardinalityLimiter) Filter(point *SeriesPoint) bool {
+
+	limit := l.getLimit(point.Metric)
+	if limit <= 0 {
+		return true
+	}
+
+	item, ok := l.metrics.Load(point.Metric)
+	if !ok {
+		item, _ = l.metrics.LoadOrStore(point.Metric, l.newMetricCardinality(limit))
+	}
+
+	mc := item.(*metricCardinality)
+
+	mc.Lock()
+	defer mc.Unlock()
+
+	if l.configuration.ResetInterval.Duration > 0 && time.Since(mc.lastReset) > l.configuration.ResetInterval.Duration {
+		mc.reset()
+	}
+
+	mc.trackTags(point)
+
+	if mc.admit(point.seriesHash()) {
+		return true
+	}
+
+	if !mc.exceeded {
+		mc.exceeded = true
+		if logh.WarnEnabled {
+			l.loggers.Warn().Str("metric", point.Metric).Msgf("series limit exceeded: %d", limit)
+		}
+	}
+
+	if l.configuration.Action == CardinalityCollapse {
+
+		mc.overflowTag = mc.offendingTag()
+
+		if len(mc.overflowTag) > 0 && point.SetTagValue(mc.overflowTag, OverflowTagValue) {
+			mc.collapsedPoints++
+			atomic.AddUint64(&l.collapsedPoints, 1)
+			return true
+		}
+	}
+
+	mc.droppedPoints++
+	atomic.AddUint64(&l.droppedPoints, 1)
+
+	return false
+}
+
+// newMetricCardinality - creates the series tracking for a metric
+func (l *CardinalityLimiter) newMetricCardinality(limit int) *metricCardinality {
+
+	mc := &metricCardinality{
+		limit:     limit,
+		series:    map[uint64]struct{}{},
+		tags:      map[string]*hyperLogLog{},
+		lastReset: time.Now(),
+	}
+
+	if l.configuration.Estimator == CardinalityHyperLogLog {
+		mc.sketch = newHyperLogLog(defaultHLLPrecision)
+	}
+
+	return mc
+}
+
+// GetStats - returns the dropped and collapsed points and the metrics with most rejected points
+func (l *CardinalityLimiter) GetStats() CardinalityStats {
+
+	offenders := []MetricCardinality{}
+
+	l.metrics.Range(func(k, v interface{}) bool {
+
+		mc := v.(*metricCardinality)
+		mc.Lock()
+		defer mc.Unlock()
+
+		offenders = append(offenders, MetricCardinality{
+			Metric:          k.(string),
+			Series:          mc.numSeries(),
+			Limit:           mc.limit,
+			DroppedPoints:   mc.droppedPoints,
+			CollapsedPoints: mc.collapsedPoints,
+			OverflowTag:     mc.overflowTag,
+		})
+
+		return true
+	})
+
+	sort.Slice(offenders, func(i, j int) bool {
+		ri := offenders[i].DroppedPoints + offenders[i].CollapsedPoints
+		rj := offenders[j].DroppedPoints + offenders[j].CollapsedPoints
+		if ri != rj {
+			return ri > rj
+		}
+		return offenders[i].Series > offenders[j].Series
+	})
+
+	if len(offenders) > l.configuration.TopOffenders {
+		offenders = offenders[:l.configuration.TopOffenders]
+	}
+
+	return CardinalityStats{
+		DroppedPoints:   atomic.LoadUint64(&l.droppedPoints),
+		CollapsedPoints: atomic.LoadUint64(&l.collapsedPoints),
+		TopOffenders:    offenders,
+	}
+}
+
+// trackTags - estimates the number of distinct values of each tag
+func (mc *metricCardinality) trackTags(point *SeriesPoint) {
+
+	for i := 0; i+1 < len(point.Tags); i += 2 {
+
+		key, ok := point.Tags[i].(string)
+		if !ok {
+			key = fmt.Sprint(point.Tags[i])
+		}
+
+		sketch, ok := mc.tags[key]
+		if !ok {
+			sketch = newHyperLogLog(tagHLLPrecision)
+			mc.tags[key] = sketch
+		}
+
+		sketch.add(hashString(point.Tags[i+1]))
+	}
+}
+
+// admit - checks if the series fits in the budget (the admitted series are always stored, the sketch only estimates all series seen)
+func (mc *metricCardinality) admit(hash uint64) bool {
+
+	if mc.sketch != nil {
+		mc.sketch.add(hash)
+	}
+
+	if _, ok := mc.series[hash]; ok {
+		return true
+	}
+
+	if len(mc.series) >= mc.limit {
+		return false
+	}
+
+	mc.series[hash] = struct{}{}
+
+	return true
+}
+
+// offendingTag - returns the tag with the most distinct values
+func (mc *metricCardinality) offendingTag() string {
+
+	var selected string
+	var max uint64
+
+	for key, sketch := range mc.tags {
+		count := sketch.count()
+		if count > max || (count == max && key < selected) {
+			selected = key
+			max = count
+		}
+	}
+
+	return selected
+}
+
+// numSeries - returns the number of series
+func (mc *metricCardinality) numSeries() uint64 {
+
+	if mc.sketch != nil {
+		return mc.sketch.count()
+	}
+
+	return uint64(len(mc.series))
+}
+
+// reset - clears the series tracking
+func (mc *metricCardinality) reset() {
+
+	if mc.sketch != nil {
+		mc.sketch.reset()
+	}
+
+	mc.series = map[uint64]struct{}{}
+
+	mc.tags = map[string]*hyperLogLog{}
+	mc.exceeded = false
+	mc.overflowTag = empty
+	mc.lastReset = time.Now()
+}
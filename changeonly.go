@@ -0,0 +1,187 @@
+package timeline
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uol/logh"
+	"github.com/uol/scheduler"
+)
+
+/**
+* Suppresses the points with the same value of the last one sent, re-emitting them as heartbeats.
+* @author rnojiri
+**/
+
+// ChangeOnlyStats - the change only filter statistics
+type ChangeOnlyStats struct {
+	SuppressedPoints uint64
+	Heartbeats       uint64
+}
+
+// changeOnlyState - the last value sent from a series
+type changeOnlyState struct {
+	key        string
+	lastValue  float64
+	lastSent   time.Time
+	lastUpdate time.Time
+	ttl        time.Duration
+	seriesMap  *sync.Map
+	ttlManager *scheduler.Manager
+	logger     *logh.ContextualLogger
+	sync.Mutex
+}
+
+// Execute - implements the Job interface
+func (s *changeOnlyState) Execute() {
+
+	s.Lock()
+	defer s.Unlock()
+
+	if time.Now().Sub(s.lastUpdate) > s.ttl {
+
+		s.seriesMap.Delete(s.key)
+		s.ttlManager.RemoveTask(s.key)
+
+		if logh.DebugEnabled {
+			s.logger.Debug().Str("hash", s.key).Msgf("series state removed")
+		}
+	}
+}
+
+// ChangeOnlyFilter - a point filter suppressing the points whose value did not change
+type ChangeOnlyFilter struct {
+	configuration    *ChangeOnlyConfig
+	metrics          map[string]struct{}
+	seriesMap        sync.Map
+	ttlManager       *scheduler.Manager
+	suppressedPoints uint64
+	heartbeats       uint64
+	loggers          *logh.ContextualLogger
+}
+
+// NewChangeOnlyFilter - creates a new change only filter
+func NewChangeOnlyFilter(configuration *ChangeOnlyConfig) (*ChangeOnlyFilter, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	if configuration.HeartbeatInterval.Duration <= 0 {
+		return nil, fmt.Errorf("invalid heartbeat interval: %s", configuration.HeartbeatInterval)
+	}
+
+	if configuration.TTL.Duration <= 0 {
+		return nil, fmt.Errorf("invalid series ttl: %s", configuration.TTL)
+	}
+
+	f := &ChangeOnlyFilter{
+		configuration: configuration,
+		metrics:       map[string]struct{}{},
+		ttlManager:    scheduler.New(),
+	}
+
+	for _, metric := range configuration.Metrics {
+		f.metrics[metric] = struct{}{}
+	}
+
+	f.BuildContextualLogger()
+
+	return f, nil
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (f *ChangeOnlyFilter) BuildContextualLogger(path ...string) {
+
+	logContext := []string{"pkg", "timeline/changeonly"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	f.loggers = logh.CreateContextualLogger(logContext...)
+}
+
+// Filter - returns false if the point has the same value of the last one sent and no heartbeat is due
+func (f *ChangeOnlyFilter) Filter(point *SeriesPoint) bool {
+
+	if len(f.metrics) > 0 {
+		if _, ok := f.metrics[point.Metric]; !ok {
+			return true
+		}
+	}
+
+	key := strconv.FormatUint(point.seriesHash(), 16)
+	now := time.Now()
+
+	item, ok := f.seriesMap.Load(key)
+	if !ok {
+
+		state := &changeOnlyState{
+			key:        key,
+			lastValue:  point.Value,
+			lastSent:   now,
+			lastUpdate: now,
+			ttl:        f.configuration.TTL.Duration,
+			seriesMap:  &f.seriesMap,
+			ttlManager: f.ttlManager,
+			logger:     f.loggers,
+		}
+
+		item, ok = f.seriesMap.LoadOrStore(key, state)
+		if !ok {
+			err := f.ttlManager.AddTask(scheduler.NewTask(key, state.ttl, state), true)
+			if err != nil && logh.ErrorEnabled {
+				f.loggers.Error().Err(err).Msg("error adding the series ttl task")
+			}
+
+			return true
+		}
+	}
+
+	state := item.(*changeOnlyState)
+
+	state.Lock()
+	defer state.Unlock()
+
+	state.lastUpdate = now
+
+	if state.lastValue != point.Value {
+		state.lastValue = point.Value
+		state.lastSent = now
+		return true
+	}
+
+	if now.Sub(state.lastSent) >= f.configuration.HeartbeatInterval.Duration {
+		state.lastSent = now
+		atomic.AddUint64(&f.heartbeats, 1)
+		return true
+	}
+
+	atomic.AddUint64(&f.suppressedPoints, 1)
+
+	return false
+}
+
+// GetStats - returns the number of suppressed points and heartbeats
+func (f *ChangeOnlyFilter) GetStats() ChangeOnlyStats {
+
+	return ChangeOnlyStats{
+		SuppressedPoints: atomic.LoadUint64(&f.suppressedPoints),
+		Heartbeats:       atomic.LoadUint64(&f.heartbeats),
+	}
+}
+
+// Stop - removes all series states
+func (f *ChangeOnlyFilter) Stop() {
+
+	f.ttlManager.RemoveAllTasks()
+
+	f.seriesMap.Range(func(k, _ interface{}) bool {
+		f.seriesMap.Delete(k)
+		return true
+	})
+}
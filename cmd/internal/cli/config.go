@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/uol/timeline"
+)
+
+/**
+* The configuration shared by the command line tools (the same shape used by the library configuration files).
+* @author rnojiri
+**/
+
+const (
+	// SerializationOpenTSDB - the unix and file transports send opentsdb lines
+	SerializationOpenTSDB string = "opentsdb"
+
+	// SerializationJSON - the unix and file transports send json points
+	SerializationJSON string = "json"
+)
+
+// Config - the command line tools configuration (only one transport must be configured)
+type Config struct {
+	Backend                *timeline.Backend                      `json:"backend,omitempty"`
+	DataTransformer        *timeline.DataTransformerConfig        `json:"dataTransformer,omitempty"`
+	HTTPTransport          *timeline.HTTPTransportConfig          `json:"httpTransport,omitempty"`
+	OpenTSDBTransport      *timeline.OpenTSDBTransportConfig      `json:"openTSDBTransport,omitempty"`
+	UDPTransport           *timeline.UDPTransportConfig           `json:"udpTransport,omitempty"`
+	InfluxTransport        *timeline.InfluxTransportConfig        `json:"influxTransport,omitempty"`
+	GraphiteTransport      *timeline.GraphiteTransportConfig      `json:"graphiteTransport,omitempty"`
+	StatsDTransport        *timeline.StatsDTransportConfig        `json:"statsDTransport,omitempty"`
+	PrometheusRemoteWrite  *timeline.PrometheusRemoteWriteConfig  `json:"prometheusRemoteWrite,omitempty"`
+	OTLPTransport          *timeline.OTLPTransportConfig          `json:"otlpTransport,omitempty"`
+	ElasticsearchTransport *timeline.ElasticsearchTransportConfig `json:"elasticsearchTransport,omitempty"`
+	UnixTransport          *timeline.UnixTransportConfig          `json:"unixTransport,omitempty"`
+	FileTransport          *timeline.FileTransportConfig          `json:"fileTransport,omitempty"`
+	Serialization          string                                 `json:"serialization,omitempty"`
+}
+
+// LoadConfig - loads the configuration from a toml or json file (chosen by the file extension)
+func LoadConfig(path string) (*Config, error) {
+
+	conf := &Config{}
+
+	err := DecodeFile(path, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conf.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// DecodeFile - decodes a toml or json file (chosen by the file extension) to the given structure
+func DecodeFile(path string, conf interface{}) error {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		_, err = toml.Decode(string(data), conf)
+	case ".json":
+		err = json.Unmarshal(data, conf)
+	default:
+		return fmt.Errorf("unsupported configuration file extension: %s", path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error loading configuration \"%s\": %s", path, err.Error())
+	}
+
+	return nil
+}
+
+// Validate - validates the configuration and sets the default values
+func (c *Config) Validate() error {
+
+	if c.Backend == nil {
+		c.Backend = &timeline.Backend{}
+	}
+
+	if len(c.Serialization) == 0 {
+		c.Serialization = SerializationOpenTSDB
+	}
+
+	if c.Serialization != SerializationOpenTSDB && c.Serialization != SerializationJSON {
+		return fmt.Errorf("invalid serialization: %s", c.Serialization)
+	}
+
+	return nil
+}
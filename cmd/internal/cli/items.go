@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+
+	jsonSerializer "github.com/uol/serializer/json"
+	openTSDBSerializer "github.com/uol/serializer/opentsdb"
+	"github.com/uol/timeline"
+)
+
+/**
+* Converts the parsed points to the items accepted by the transports and flattens them.
+* @author rnojiri
+**/
+
+const (
+	// JSONPointSchema - the json serializer schema used by the json transports
+	JSONPointSchema string = "point"
+)
+
+// ToItem - converts the opentsdb item to the item accepted by the pipeline transport
+func (p *Pipeline) ToItem(item *openTSDBSerializer.ArrayItem) (interface{}, error) {
+
+	if !p.JSONItems {
+		return item, nil
+	}
+
+	tags := make(map[string]string, len(item.Tags)/2)
+
+	for i := 0; i+1 < len(item.Tags); i += 2 {
+
+		key, ok := item.Tags[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("error casting tag key to string")
+		}
+
+		tags[key] = fmt.Sprint(item.Tags[i+1])
+	}
+
+	return &jsonSerializer.ArrayItem{
+		Name: JSONPointSchema,
+		Parameters: []interface{}{
+			"metric", item.Metric,
+			defaultValueProperty, item.Value,
+			defaultTimestampProperty, item.Timestamp,
+			"tags", tags,
+		},
+	}, nil
+}
+
+// Flatten - flattens the item using one operation or multiple operations sharing the same values
+func (p *Pipeline) Flatten(operations []timeline.FlatOperation, item interface{}) error {
+
+	if len(operations) == 1 {
+		return p.Manager.Flatten(operations[0], item)
+	}
+
+	return p.Manager.FlattenMultiple(operations, item)
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uol/logh"
+	openTSDBSerializer "github.com/uol/serializer/opentsdb"
+	"github.com/uol/timeline"
+)
+
+/**
+* Reads the opentsdb telnet lines and json points from files or streams.
+* @author rnojiri
+**/
+
+const (
+	maxLineSize           int   = 4 * 1024 * 1024
+	millisecondsThreshold int64 = 100000000000
+)
+
+// ParseLine - parses a opentsdb telnet line (the timestamp can be "now") or a json point/array (empty lines and comments return no items)
+func ParseLine(line string) ([]*openTSDBSerializer.ArrayItem, error) {
+
+	line = strings.TrimSpace(line)
+
+	if len(line) == 0 || line[0] == '#' {
+		return nil, nil
+	}
+
+	if line[0] == '{' || line[0] == '[' {
+		return timeline.ParseOpenTSDBJSON([]byte(line))
+	}
+
+	item, err := timeline.ParseOpenTSDBLine(replaceNow(line))
+	if err != nil {
+		return nil, err
+	}
+
+	return []*openTSDBSerializer.ArrayItem{item}, nil
+}
+
+// replaceNow - replaces the "now" timestamp by the current unix time
+func replaceNow(line string) string {
+
+	fields := strings.Fields(line)
+
+	i := 1
+	if len(fields) > 0 && fields[0] == "put" {
+		i = 2
+	}
+
+	if len(fields) <= i || fields[i] != "now" {
+		return line
+	}
+
+	fields[i] = strconv.FormatInt(time.Now().Unix(), 10)
+
+	return strings.Join(fields, " ")
+}
+
+// ReadLines - calls the function for each line from the reader
+func ReadLines(reader io.Reader, fn func(line string) bool) error {
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	for scanner.Scan() {
+		if !fn(scanner.Text()) {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+// OpenFile - opens the file ("-" is the standard input) decompressing the gzip files
+func OpenFile(path string) (io.ReadCloser, error) {
+
+	if path == "-" {
+		return os.Stdin, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &gzipFile{Reader: reader, file: file}, nil
+}
+
+// gzipFile - closes the gzip reader and the file
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+// Close - closes the gzip reader and the file
+func (g *gzipFile) Close() error {
+
+	g.Reader.Close()
+
+	return g.file.Close()
+}
+
+// ShiftTimestamp - shifts the timestamp in seconds or milliseconds
+func ShiftTimestamp(timestamp int64, shift time.Duration) int64 {
+
+	if timestamp >= millisecondsThreshold {
+		return timestamp + int64(shift/time.Millisecond)
+	}
+
+	return timestamp + int64(shift/time.Second)
+}
+
+// ConfigureLogger - configures the global logger (the logs are written to the standard output)
+func ConfigureLogger(level string) {
+
+	logh.ConfigureGlobalLogger(logh.Level(level), logh.CONSOLE)
+}
+
+// flatOperations - the flattener operations by name
+var flatOperations = map[string]timeline.FlatOperation{
+	"avg":      timeline.Avg,
+	"sum":      timeline.Sum,
+	"count":    timeline.Count,
+	"max":      timeline.Max,
+	"min":      timeline.Min,
+	"median":   timeline.Median,
+	"stddev":   timeline.StdDev,
+	"variance": timeline.Variance,
+	"p50":      timeline.P50,
+	"p90":      timeline.P90,
+	"p95":      timeline.P95,
+	"p99":      timeline.P99,
+	"first":    timeline.First,
+	"last":     timeline.Last,
+	"range":    timeline.Range,
+	"rate":     timeline.Rate,
+}
+
+// ParseFlatOperation - returns the flattener operation by name (other percentiles can be used like "p75" or "p99.9")
+func ParseFlatOperation(name string) (timeline.FlatOperation, error) {
+
+	name = strings.ToLower(name)
+
+	operation, ok := flatOperations[name]
+	if ok {
+		return operation, nil
+	}
+
+	if strings.HasPrefix(name, "p") {
+		percentile, err := strconv.ParseFloat(name[1:], 64)
+		if err == nil && percentile >= 0 && percentile <= 100 {
+			return timeline.QuantileOperation(percentile / 100)
+		}
+	}
+
+	return 0, fmt.Errorf("invalid flattener operation: %s", name)
+}
+
+// ParseFlatOperations - returns the flattener operations from a comma separated list of names
+func ParseFlatOperations(names string) ([]timeline.FlatOperation, error) {
+
+	split := strings.Split(names, ",")
+	operations := make([]timeline.FlatOperation, len(split))
+
+	for i, name := range split {
+
+		var err error
+		operations[i], err = ParseFlatOperation(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return operations, nil
+}
+
+// SeriesKey - returns a key identifying the series (metric and tags)
+func SeriesKey(item *openTSDBSerializer.ArrayItem) string {
+
+	var b strings.Builder
+
+	b.WriteString(item.Metric)
+
+	for _, tag := range item.Tags {
+		b.WriteByte(' ')
+		b.WriteString(fmt.Sprint(tag))
+	}
+
+	return b.String()
+}
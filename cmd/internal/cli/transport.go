@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/uol/funks"
+	"github.com/uol/hashing"
+	jsonSerializer "github.com/uol/serializer/json"
+	"github.com/uol/timeline"
+)
+
+/**
+* Creates the configured transport and the manager.
+* @author rnojiri
+**/
+
+const (
+	defaultTimestampProperty string = "timestamp"
+	defaultValueProperty     string = "value"
+	defaultHashSize          int    = 12
+)
+
+// Pipeline - the manager and the kind of item accepted by its transport
+type Pipeline struct {
+	Manager   *timeline.Manager
+	Transport timeline.Transport
+	JSONItems bool
+}
+
+// setDefaultProperties - uses the json point properties if they are not configured
+func setDefaultProperties(conf *timeline.CustomSerializerConfig) {
+
+	if len(conf.TimestampProperty) == 0 {
+		conf.TimestampProperty = defaultTimestampProperty
+	}
+
+	if len(conf.ValueProperty) == 0 {
+		conf.ValueProperty = defaultValueProperty
+	}
+}
+
+// NewTransport - creates the configured transport (returns true if it accepts json items)
+func NewTransport(conf *Config) (timeline.Transport, bool, error) {
+
+	transports := []timeline.Transport{}
+	jsonItems := false
+
+	add := func(t timeline.Transport, err error) error {
+		if err != nil {
+			return err
+		}
+		transports = append(transports, t)
+		return nil
+	}
+
+	var err error
+
+	if conf.OpenTSDBTransport != nil {
+		err = add(timeline.NewOpenTSDBTransport(conf.OpenTSDBTransport))
+	}
+
+	if err == nil && conf.HTTPTransport != nil {
+		setDefaultProperties(&conf.HTTPTransport.CustomSerializerConfig)
+		var s *jsonSerializer.Serializer
+		if s, err = NewJSONSerializer(conf.HTTPTransport.SerializerBufferSize); err == nil {
+			err = add(timeline.NewHTTPTransport(conf.HTTPTransport, s))
+		}
+		jsonItems = true
+	}
+
+	if err == nil && conf.UDPTransport != nil {
+		setDefaultProperties(&conf.UDPTransport.CustomSerializerConfig)
+		var s *jsonSerializer.Serializer
+		if s, err = NewJSONSerializer(conf.UDPTransport.SerializerBufferSize); err == nil {
+			err = add(timeline.NewUDPTransport(conf.UDPTransport, s))
+		}
+		jsonItems = true
+	}
+
+	if err == nil && conf.InfluxTransport != nil {
+		err = add(timeline.NewInfluxTransport(conf.InfluxTransport))
+	}
+
+	if err == nil && conf.GraphiteTransport != nil {
+		err = add(timeline.NewGraphiteTransport(conf.GraphiteTransport))
+	}
+
+	if err == nil && conf.StatsDTransport != nil {
+		err = add(timeline.NewStatsDTransport(conf.StatsDTransport))
+	}
+
+	if err == nil && conf.PrometheusRemoteWrite != nil {
+		err = add(timeline.NewPrometheusRemoteWriteTransport(conf.PrometheusRemoteWrite))
+	}
+
+	if err == nil && conf.OTLPTransport != nil {
+		err = add(timeline.NewOTLPTransport(conf.OTLPTransport))
+	}
+
+	if err == nil && conf.ElasticsearchTransport != nil {
+		setDefaultProperties(&conf.ElasticsearchTransport.CustomSerializerConfig)
+		var s *jsonSerializer.Serializer
+		if s, err = NewJSONSerializer(conf.ElasticsearchTransport.SerializerBufferSize); err == nil {
+			err = add(timeline.NewElasticsearchTransport(conf.ElasticsearchTransport, s))
+		}
+		jsonItems = true
+	}
+
+	if err == nil && conf.UnixTransport != nil {
+		if conf.Serialization == SerializationJSON {
+			setDefaultProperties(&conf.UnixTransport.CustomSerializerConfig)
+			var s *jsonSerializer.Serializer
+			if s, err = NewJSONSerializer(conf.UnixTransport.SerializerBufferSize); err == nil {
+				err = add(timeline.NewUnixTransport(conf.UnixTransport, s))
+			}
+			jsonItems = true
+		} else {
+			err = add(timeline.NewUnixTransport(conf.UnixTransport, nil))
+		}
+	}
+
+	if err == nil && conf.FileTransport != nil {
+		if conf.Serialization == SerializationJSON {
+			setDefaultProperties(&conf.FileTransport.CustomSerializerConfig)
+			var s *jsonSerializer.Serializer
+			if s, err = NewJSONSerializer(conf.FileTransport.SerializerBufferSize); err == nil {
+				err = add(timeline.NewFileTransport(conf.FileTransport, s))
+			}
+			jsonItems = true
+		} else {
+			err = add(timeline.NewFileTransport(conf.FileTransport, nil))
+		}
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(transports) != 1 {
+		return nil, false, fmt.Errorf("expected exactly one configured transport, found %d", len(transports))
+	}
+
+	return transports[0], jsonItems, nil
+}
+
+// NewPipeline - creates the transport and the manager using the configuration
+func NewPipeline(conf *Config, manualMode bool) (*Pipeline, error) {
+
+	transport, jsonItems, err := NewTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPipelineWithTransport(conf, transport, jsonItems, manualMode)
+}
+
+// NewPipelineWithTransport - creates the manager using the given transport (the data transformer defaults are set in the configuration)
+func NewPipelineWithTransport(conf *Config, transport timeline.Transport, jsonItems, manualMode bool) (*Pipeline, error) {
+
+	dtc := conf.DataTransformer
+	if dtc == nil {
+		dtc = &timeline.DataTransformerConfig{}
+	}
+
+	if dtc.CycleDuration.Duration <= 0 {
+		dtc.CycleDuration = *funks.ForceNewStringDuration("15s")
+	}
+
+	if len(dtc.HashingAlgorithm) == 0 {
+		dtc.HashingAlgorithm = hashing.SHAKE128
+		dtc.HashSize = defaultHashSize
+	}
+
+	conf.DataTransformer = dtc
+
+	manager, err := timeline.NewManager(transport, timeline.NewFlattener(dtc), timeline.NewAccumulator(dtc), conf.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	err = manager.Start(manualMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pipeline{
+		Manager:   manager,
+		Transport: transport,
+		JSONItems: jsonItems,
+	}, nil
+}
+
+// NewJSONSerializer - creates the json serializer with the point schema
+func NewJSONSerializer(bufferSize int) (*jsonSerializer.Serializer, error) {
+
+	s := jsonSerializer.New(bufferSize)
+
+	err := s.Add(JSONPointSchema, jsonSerializer.NumberPoint{}, "metric", defaultValueProperty, defaultTimestampProperty, "tags")
+	if err != nil {
+		return nil, fmt.Errorf("error creating the json serializer: %s", err.Error())
+	}
+
+	return s, nil
+}
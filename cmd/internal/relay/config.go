@@ -0,0 +1,229 @@
+package relay
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/timeline"
+	"github.com/uol/timeline/cmd/internal/cli"
+)
+
+/**
+* The relay configuration: the listeners, the rules and the queue (the transport is configured like the other command line tools).
+* @author rnojiri
+**/
+
+const (
+	// ProtocolTelnet - the opentsdb telnet "put" lines listener
+	ProtocolTelnet string = "telnet"
+
+	// ProtocolHTTP - the opentsdb /api/put json listener
+	ProtocolHTTP string = "http"
+
+	// ModeSend - the points are sent as they are
+	ModeSend string = "send"
+
+	// ModeFlatten - the points are flattened using the rule operations (comma separated)
+	ModeFlatten string = "flatten"
+
+	// ModeAccumulate - the points are counted by series
+	ModeAccumulate string = "accumulate"
+
+	// ModeDrop - the points are discarded
+	ModeDrop string = "drop"
+
+	defaultHTTPEndpoint    string        = "/api/put"
+	defaultMaxLineSize     int           = 64 * 1024
+	defaultMaxBodySize     int           = 4 * 1024 * 1024
+	defaultQueueSize       int           = 10000
+	defaultFlushInterval   time.Duration = time.Second
+	defaultShutdownTimeout time.Duration = 10 * time.Second
+)
+
+// Config - the relay tool configuration
+type Config struct {
+	cli.Config
+	Relay *RelayConfig `json:"relay,omitempty"`
+}
+
+// RelayConfig - the relay configuration
+type RelayConfig struct {
+	Listeners       []ListenerConfig `json:"listeners,omitempty"`
+	Rules           []RuleConfig     `json:"rules,omitempty"`
+	QueueSize       int              `json:"queueSize,omitempty"`
+	FlushInterval   funks.Duration   `json:"flushInterval,omitempty"`
+	ShutdownTimeout funks.Duration   `json:"shutdownTimeout,omitempty"`
+}
+
+// ListenerConfig - a telnet or http listener configuration
+type ListenerConfig struct {
+	Protocol       string         `json:"protocol,omitempty"`
+	Address        string         `json:"address,omitempty"`
+	Endpoint       string         `json:"endpoint,omitempty"`
+	MaxLineSize    int            `json:"maxLineSize,omitempty"`
+	MaxBodySize    int            `json:"maxBodySize,omitempty"`
+	ReadTimeout    funks.Duration `json:"readTimeout,omitempty"`
+	EnqueueTimeout funks.Duration `json:"enqueueTimeout,omitempty"`
+}
+
+// RuleConfig - a rule applied to the metrics matching the regular expression (the first matching rule is used)
+type RuleConfig struct {
+	Metric    string         `json:"metric,omitempty"`
+	Mode      string         `json:"mode,omitempty"`
+	Operation string         `json:"operation,omitempty"`
+	TTL       funks.Duration `json:"ttl,omitempty"`
+}
+
+// rule - a compiled rule
+type rule struct {
+	metric     *regexp.Regexp
+	mode       string
+	operations []timeline.FlatOperation
+	ttl        time.Duration
+}
+
+// LoadConfig - loads the relay configuration from a toml or json file
+func LoadConfig(path string) (*Config, error) {
+
+	conf := &Config{}
+
+	err := cli.DecodeFile(path, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conf.Config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.Relay == nil {
+		return nil, fmt.Errorf("no relay configuration found")
+	}
+
+	err = conf.Relay.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// Validate - validates the relay configuration and sets the default values
+func (c *RelayConfig) Validate() error {
+
+	if len(c.Listeners) == 0 {
+		return fmt.Errorf("no listener was configured")
+	}
+
+	for i := range c.Listeners {
+		if err := c.Listeners[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.QueueSize < 0 {
+		return fmt.Errorf("invalid queue size: %d", c.QueueSize)
+	}
+
+	if c.QueueSize == 0 {
+		c.QueueSize = defaultQueueSize
+	}
+
+	if c.FlushInterval.Duration < 0 {
+		return fmt.Errorf("invalid flush interval: %s", c.FlushInterval)
+	}
+
+	if c.FlushInterval.Duration == 0 {
+		c.FlushInterval.Duration = defaultFlushInterval
+	}
+
+	if c.ShutdownTimeout.Duration <= 0 {
+		c.ShutdownTimeout.Duration = defaultShutdownTimeout
+	}
+
+	return nil
+}
+
+// Validate - validates the listener configuration and sets the default values
+func (c *ListenerConfig) Validate() error {
+
+	c.Protocol = strings.ToLower(c.Protocol)
+
+	if c.Protocol != ProtocolTelnet && c.Protocol != ProtocolHTTP {
+		return fmt.Errorf("invalid listener protocol: %s", c.Protocol)
+	}
+
+	if len(c.Address) == 0 {
+		return fmt.Errorf("no address was configured for the %s listener", c.Protocol)
+	}
+
+	if c.MaxLineSize < 0 || c.MaxBodySize < 0 {
+		return fmt.Errorf("invalid maximum size on the %s listener: %s", c.Protocol, c.Address)
+	}
+
+	if c.ReadTimeout.Duration < 0 || c.EnqueueTimeout.Duration < 0 {
+		return fmt.Errorf("invalid timeout on the %s listener: %s", c.Protocol, c.Address)
+	}
+
+	if c.MaxLineSize == 0 {
+		c.MaxLineSize = defaultMaxLineSize
+	}
+
+	if c.MaxBodySize == 0 {
+		c.MaxBodySize = defaultMaxBodySize
+	}
+
+	if len(c.Endpoint) == 0 {
+		c.Endpoint = defaultHTTPEndpoint
+	}
+
+	return nil
+}
+
+// compileRules - compiles the configured rules
+func compileRules(configs []RuleConfig) ([]*rule, error) {
+
+	rules := make([]*rule, len(configs))
+
+	for i, c := range configs {
+
+		r := &rule{
+			mode: strings.ToLower(c.Mode),
+			ttl:  c.TTL.Duration,
+		}
+
+		if len(r.mode) == 0 {
+			r.mode = ModeSend
+		}
+
+		var err error
+
+		switch r.mode {
+		case ModeSend, ModeAccumulate, ModeDrop:
+		case ModeFlatten:
+			r.operations, err = cli.ParseFlatOperations(c.Operation)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("invalid rule mode: %s", c.Mode)
+		}
+
+		if r.ttl < 0 {
+			return nil, fmt.Errorf("invalid rule ttl: %s", c.TTL)
+		}
+
+		r.metric, err = regexp.Compile(c.Metric)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule metric \"%s\": %s", c.Metric, err.Error())
+		}
+
+		rules[i] = r
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,88 @@
+package relay
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/uol/logh"
+	"github.com/uol/timeline"
+)
+
+/**
+* The opentsdb /api/put listener, accepts a json point or an array of points.
+* The request is answered with 503 when the queue stays full until the enqueue timeout (the producer may retry).
+* @author rnojiri
+**/
+
+// newHTTPServer - creates the http server for the listener
+func (r *Relay) newHTTPServer(conf *ListenerConfig) *http.Server {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(conf.Endpoint, func(w http.ResponseWriter, req *http.Request) {
+		r.handlePut(w, req, conf)
+	})
+
+	return &http.Server{
+		Addr:        conf.Address,
+		Handler:     mux,
+		ReadTimeout: conf.ReadTimeout.Duration,
+	}
+}
+
+// serveHTTP - serves the http requests until the server is shut down
+func (r *Relay) serveHTTP(server *http.Server, listener net.Listener) {
+
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		r.logError(err, "error serving http")
+	}
+}
+
+// handlePut - handles the /api/put request
+func (r *Relay) handlePut(w http.ResponseWriter, req *http.Request, conf *ListenerConfig) {
+
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body io.Reader = http.MaxBytesReader(w, req.Body, int64(conf.MaxBodySize))
+
+	if strings.EqualFold(req.Header.Get("Content-Encoding"), "gzip") {
+		gzipReader, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gzipReader.Close()
+		body = io.LimitReader(gzipReader, int64(conf.MaxBodySize))
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, err := timeline.ParseOpenTSDBJSON(data)
+	if err != nil {
+		atomic.AddUint64(&r.stats.Invalid, 1)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !r.enqueue(items, conf.EnqueueTimeout.Duration) {
+		if logh.WarnEnabled {
+			r.loggers.Warn().Int("points", len(items)).Msg("queue is full, request rejected")
+		}
+		http.Error(w, "the relay queue is full", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,394 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uol/logh"
+	openTSDBSerializer "github.com/uol/serializer/opentsdb"
+	"github.com/uol/timeline"
+	"github.com/uol/timeline/cmd/internal/cli"
+)
+
+/**
+* Receives the opentsdb points from the listeners and forwards them using the configured transport.
+* The points are queued and consumed by only one dispatcher, when the transport is slow the queue fills up and the listeners stop reading.
+* @author rnojiri
+**/
+
+// Stats - the relay statistics
+type Stats struct {
+	Received    uint64
+	Invalid     uint64
+	Rejected    uint64
+	Dropped     uint64
+	Failed      uint64
+	Connections int64
+}
+
+// accumulatedSeries - the hash of a series stored in the accumulator
+type accumulatedSeries struct {
+	hash       string
+	ttl        time.Duration
+	lastUpdate time.Time
+}
+
+// Relay - the relay daemon
+type Relay struct {
+	configuration *RelayConfig
+	pipeline      *cli.Pipeline
+	rules         []*rule
+	queue         chan []*openTSDBSerializer.ArrayItem
+	hashes        map[string]*accumulatedSeries
+	listeners     []net.Listener
+	servers       []*http.Server
+	addresses     map[string][]string
+	connections   sync.Map
+	handlers      sync.WaitGroup
+	closed        bool
+	closeLock     sync.RWMutex
+	dispatcher    chan struct{}
+	cycleDuration time.Duration
+	stats         Stats
+	loggers       *logh.ContextualLogger
+}
+
+// New - creates a new relay using the pipeline (it must be in manual mode)
+func New(configuration *Config, pipeline *cli.Pipeline) (*Relay, error) {
+
+	if configuration == nil || configuration.Relay == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	if pipeline == nil {
+		return nil, fmt.Errorf("no pipeline was configured")
+	}
+
+	rules, err := compileRules(configuration.Relay.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	cycleDuration := time.Duration(0)
+	if configuration.DataTransformer != nil {
+		cycleDuration = configuration.DataTransformer.CycleDuration.Duration
+	}
+
+	return &Relay{
+		configuration: configuration.Relay,
+		pipeline:      pipeline,
+		rules:         rules,
+		queue:         make(chan []*openTSDBSerializer.ArrayItem, configuration.Relay.QueueSize),
+		hashes:        map[string]*accumulatedSeries{},
+		addresses:     map[string][]string{},
+		dispatcher:    make(chan struct{}),
+		cycleDuration: cycleDuration,
+		loggers:       logh.CreateContextualLogger("pkg", "timeline/relay"),
+	}, nil
+}
+
+// Start - starts the dispatcher and all configured listeners
+func (r *Relay) Start() error {
+
+	for i := range r.configuration.Listeners {
+
+		conf := &r.configuration.Listeners[i]
+
+		listener, err := net.Listen("tcp", conf.Address)
+		if err != nil {
+			r.closeListeners()
+			for _, s := range r.servers {
+				s.Close()
+			}
+			return err
+		}
+
+		if conf.Protocol == ProtocolTelnet {
+			r.listeners = append(r.listeners, listener)
+			r.handlers.Add(1)
+			go r.acceptTelnet(listener, conf)
+		} else {
+			server := r.newHTTPServer(conf)
+			r.servers = append(r.servers, server)
+			go r.serveHTTP(server, listener)
+		}
+
+		r.addresses[conf.Protocol] = append(r.addresses[conf.Protocol], listener.Addr().String())
+
+		if logh.InfoEnabled {
+			r.loggers.Info().Str("address", listener.Addr().String()).Msgf("%s listener started", conf.Protocol)
+		}
+	}
+
+	go r.dispatch()
+
+	return nil
+}
+
+// Addresses - returns the listening addresses by protocol (useful when the configured port is zero)
+func (r *Relay) Addresses() map[string][]string {
+
+	return r.addresses
+}
+
+// GetStats - returns the relay statistics
+func (r *Relay) GetStats() Stats {
+
+	return Stats{
+		Received:    atomic.LoadUint64(&r.stats.Received),
+		Invalid:     atomic.LoadUint64(&r.stats.Invalid),
+		Rejected:    atomic.LoadUint64(&r.stats.Rejected),
+		Dropped:     atomic.LoadUint64(&r.stats.Dropped),
+		Failed:      atomic.LoadUint64(&r.stats.Failed),
+		Connections: atomic.LoadInt64(&r.stats.Connections),
+	}
+}
+
+// enqueue - enqueues the points, waits for the queue if the timeout is zero (returns false if the points were rejected)
+func (r *Relay) enqueue(items []*openTSDBSerializer.ArrayItem, timeout time.Duration) bool {
+
+	if len(items) == 0 {
+		return true
+	}
+
+	r.closeLock.RLock()
+	defer r.closeLock.RUnlock()
+
+	if r.closed {
+		atomic.AddUint64(&r.stats.Rejected, uint64(len(items)))
+		return false
+	}
+
+	if timeout <= 0 {
+		r.queue <- items
+		atomic.AddUint64(&r.stats.Received, uint64(len(items)))
+		return true
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case r.queue <- items:
+		atomic.AddUint64(&r.stats.Received, uint64(len(items)))
+		return true
+	case <-timer.C:
+		atomic.AddUint64(&r.stats.Rejected, uint64(len(items)))
+		return false
+	}
+}
+
+// dispatch - consumes the queue and drives the manager cycles (the manager is used only by this goroutine)
+func (r *Relay) dispatch() {
+
+	flushTicker := time.NewTicker(r.configuration.FlushInterval.Duration)
+	defer flushTicker.Stop()
+
+	var cycle <-chan time.Time
+	if r.cycleDuration > 0 {
+		cycleTicker := time.NewTicker(r.cycleDuration)
+		defer cycleTicker.Stop()
+		cycle = cycleTicker.C
+	}
+
+	defer close(r.dispatcher)
+
+	for {
+		select {
+		case items, ok := <-r.queue:
+			if !ok {
+				r.pipeline.Manager.ProcessCycle()
+				r.flush()
+				return
+			}
+			for _, item := range items {
+				r.process(item)
+			}
+		case <-flushTicker.C:
+			r.flush()
+			r.expireHashes()
+		case <-cycle:
+			r.pipeline.Manager.ProcessCycle()
+		}
+	}
+}
+
+// process - applies the first matching rule to the point
+func (r *Relay) process(item *openTSDBSerializer.ArrayItem) {
+
+	mode := ModeSend
+	var matched *rule
+
+	for _, rl := range r.rules {
+		if rl.metric.MatchString(item.Metric) {
+			matched = rl
+			mode = rl.mode
+			break
+		}
+	}
+
+	if mode == ModeDrop {
+		atomic.AddUint64(&r.stats.Dropped, 1)
+		return
+	}
+
+	converted, err := r.pipeline.ToItem(item)
+	if err != nil {
+		r.logError(err, "error converting point")
+		atomic.AddUint64(&r.stats.Invalid, 1)
+		return
+	}
+
+	switch mode {
+	case ModeFlatten:
+		err = r.pipeline.Flatten(matched.operations, converted)
+	case ModeAccumulate:
+		err = r.accumulate(item, converted, matched.ttl)
+	default:
+		r.pipeline.Manager.Send(converted)
+	}
+
+	if err != nil {
+		r.logError(err, "error processing point")
+		atomic.AddUint64(&r.stats.Invalid, 1)
+	}
+}
+
+// accumulate - stores the series when needed (again if its ttl expired) and increments it
+func (r *Relay) accumulate(item *openTSDBSerializer.ArrayItem, converted interface{}, ttl time.Duration) error {
+
+	key := cli.SeriesKey(item)
+	now := time.Now()
+
+	if series, ok := r.hashes[key]; ok {
+
+		err := r.pipeline.Manager.IncrementAccumulatedData(series.hash)
+		if err != timeline.ErrNotStored {
+			series.lastUpdate = now
+			return err
+		}
+
+		delete(r.hashes, key)
+	}
+
+	hash, err := r.pipeline.Manager.StoreDataToAccumulate(ttl, converted)
+	if err != nil {
+		return err
+	}
+
+	r.hashes[key] = &accumulatedSeries{
+		hash:       hash,
+		ttl:        ttl,
+		lastUpdate: now,
+	}
+
+	return r.pipeline.Manager.IncrementAccumulatedData(hash)
+}
+
+// expireHashes - forgets the series not updated after the accumulator expired them (the series without ttl are never expired)
+func (r *Relay) expireHashes() {
+
+	now := time.Now()
+
+	for key, series := range r.hashes {
+
+		// the accumulator checks the ttl periodically after the last cycle, so it is removed there before this limit
+		if series.ttl > 0 && now.Sub(series.lastUpdate) > 2*series.ttl+r.cycleDuration {
+			delete(r.hashes, key)
+		}
+	}
+}
+
+// flush - sends the buffered points
+func (r *Relay) flush() {
+
+	if err := r.pipeline.Manager.SendData(); err != nil {
+		atomic.AddUint64(&r.stats.Failed, 1)
+		r.logError(err, "error sending data")
+	}
+}
+
+// closeListeners - closes the telnet listeners
+func (r *Relay) closeListeners() {
+
+	for _, l := range r.listeners {
+		l.Close()
+	}
+}
+
+// Shutdown - stops the listeners, sends the queued points and closes the transport
+func (r *Relay) Shutdown() error {
+
+	if logh.InfoEnabled {
+		r.loggers.Info().Msg("shutting down...")
+	}
+
+	deadline := time.Now().Add(r.configuration.ShutdownTimeout.Duration)
+	errs := []error{}
+
+	r.closeListeners()
+
+	for _, s := range r.servers {
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		if err := s.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+		cancel()
+	}
+
+	r.connections.Range(func(key, _ interface{}) bool {
+		key.(net.Conn).Close()
+		return true
+	})
+
+	handlersDone := make(chan struct{})
+
+	go func() {
+		r.handlers.Wait()
+		r.closeLock.Lock()
+		r.closed = true
+		close(r.queue)
+		r.closeLock.Unlock()
+		<-r.dispatcher
+		close(handlersDone)
+	}()
+
+	select {
+	case <-handlersDone:
+	case <-time.After(time.Until(deadline)):
+		errs = append(errs, fmt.Errorf("timeout sending the queued points"))
+	}
+
+	r.pipeline.Transport.Close()
+
+	stats := r.GetStats()
+
+	if logh.InfoEnabled {
+		r.loggers.Info().
+			Uint64("received", stats.Received).
+			Uint64("invalid", stats.Invalid).
+			Uint64("rejected", stats.Rejected).
+			Uint64("dropped", stats.Dropped).
+			Uint64("failed", stats.Failed).
+			Msg("relay terminated")
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
+
+// logError - logs an error
+func (r *Relay) logError(err error, msg string) {
+
+	if logh.ErrorEnabled {
+		r.loggers.Error().Err(err).Msg(msg)
+	}
+}
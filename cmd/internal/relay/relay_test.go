@@ -0,0 +1,306 @@
+package relay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+	openTSDBSerializer "github.com/uol/serializer/opentsdb"
+	"github.com/uol/timeline"
+	"github.com/uol/timeline/cmd/internal/cli"
+)
+
+/**
+* The relay tests.
+* @author rnojiri
+**/
+
+// testWriter - a synchronized writer (blocks the writes while the gate is closed)
+type testWriter struct {
+	buffer  bytes.Buffer
+	gate    chan struct{}
+	blocked chan struct{}
+	sync.Mutex
+}
+
+// Write - waits for the gate and writes to the buffer
+func (w *testWriter) Write(p []byte) (int, error) {
+
+	if w.gate != nil {
+		select {
+		case w.blocked <- struct{}{}:
+		default:
+		}
+		<-w.gate
+	}
+
+	w.Lock()
+	defer w.Unlock()
+
+	return w.buffer.Write(p)
+}
+
+// String - returns the written data
+func (w *testWriter) String() string {
+
+	w.Lock()
+	defer w.Unlock()
+
+	return w.buffer.String()
+}
+
+// createConfig - creates a relay configuration with a telnet and a http listener
+func createConfig(t *testing.T, rules ...RuleConfig) *Config {
+
+	conf := &Config{
+		Relay: &RelayConfig{
+			Listeners: []ListenerConfig{
+				{Protocol: ProtocolTelnet, Address: "127.0.0.1:0"},
+				{Protocol: ProtocolHTTP, Address: "127.0.0.1:0"},
+			},
+			Rules:         rules,
+			FlushInterval: funks.Duration{Duration: time.Hour},
+		},
+	}
+
+	if err := conf.Config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conf.Relay.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	return conf
+}
+
+// createRelay - creates and starts a relay writing the opentsdb lines to the writer
+func createRelay(t *testing.T, conf *Config, writer *testWriter) *Relay {
+
+	transport, err := timeline.NewWriterTransport(&timeline.FileTransportConfig{
+		DefaultTransportConfig: timeline.DefaultTransportConfig{
+			BatchSendInterval:    funks.Duration{Duration: time.Hour},
+			RequestTimeout:       funks.Duration{Duration: time.Second},
+			TransportBufferSize:  100,
+			SerializerBufferSize: 256,
+		},
+	}, writer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pipeline, err := cli.NewPipelineWithTransport(&conf.Config, transport, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New(conf, pipeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+// put - posts the json points to the http listener
+func put(t *testing.T, r *Relay, body string, compress bool) int {
+
+	var buffer bytes.Buffer
+
+	if compress {
+		gzipWriter := gzip.NewWriter(&buffer)
+		gzipWriter.Write([]byte(body))
+		gzipWriter.Close()
+	} else {
+		buffer.WriteString(body)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "http://"+r.Addresses()[ProtocolHTTP][0]+defaultHTTPEndpoint, &buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+
+	return res.StatusCode
+}
+
+// TestTelnet - tests the put lines and the version and exit commands
+func TestTelnet(t *testing.T) {
+
+	writer := &testWriter{}
+	r := createRelay(t, createConfig(t), writer)
+
+	conn, err := net.Dial("tcp", r.Addresses()[ProtocolTelnet][0])
+	if !assert.NoError(t, err, "expected no error connecting") {
+		r.Shutdown()
+		return
+	}
+
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	conn.Write([]byte("version\n"))
+	line, err := reader.ReadString('\n')
+	assert.NoError(t, err, "expected the version reply")
+	assert.Equal(t, "timeline relay\n", line, "expected the version")
+
+	conn.Write([]byte("put cpu 1600000000 10 host=a\nput invalid\n"))
+	line, err = reader.ReadString('\n')
+	assert.NoError(t, err, "expected the error reply")
+	assert.True(t, strings.HasPrefix(line, "put: "), "expected the opentsdb error format: %s", line)
+
+	conn.Write([]byte("exit\n"))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = reader.ReadString('\n')
+	assert.Error(t, err, "expected the connection closed by the exit command")
+
+	assert.NoError(t, r.Shutdown(), "expected no error shutting down")
+	assert.Equal(t, "put cpu 1600000000 10 host=a\n", writer.String(), "expected the relayed point")
+
+	stats := r.GetStats()
+	assert.Equal(t, uint64(1), stats.Received, "expected one received point")
+	assert.Equal(t, uint64(1), stats.Invalid, "expected one invalid point")
+}
+
+// TestHTTPGzip - tests the gzip compressed json points
+func TestHTTPGzip(t *testing.T) {
+
+	writer := &testWriter{}
+	r := createRelay(t, createConfig(t), writer)
+
+	body := `[{"metric":"mem","timestamp":1600000000,"value":1,"tags":{"host":"a"}},{"metric":"mem","timestamp":1600000001,"value":2,"tags":{"host":"a"}}]`
+
+	assert.Equal(t, http.StatusNoContent, put(t, r, body, true), "expected the points accepted")
+	assert.Equal(t, http.StatusBadRequest, put(t, r, "not json", false), "expected the invalid body rejected")
+
+	assert.NoError(t, r.Shutdown(), "expected no error shutting down")
+	assert.Equal(t, "put mem 1600000000 1 host=a\nput mem 1600000001 2 host=a\n", writer.String(), "expected the relayed points")
+}
+
+// TestHTTPBackpressure - tests the 503 status when the queue stays full until the enqueue timeout
+func TestHTTPBackpressure(t *testing.T) {
+
+	writer := &testWriter{
+		gate:    make(chan struct{}),
+		blocked: make(chan struct{}, 1),
+	}
+
+	conf := createConfig(t)
+	conf.Relay.QueueSize = 1
+	conf.Relay.FlushInterval = funks.Duration{Duration: 20 * time.Millisecond}
+	conf.Relay.Listeners[1].EnqueueTimeout = funks.Duration{Duration: 100 * time.Millisecond}
+
+	r := createRelay(t, conf, writer)
+
+	point := `{"metric":"cpu","timestamp":1600000000,"value":1,"tags":{"host":"a"}}`
+
+	assert.Equal(t, http.StatusNoContent, put(t, r, point, false), "expected the first point accepted")
+
+	select {
+	case <-writer.blocked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the dispatcher blocked by the writer")
+	}
+
+	assert.Equal(t, http.StatusNoContent, put(t, r, point, false), "expected the point queued")
+	assert.Equal(t, http.StatusServiceUnavailable, put(t, r, point, false), "expected the point rejected by the full queue")
+
+	close(writer.gate)
+
+	assert.NoError(t, r.Shutdown(), "expected no error shutting down")
+	assert.Equal(t, 2, strings.Count(writer.String(), "put cpu"), "expected only the accepted points")
+	assert.Equal(t, uint64(1), r.GetStats().Rejected, "expected one rejected point")
+}
+
+// TestShutdownDrain - tests if the queued points are sent by the shutdown
+func TestShutdownDrain(t *testing.T) {
+
+	writer := &testWriter{}
+	r := createRelay(t, createConfig(t), writer)
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusNoContent, put(t, r, `{"metric":"cpu","timestamp":1600000000,"value":1,"tags":{"host":"a"}}`, false), "expected the point accepted")
+	}
+
+	assert.Empty(t, writer.String(), "expected nothing sent before the shutdown")
+	assert.NoError(t, r.Shutdown(), "expected no error shutting down")
+	assert.Equal(t, 10, strings.Count(writer.String(), "put cpu 1600000000 1 host=a\n"), "expected all queued points sent")
+	assert.False(t, r.enqueue([]*openTSDBSerializer.ArrayItem{{Metric: "cpu"}}, 0), "expected the points rejected after the shutdown")
+}
+
+// TestRuleModes - tests the send, flatten, accumulate and drop rules
+func TestRuleModes(t *testing.T) {
+
+	writer := &testWriter{}
+	r := createRelay(t, createConfig(t,
+		RuleConfig{Metric: `^drop\.`, Mode: ModeDrop},
+		RuleConfig{Metric: `^flat\.`, Mode: ModeFlatten, Operation: "sum"},
+		RuleConfig{Metric: `^acc\.`, Mode: ModeAccumulate},
+	), writer)
+
+	body := `[` +
+		`{"metric":"send.cpu","timestamp":1600000000,"value":7,"tags":{"host":"a"}},` +
+		`{"metric":"drop.cpu","timestamp":1600000000,"value":1,"tags":{"host":"a"}},` +
+		`{"metric":"flat.cpu","timestamp":1600000000,"value":2,"tags":{"host":"a"}},` +
+		`{"metric":"flat.cpu","timestamp":1600000000,"value":3,"tags":{"host":"a"}},` +
+		`{"metric":"acc.cpu","timestamp":1600000000,"value":1,"tags":{"host":"a"}},` +
+		`{"metric":"acc.cpu","timestamp":1600000000,"value":1,"tags":{"host":"a"}},` +
+		`{"metric":"acc.cpu","timestamp":1600000000,"value":1,"tags":{"host":"a"}}` +
+		`]`
+
+	assert.Equal(t, http.StatusNoContent, put(t, r, body, false), "expected the points accepted")
+	assert.NoError(t, r.Shutdown(), "expected no error shutting down")
+
+	output := writer.String()
+
+	assert.Contains(t, output, "put send.cpu 1600000000 7 host=a\n", "expected the point sent as it is")
+	assert.Regexp(t, `put flat\.cpu \d+ 5 host=a\n`, output, "expected the flattened sum")
+	assert.Regexp(t, `put acc\.cpu \d+ 3 host=a\n`, output, "expected the accumulated count")
+	assert.NotContains(t, output, "drop.cpu", "expected the dropped point")
+	assert.Equal(t, 3, strings.Count(output, "\n"), "expected only three points")
+	assert.Equal(t, uint64(1), r.GetStats().Dropped, "expected one dropped point")
+}
+
+// TestAccumulatedHashExpiration - tests if the relay forgets the series expired by the accumulator
+func TestAccumulatedHashExpiration(t *testing.T) {
+
+	conf := createConfig(t, RuleConfig{Metric: `.`, Mode: ModeAccumulate, TTL: funks.Duration{Duration: 50 * time.Millisecond}})
+	conf.Relay.FlushInterval = funks.Duration{Duration: 20 * time.Millisecond}
+	conf.DataTransformer = &timeline.DataTransformerConfig{
+		CycleDuration: funks.Duration{Duration: 50 * time.Millisecond},
+	}
+
+	r := createRelay(t, conf, &testWriter{})
+
+	assert.Equal(t, http.StatusNoContent, put(t, r, `{"metric":"cpu","timestamp":1600000000,"value":1,"tags":{"host":"a"}}`, false), "expected the point accepted")
+
+	<-time.After(500 * time.Millisecond)
+
+	assert.NoError(t, r.Shutdown(), "expected no error shutting down")
+
+	// the dispatcher is terminated after the shutdown
+	assert.Empty(t, r.hashes, "expected the expired series removed")
+}
@@ -0,0 +1,101 @@
+package relay
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/uol/logh"
+	openTSDBSerializer "github.com/uol/serializer/opentsdb"
+	"github.com/uol/timeline"
+)
+
+/**
+* The opentsdb telnet listener, accepts "put" lines (the errors are answered like the opentsdb does).
+* The connection is not read while the queue is full.
+* @author rnojiri
+**/
+
+const (
+	telnetVersionCommand string = "version"
+	telnetExitCommand    string = "exit"
+	telnetVersionReply   string = "timeline relay\n"
+)
+
+// acceptTelnet - accepts the telnet connections until the listener is closed
+func (r *Relay) acceptTelnet(listener net.Listener, conf *ListenerConfig) {
+
+	defer r.handlers.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if castedErr, ok := err.(net.Error); ok && castedErr.Temporary() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return
+		}
+
+		r.connections.Store(conn, struct{}{})
+		r.handlers.Add(1)
+
+		go r.handleTelnet(conn, conf)
+	}
+}
+
+// handleTelnet - reads the lines from the connection
+func (r *Relay) handleTelnet(conn net.Conn, conf *ListenerConfig) {
+
+	atomic.AddInt64(&r.stats.Connections, 1)
+
+	defer func() {
+		conn.Close()
+		r.connections.Delete(conn)
+		atomic.AddInt64(&r.stats.Connections, -1)
+		r.handlers.Done()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), conf.MaxLineSize)
+
+	for {
+		if conf.ReadTimeout.Duration > 0 {
+			conn.SetReadDeadline(time.Now().Add(conf.ReadTimeout.Duration))
+		}
+
+		if !scanner.Scan() {
+			break
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+
+		switch line {
+		case telnetVersionCommand:
+			conn.Write([]byte(telnetVersionReply))
+			continue
+		case telnetExitCommand:
+			return
+		}
+
+		item, err := timeline.ParseOpenTSDBLine(line)
+		if err != nil {
+			atomic.AddUint64(&r.stats.Invalid, 1)
+			conn.Write([]byte("put: " + err.Error() + "\n"))
+			continue
+		}
+
+		if !r.enqueue([]*openTSDBSerializer.ArrayItem{item}, 0) {
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil && logh.DebugEnabled {
+		r.loggers.Debug().Str("address", conn.RemoteAddr().String()).Err(err).Msg("telnet connection closed")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/uol/timeline/cmd/internal/cli"
+	"github.com/uol/timeline/cmd/internal/relay"
+)
+
+/**
+* Relays the opentsdb telnet lines and /api/put json points using the configured transport, flattener and accumulator rules.
+* Usage: timeline-relay -config relay.toml
+* @author rnojiri
+**/
+
+func main() {
+
+	configFile := flag.String("config", "", "the toml or json configuration file")
+	logLevel := flag.String("log-level", "info", "the log level (debug, info, warn, error or silent)")
+
+	flag.Parse()
+
+	if len(*configFile) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: timeline-relay -config <file> [options]")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	cli.ConfigureLogger(*logLevel)
+
+	conf, err := relay.LoadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+
+	pipeline, err := cli.NewPipeline(&conf.Config, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error creating the transport: %s\n", err.Error())
+		os.Exit(2)
+	}
+
+	r, err := relay.New(conf, pipeline)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+
+	if err := r.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "error starting the relay: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	<-signals
+
+	if err := r.Shutdown(); err != nil {
+		fmt.Fprintf(os.Stderr, "error shutting down the relay: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
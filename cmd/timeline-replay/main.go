@@ -0,0 +1,211 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	openTSDBSerializer "github.com/uol/serializer/opentsdb"
+	"github.com/uol/timeline/cmd/internal/cli"
+)
+
+/**
+* Replays the recorded opentsdb telnet lines or json payloads using the configured transport.
+* Usage: timeline-replay -config conf.toml [-rate 100] [-shift 24h] [-metric regexp] [-dry-run] file1 [file2.gz ...]
+* @author rnojiri
+**/
+
+// replayer - replays the points from the files
+type replayer struct {
+	pipeline  *cli.Pipeline
+	shift     time.Duration
+	filter    *regexp.Regexp
+	dryRun    bool
+	batchSize int
+	limiter   <-chan time.Time
+	stdout    io.Writer
+	stderr    io.Writer
+	pending   int
+	sent      int
+	skipped   int
+	invalid   int
+	failures  int
+}
+
+// replay - replays one point
+func (r *replayer) replay(item *openTSDBSerializer.ArrayItem) {
+
+	if r.filter != nil && !r.filter.MatchString(item.Metric) {
+		r.skipped++
+		return
+	}
+
+	item.Timestamp = cli.ShiftTimestamp(item.Timestamp, r.shift)
+
+	converted, err := r.pipeline.ToItem(item)
+	if err != nil {
+		fmt.Fprintf(r.stderr, "error converting point \"%s\": %s\n", item.Metric, err.Error())
+		r.invalid++
+		return
+	}
+
+	if r.dryRun {
+		serialized, err := r.pipeline.Manager.Serialize(converted)
+		if err != nil {
+			fmt.Fprintf(r.stderr, "error serializing point \"%s\": %s\n", item.Metric, err.Error())
+			r.invalid++
+			return
+		}
+		fmt.Fprintln(r.stdout, strings.TrimRight(serialized, "\n"))
+		r.sent++
+		return
+	}
+
+	if r.limiter != nil {
+		<-r.limiter
+	}
+
+	r.pipeline.Manager.Send(converted)
+	r.sent++
+	r.pending++
+
+	if r.pending >= r.batchSize {
+		r.flush()
+	}
+}
+
+// flush - sends the buffered points
+func (r *replayer) flush() {
+
+	if r.pending == 0 {
+		return
+	}
+
+	if err := r.pipeline.Manager.SendData(); err != nil {
+		fmt.Fprintf(r.stderr, "error sending %d points: %s\n", r.pending, err.Error())
+		r.failures += r.pending
+	}
+
+	r.pending = 0
+}
+
+// replayFile - replays all points from the file
+func (r *replayer) replayFile(path string) error {
+
+	file, err := cli.OpenFile(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	lineNumber := 0
+
+	return cli.ReadLines(file, func(line string) bool {
+
+		lineNumber++
+
+		items, err := cli.ParseLine(line)
+		if err != nil {
+			fmt.Fprintf(r.stderr, "%s:%d: %s\n", path, lineNumber, err.Error())
+			r.invalid++
+			return true
+		}
+
+		for _, item := range items {
+			r.replay(item)
+		}
+
+		return true
+	})
+}
+
+func main() {
+
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run - runs the command and returns the exit code (2 for the usage errors and 1 for the invalid or failed points)
+func run(args []string, stdout, stderr io.Writer) int {
+
+	flags := flag.NewFlagSet("timeline-replay", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	configFile := flags.String("config", "", "the toml or json configuration file")
+	rate := flags.Float64("rate", 0, "the maximum number of points sent per second (zero is unlimited)")
+	shift := flags.Duration("shift", 0, "the duration added to the point timestamps (ex: 24h or -1h)")
+	metric := flags.String("metric", "", "replays only the metrics matching this regular expression")
+	dryRun := flags.Bool("dry-run", false, "prints the serialized points instead of sending them")
+	batchSize := flags.Int("batch", 1000, "the number of points sent by batch")
+	logLevel := flags.String("log-level", "error", "the log level (debug, info, warn, error or silent)")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if len(*configFile) == 0 || flags.NArg() == 0 || *batchSize <= 0 || *rate < 0 {
+		fmt.Fprintln(stderr, "usage: timeline-replay -config <file> [options] <file> [file ...]")
+		flags.PrintDefaults()
+		return 2
+	}
+
+	cli.ConfigureLogger(*logLevel)
+
+	conf, err := cli.LoadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+
+	pipeline, err := cli.NewPipeline(conf, true)
+	if err != nil {
+		fmt.Fprintf(stderr, "error creating the transport: %s\n", err.Error())
+		return 2
+	}
+
+	defer pipeline.Transport.Close()
+
+	r := &replayer{
+		pipeline:  pipeline,
+		shift:     *shift,
+		dryRun:    *dryRun,
+		batchSize: *batchSize,
+		stdout:    stdout,
+		stderr:    stderr,
+	}
+
+	if len(*metric) > 0 {
+		r.filter, err = regexp.Compile(*metric)
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid metric filter: %s\n", err.Error())
+			return 2
+		}
+	}
+
+	if interval := time.Duration(float64(time.Second) / *rate); *rate > 0 && interval > 0 && !*dryRun {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		r.limiter = ticker.C
+	}
+
+	for _, path := range flags.Args() {
+		if err := r.replayFile(path); err != nil {
+			fmt.Fprintf(stderr, "error reading \"%s\": %s\n", path, err.Error())
+			r.invalid++
+		}
+	}
+
+	r.flush()
+
+	fmt.Fprintf(stderr, "sent: %d, skipped: %d, invalid: %d, failed: %d\n", r.sent, r.skipped, r.invalid, r.failures)
+
+	if r.invalid > 0 || r.failures > 0 {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/**
+* The timeline-replay command tests.
+* @author rnojiri
+**/
+
+const (
+	testRecording string = "cpu 1600000000 1 host=a\n" +
+		"mem 1600000000 2 host=a\n" +
+		"cpu 1600000001000 3 host=a\n" +
+		"invalid\n"
+)
+
+// testBackend - a http backend storing the received points
+type testBackend struct {
+	server *httptest.Server
+	points chan []map[string]interface{}
+}
+
+// createBackend - creates a http backend accepting all points
+func createBackend(t *testing.T) *testBackend {
+
+	b := &testBackend{
+		points: make(chan []map[string]interface{}, 100),
+	}
+
+	b.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		points := []map[string]interface{}{}
+		if err := json.Unmarshal(body, &points); err != nil {
+			t.Error(err)
+		}
+
+		b.points <- points
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	return b
+}
+
+// received - returns the points received by the backend
+func (b *testBackend) received() []map[string]interface{} {
+
+	result := []map[string]interface{}{}
+
+	for {
+		select {
+		case points := <-b.points:
+			result = append(result, points...)
+		default:
+			return result
+		}
+	}
+}
+
+// createFiles - creates the configuration file using the http transport and the recording file
+func createFiles(t *testing.T, b *testBackend, recording string) (dir, confPath, recordingPath string) {
+
+	host, port, err := net.SplitHostPort(b.server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err = ioutil.TempDir("", "timeline-replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := fmt.Sprintf(`{
+		"backend": {"host": "%s", "port": %s},
+		"httpTransport": {
+			"serviceEndpoint": "/api/put",
+			"method": "POST",
+			"expectedResponseStatus": 204,
+			"batchSendInterval": "1s",
+			"requestTimeout": "1s",
+			"transportBufferSize": 100,
+			"serializerBufferSize": 1024
+		}
+	}`, host, port)
+
+	confPath = filepath.Join(dir, "conf.json")
+	recordingPath = filepath.Join(dir, "recording.txt")
+
+	if err := ioutil.WriteFile(confPath, []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(recordingPath, []byte(recording), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+// runCommand - runs the command replaying the recording and returns the exit code and the outputs
+func runCommand(t *testing.T, b *testBackend, recording string, args ...string) (int, string, string) {
+
+	dir, confPath, recordingPath := createFiles(t, b, recording)
+	defer os.RemoveAll(dir)
+
+	var stdout, stderr bytes.Buffer
+
+	args = append([]string{"-config", confPath, "-log-level", "silent"}, args...)
+	code := run(append(args, recordingPath), &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+// TestReplay - tests the replayed points and the invalid lines
+func TestReplay(t *testing.T) {
+
+	b := createBackend(t)
+	defer b.server.Close()
+
+	code, _, stderr := runCommand(t, b, testRecording)
+	assert.Equal(t, 1, code, "expected failure by the invalid line")
+	assert.Contains(t, stderr, "recording.txt:4", "expected the invalid line number")
+	assert.Contains(t, stderr, "sent: 3, skipped: 0, invalid: 1, failed: 0", "expected the summary")
+	assert.Len(t, b.received(), 3, "expected the valid points replayed")
+}
+
+// TestReplayShift - tests the timestamp shift in seconds and milliseconds
+func TestReplayShift(t *testing.T) {
+
+	b := createBackend(t)
+	defer b.server.Close()
+
+	code, _, stderr := runCommand(t, b, "cpu 1600000000 1 host=a\ncpu 1600000001000 3 host=a\n", "-shift", "1h")
+	assert.Equal(t, 0, code, "expected success: %s", stderr)
+
+	points := b.received()
+	if assert.Len(t, points, 2, "expected two points") {
+		assert.Equal(t, float64(1600003600), points[0]["timestamp"], "expected the shifted seconds")
+		assert.Equal(t, float64(1600003601000), points[1]["timestamp"], "expected the shifted milliseconds")
+	}
+}
+
+// TestReplayMetricFilter - tests if only the matching metrics are replayed
+func TestReplayMetricFilter(t *testing.T) {
+
+	b := createBackend(t)
+	defer b.server.Close()
+
+	code, _, stderr := runCommand(t, b, "cpu 1600000000 1 host=a\nmem 1600000000 2 host=a\ncpu.idle 1600000000 3 host=a\n", "-metric", "^cpu")
+	assert.Equal(t, 0, code, "expected success: %s", stderr)
+	assert.Contains(t, stderr, "sent: 2, skipped: 1", "expected the skipped metric")
+
+	for _, p := range b.received() {
+		assert.True(t, strings.HasPrefix(p["metric"].(string), "cpu"), "expected only the cpu metrics")
+	}
+
+	code, _, _ = runCommand(t, b, testRecording, "-metric", "(")
+	assert.Equal(t, 2, code, "expected the invalid filter rejected")
+}
+
+// TestReplayDryRun - tests if the points are only printed
+func TestReplayDryRun(t *testing.T) {
+
+	b := createBackend(t)
+	defer b.server.Close()
+
+	code, stdout, stderr := runCommand(t, b, "cpu 1600000000 1 host=a\nmem 1600000000 2 host=b\n", "-dry-run", "-shift", "-1h")
+	assert.Equal(t, 0, code, "expected success: %s", stderr)
+	assert.Empty(t, b.received(), "expected nothing sent")
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if assert.Len(t, lines, 2, "expected one printed line by point") {
+
+		point := map[string]interface{}{}
+		if assert.NoError(t, json.Unmarshal([]byte(lines[1]), &point), "expected the serialized point") {
+			assert.Equal(t, "mem", point["metric"], "expected the metric")
+			assert.Equal(t, float64(1599996400), point["timestamp"], "expected the shifted timestamp")
+		}
+	}
+}
+
+// TestReplayRateLimit - tests if the points are sent at the maximum rate
+func TestReplayRateLimit(t *testing.T) {
+
+	b := createBackend(t)
+	defer b.server.Close()
+
+	start := time.Now()
+
+	code, _, stderr := runCommand(t, b, strings.Repeat("cpu 1600000000 1 host=a\n", 6), "-rate", "20", "-batch", "2")
+	assert.Equal(t, 0, code, "expected success: %s", stderr)
+
+	assert.True(t, time.Since(start) >= 250*time.Millisecond, "expected at least 6 ticks of 50ms: %s", time.Since(start))
+	assert.Len(t, b.received(), 6, "expected all points sent")
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	openTSDBSerializer "github.com/uol/serializer/opentsdb"
+	"github.com/uol/timeline"
+	"github.com/uol/timeline/cmd/internal/cli"
+)
+
+/**
+* Sends points using the configured transport, the points are read from the arguments or from the stdin (one per line).
+* Usage: timeline-send -config conf.toml [-mode send|flatten|accumulate] [-operation avg[,max,p99]] ["put cpu now 10 host=a" ...]
+* @author rnojiri
+**/
+
+const (
+	modeSend       string = "send"
+	modeFlatten    string = "flatten"
+	modeAccumulate string = "accumulate"
+)
+
+// sender - sends the points using the selected mode
+type sender struct {
+	pipeline   *cli.Pipeline
+	mode       string
+	operations []timeline.FlatOperation
+	hashes     map[string]string
+	stderr     io.Writer
+	points     int
+	invalid    int
+}
+
+// add - adds one point to the transport, flattener or accumulator
+func (s *sender) add(item *openTSDBSerializer.ArrayItem) {
+
+	converted, err := s.pipeline.ToItem(item)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "error converting point \"%s\": %s\n", item.Metric, err.Error())
+		s.invalid++
+		return
+	}
+
+	switch s.mode {
+	case modeFlatten:
+		err = s.pipeline.Flatten(s.operations, converted)
+	case modeAccumulate:
+		err = s.accumulate(item, converted)
+	default:
+		s.pipeline.Manager.Send(converted)
+	}
+
+	if err != nil {
+		fmt.Fprintf(s.stderr, "error adding point \"%s\": %s\n", item.Metric, err.Error())
+		s.invalid++
+		return
+	}
+
+	s.points++
+}
+
+// accumulate - stores the series once and increments it for each point
+func (s *sender) accumulate(item *openTSDBSerializer.ArrayItem, converted interface{}) error {
+
+	key := cli.SeriesKey(item)
+
+	hash, ok := s.hashes[key]
+	if !ok {
+		var err error
+		hash, err = s.pipeline.Manager.StoreDataToAccumulate(0, converted)
+		if err != nil {
+			return err
+		}
+		s.hashes[key] = hash
+	}
+
+	return s.pipeline.Manager.IncrementAccumulatedData(hash)
+}
+
+// addLine - parses and adds the points from a line
+func (s *sender) addLine(source string, line string) {
+
+	items, err := cli.ParseLine(line)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "%s: %s\n", source, err.Error())
+		s.invalid++
+		return
+	}
+
+	for _, item := range items {
+		s.add(item)
+	}
+}
+
+// deliver - processes the flattener/accumulator cycle and sends the buffered points
+func (s *sender) deliver(timeout time.Duration) error {
+
+	result := make(chan error, 1)
+
+	go func() {
+		s.pipeline.Manager.ProcessCycle()
+		result <- s.pipeline.Manager.SendData()
+	}()
+
+	if timeout <= 0 {
+		return <-result
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("timeout after %s", timeout)
+	}
+}
+
+func main() {
+
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stderr))
+}
+
+// run - runs the command and returns the exit code (2 for the usage errors and 1 for the delivery errors or invalid points)
+func run(args []string, stdin io.Reader, stderr io.Writer) int {
+
+	flags := flag.NewFlagSet("timeline-send", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	configFile := flags.String("config", "", "the toml or json configuration file")
+	mode := flags.String("mode", modeSend, "send the points as they are, flatten them or accumulate them (send, flatten or accumulate)")
+	operation := flags.String("operation", "avg", "the flattener operations used by the flatten mode (comma separated)")
+	timeout := flags.Duration("timeout", 30*time.Second, "the maximum time waiting for the delivery (zero waits forever)")
+	logLevel := flags.String("log-level", "error", "the log level (debug, info, warn, error or silent)")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if len(*configFile) == 0 {
+		fmt.Fprintln(stderr, "usage: timeline-send -config <file> [options] [point ...] (reads the stdin when there are no points)")
+		flags.PrintDefaults()
+		return 2
+	}
+
+	s := &sender{
+		mode:   strings.ToLower(*mode),
+		hashes: map[string]string{},
+		stderr: stderr,
+	}
+
+	switch s.mode {
+	case modeSend, modeFlatten, modeAccumulate:
+	default:
+		fmt.Fprintf(stderr, "invalid mode: %s\n", *mode)
+		return 2
+	}
+
+	var err error
+
+	if s.mode == modeFlatten {
+		s.operations, err = cli.ParseFlatOperations(*operation)
+		if err != nil {
+			fmt.Fprintln(stderr, err.Error())
+			return 2
+		}
+	}
+
+	cli.ConfigureLogger(*logLevel)
+
+	conf, err := cli.LoadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+
+	s.pipeline, err = cli.NewPipeline(conf, true)
+	if err != nil {
+		fmt.Fprintf(stderr, "error creating the transport: %s\n", err.Error())
+		return 2
+	}
+
+	if flags.NArg() > 0 {
+		for i, arg := range flags.Args() {
+			s.addLine(fmt.Sprintf("argument %d", i+1), arg)
+		}
+	} else {
+		lineNumber := 0
+		err = cli.ReadLines(stdin, func(line string) bool {
+			lineNumber++
+			s.addLine(fmt.Sprintf("stdin:%d", lineNumber), line)
+			return true
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "error reading the stdin: %s\n", err.Error())
+			s.invalid++
+		}
+	}
+
+	if err := s.deliver(*timeout); err != nil {
+		fmt.Fprintf(stderr, "error sending the points: %s\n", err.Error())
+		return 1
+	}
+
+	s.pipeline.Transport.Close()
+
+	if s.invalid > 0 {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/**
+* The timeline-send command tests.
+* @author rnojiri
+**/
+
+// testBackend - a http backend storing the received points
+type testBackend struct {
+	server *httptest.Server
+	points chan []map[string]interface{}
+	status int
+}
+
+// createBackend - creates a http backend responding the given status
+func createBackend(t *testing.T, status int) *testBackend {
+
+	b := &testBackend{
+		points: make(chan []map[string]interface{}, 10),
+		status: status,
+	}
+
+	b.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		points := []map[string]interface{}{}
+		if err := json.Unmarshal(body, &points); err != nil {
+			t.Error(err)
+		}
+
+		b.points <- points
+
+		w.WriteHeader(b.status)
+	}))
+
+	return b
+}
+
+// received - returns the points received by the backend
+func (b *testBackend) received() []map[string]interface{} {
+
+	result := []map[string]interface{}{}
+
+	for {
+		select {
+		case points := <-b.points:
+			result = append(result, points...)
+		default:
+			return result
+		}
+	}
+}
+
+// createConfigFile - creates the configuration file using the http transport
+func createConfigFile(t *testing.T, b *testBackend) string {
+
+	host, port, err := net.SplitHostPort(b.server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "timeline-send")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := fmt.Sprintf(`{
+		"backend": {"host": "%s", "port": %s},
+		"httpTransport": {
+			"serviceEndpoint": "/api/put",
+			"method": "POST",
+			"expectedResponseStatus": 204,
+			"batchSendInterval": "1s",
+			"requestTimeout": "1s",
+			"transportBufferSize": 100,
+			"serializerBufferSize": 1024
+		}
+	}`, host, port)
+
+	path := filepath.Join(dir, "conf.json")
+
+	if err := ioutil.WriteFile(path, []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// runCommand - runs the command using the backend and returns the exit code and the error output
+func runCommand(t *testing.T, b *testBackend, stdin string, args ...string) (int, string) {
+
+	path := createConfigFile(t, b)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	var stderr bytes.Buffer
+
+	code := run(append([]string{"-config", path, "-log-level", "silent"}, args...), strings.NewReader(stdin), &stderr)
+
+	return code, stderr.String()
+}
+
+// TestSendArguments - tests the points given as arguments
+func TestSendArguments(t *testing.T) {
+
+	b := createBackend(t, http.StatusNoContent)
+	defer b.server.Close()
+
+	code, stderr := runCommand(t, b, "", "put cpu 1600000000 10 host=a", "mem 1600000000 20 host=b")
+	assert.Equal(t, 0, code, "expected success: %s", stderr)
+
+	points := b.received()
+	if assert.Len(t, points, 2, "expected two points") {
+		assert.Equal(t, "cpu", points[0]["metric"], "expected the first metric")
+		assert.Equal(t, float64(10), points[0]["value"], "expected the first value")
+		assert.Equal(t, map[string]interface{}{"host": "a"}, points[0]["tags"], "expected the first tags")
+		assert.Equal(t, "mem", points[1]["metric"], "expected the second metric")
+		assert.Equal(t, float64(20), points[1]["value"], "expected the second value")
+	}
+}
+
+// TestSendStdin - tests the points read from the stdin (comments and empty lines are ignored)
+func TestSendStdin(t *testing.T) {
+
+	b := createBackend(t, http.StatusNoContent)
+	defer b.server.Close()
+
+	stdin := "# comment\n\nput cpu 1600000000 1 host=a\n" + `{"metric":"cpu","timestamp":1600000001,"value":2,"tags":{"host":"a"}}` + "\n"
+
+	code, stderr := runCommand(t, b, stdin)
+	assert.Equal(t, 0, code, "expected success: %s", stderr)
+
+	points := b.received()
+	if assert.Len(t, points, 2, "expected two points") {
+		assert.Equal(t, float64(1), points[0]["value"], "expected the telnet line value")
+		assert.Equal(t, float64(2), points[1]["value"], "expected the json point value")
+	}
+}
+
+// TestSendFlatten - tests the flatten mode with multiple operations
+func TestSendFlatten(t *testing.T) {
+
+	b := createBackend(t, http.StatusNoContent)
+	defer b.server.Close()
+
+	code, stderr := runCommand(t, b, "cpu 1600000000 2 host=a\ncpu 1600000000 4 host=a\n", "-mode", "flatten", "-operation", "sum,max")
+	assert.Equal(t, 0, code, "expected success: %s", stderr)
+
+	values := map[float64]bool{}
+	for _, p := range b.received() {
+		values[p["value"].(float64)] = true
+	}
+
+	assert.Equal(t, map[float64]bool{6: true, 4: true}, values, "expected the sum and the maximum")
+}
+
+// TestSendAccumulate - tests the accumulate mode counting the points by series
+func TestSendAccumulate(t *testing.T) {
+
+	b := createBackend(t, http.StatusNoContent)
+	defer b.server.Close()
+
+	code, stderr := runCommand(t, b, "cpu 1600000000 1 host=a\ncpu 1600000001 1 host=a\ncpu 1600000002 1 host=a\ncpu 1600000000 1 host=b\n", "-mode", "accumulate")
+	assert.Equal(t, 0, code, "expected success: %s", stderr)
+
+	counts := map[string]float64{}
+	for _, p := range b.received() {
+		counts[p["tags"].(map[string]interface{})["host"].(string)] = p["value"].(float64)
+	}
+
+	assert.Equal(t, map[string]float64{"a": 3, "b": 1}, counts, "expected the counts by series")
+}
+
+// TestSendExitCodes - tests the exit codes for the delivery errors, the invalid points and the usage errors
+func TestSendExitCodes(t *testing.T) {
+
+	b := createBackend(t, http.StatusInternalServerError)
+	defer b.server.Close()
+
+	code, stderr := runCommand(t, b, "", "cpu 1600000000 1 host=a")
+	assert.Equal(t, 1, code, "expected failure when the delivery fails")
+	assert.Contains(t, stderr, "error sending the points", "expected the delivery error")
+
+	b.status = http.StatusNoContent
+
+	code, stderr = runCommand(t, b, "", "cpu 1600000000 1 host=a", "invalid")
+	assert.Equal(t, 1, code, "expected failure with invalid points")
+	assert.Contains(t, stderr, "argument 2", "expected the invalid argument")
+
+	code, _ = runCommand(t, b, "", "-mode", "unknown")
+	assert.Equal(t, 2, code, "expected the usage error")
+}
@@ -12,6 +12,11 @@ import (
 * @author rnojiri
 **/
 
+const (
+	defaultMetricProperty string = "metric"
+	defaultTagsProperty   string = "tags"
+)
+
 type customSerializerTransport struct {
 	configuration *CustomSerializerConfig
 }
@@ -131,9 +136,195 @@ func (t *customSerializerTransport) flattenerPointToDataChannelItem(point *Flatt
 		return nil, fmt.Errorf("error casting flattener point to data channel item: %+v", *point)
 	}
 
-	item.Parameters = append(item.Parameters, t.configuration.TimestampProperty, point.timestamp, t.configuration.ValueProperty, point.value)
+	if point.label != nil {
+		labeled, err := t.applyLabel(item, point.label)
+		if err != nil {
+			return nil, err
+		}
+		item = labeled
+	}
+
+	// new parameters for each emission (the buckets can be emitted again by the late corrections)
+	parameters := make([]interface{}, len(item.Parameters), len(item.Parameters)+4)
+	copy(parameters, item.Parameters)
+
+	return &serializer.ArrayItem{
+		Name:       item.Name,
+		Parameters: append(parameters, t.configuration.TimestampProperty, point.timestamp, t.configuration.ValueProperty, point.value),
+	}, nil
+}
+
+// applyLabel - returns a copy of the item with the operation suffix in the metric property or the operation tag in the tags property
+func (t *customSerializerTransport) applyLabel(item *serializer.ArrayItem, label *operationLabel) (*serializer.ArrayItem, error) {
+
+	metricProperty := t.configuration.MetricProperty
+	if len(metricProperty) == 0 {
+		metricProperty = defaultMetricProperty
+	}
+
+	tagsProperty := t.configuration.TagsProperty
+	if len(tagsProperty) == 0 {
+		tagsProperty = defaultTagsProperty
+	}
+
+	parameters := make([]interface{}, len(item.Parameters), len(item.Parameters)+2)
+	copy(parameters, item.Parameters)
+
+	suffixPending := len(label.suffix) > 0
+	tagPending := len(label.tagKey) > 0
+
+	for i := 0; i < len(parameters)-1; i += 2 {
+
+		if suffixPending && parameters[i] == metricProperty {
+
+			metric, ok := parameters[i+1].(string)
+			if !ok {
+				return nil, fmt.Errorf("expecting a string as metric for parameter: %s", metricProperty)
+			}
+
+			parameters[i+1] = metric + label.suffix
+			suffixPending = false
+
+		} else if tagPending && parameters[i] == tagsProperty {
+
+			tags, err := addTag(parameters[i+1], tagsProperty, label.tagKey, label.tagValue)
+			if err != nil {
+				return nil, err
+			}
+
+			parameters[i+1] = tags
+			tagPending = false
+		}
+	}
+
+	if suffixPending {
+		return nil, fmt.Errorf("metric property not found: %s", metricProperty)
+	}
 
-	return item, nil
+	if tagPending {
+		parameters = append(parameters, tagsProperty, map[string]string{label.tagKey: label.tagValue})
+	}
+
+	return &serializer.ArrayItem{
+		Name:       item.Name,
+		Parameters: parameters,
+	}, nil
+}
+
+// dataChannelItemToRollup - returns a copy of the item without the properties and the tags (from the tags property) removed by the rollup, the metric is read from the metric property or the schema name
+func (t *customSerializerTransport) dataChannelItemToRollup(instance interface{}, rollup *FlattenRollup) (interface{}, error) {
+
+	item, ok := instance.(*serializer.ArrayItem)
+	if !ok {
+		return nil, fmt.Errorf("error casting instance to data channel item: %+v", instance)
+	}
+
+	metricProperty := t.configuration.MetricProperty
+	if len(metricProperty) == 0 {
+		metricProperty = defaultMetricProperty
+	}
+
+	tagsProperty := t.configuration.TagsProperty
+	if len(tagsProperty) == 0 {
+		tagsProperty = defaultTagsProperty
+	}
+
+	metric := item.Name
+	parameters := make([]interface{}, 0, len(item.Parameters))
+	removed := false
+
+	for i := 0; i+1 < len(item.Parameters); i += 2 {
+
+		key, ok := item.Parameters[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("expecting a property name in parameter item: %s", item.Parameters[i])
+		}
+
+		value := item.Parameters[i+1]
+
+		switch key {
+		case metricProperty:
+			if name, ok := value.(string); ok {
+				metric = name
+			}
+		case t.configuration.ValueProperty, t.configuration.TimestampProperty:
+		case tagsProperty:
+			tags, changed, err := removeTags(value, tagsProperty, rollup)
+			if err != nil {
+				return nil, err
+			}
+			value = tags
+			removed = removed || changed
+		default:
+			if !rollup.keepsTag(key) {
+				removed = true
+				continue
+			}
+		}
+
+		parameters = append(parameters, key, value)
+	}
+
+	if !rollup.matches(metric) {
+		return nil, nil
+	}
+
+	if !removed {
+		return item, nil
+	}
+
+	return &serializer.ArrayItem{
+		Name:       item.Name,
+		Parameters: parameters,
+	}, nil
+}
+
+// removeTags - returns a copy of the tags map (keeping its type) without the tags removed by the rollup and true if some tag was removed
+func removeTags(instance interface{}, tagsProperty string, rollup *FlattenRollup) (interface{}, bool, error) {
+
+	switch tags := instance.(type) {
+	case map[string]string:
+		copied := make(map[string]string, len(tags))
+		for k, v := range tags {
+			if rollup.keepsTag(k) {
+				copied[k] = v
+			}
+		}
+		return copied, len(copied) != len(tags), nil
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(tags))
+		for k, v := range tags {
+			if rollup.keepsTag(k) {
+				copied[k] = v
+			}
+		}
+		return copied, len(copied) != len(tags), nil
+	default:
+		return nil, false, fmt.Errorf("expecting a map as tags for parameter: %s", tagsProperty)
+	}
+}
+
+// addTag - returns a copy of the tags map (keeping its type) with the new tag
+func addTag(instance interface{}, tagsProperty, key, value string) (interface{}, error) {
+
+	switch tags := instance.(type) {
+	case map[string]string:
+		copied := make(map[string]string, len(tags)+1)
+		for k, v := range tags {
+			copied[k] = v
+		}
+		copied[key] = value
+		return copied, nil
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(tags)+1)
+		for k, v := range tags {
+			copied[k] = v
+		}
+		copied[key] = value
+		return copied, nil
+	default:
+		return nil, fmt.Errorf("expecting a map as tags for parameter: %s", tagsProperty)
+	}
 }
 
 // dataChannelItemToAccumulatedData - converts the data channel item to the accumulated data
@@ -189,3 +380,42 @@ func (t *customSerializerTransport) accumulatedDataToDataChannelItem(point *accu
 		Parameters: fullParameters,
 	}, nil
 }
+
+// dataChannelItemToSeriesPoint - converts the data channel item to the series point
+func (t *customSerializerTransport) dataChannelItemToSeriesPoint(instance interface{}) (*SeriesPoint, error) {
+
+	item, ok := instance.(*serializer.ArrayItem)
+	if !ok {
+		return nil, fmt.Errorf("error casting instance to data channel item: %+v", instance)
+	}
+
+	numParameters := len(item.Parameters)
+
+	point := &SeriesPoint{
+		Metric:     item.Name,
+		Tags:       make([]interface{}, 0, numParameters),
+		tagIndexes: make([]int, 0, numParameters),
+		source:     &item.Parameters,
+	}
+
+	for i := 0; i+1 < numParameters; i += 2 {
+
+		if key, ok := item.Parameters[i].(string); ok {
+
+			if key == t.configuration.ValueProperty {
+				point.Value, _ = item.Parameters[i+1].(float64)
+				continue
+			}
+
+			if key == t.configuration.TimestampProperty {
+				point.Timestamp, _ = item.Parameters[i+1].(int64)
+				continue
+			}
+		}
+
+		point.Tags = append(point.Tags, item.Parameters[i], item.Parameters[i+1])
+		point.tagIndexes = append(point.tagIndexes, i, i+1)
+	}
+
+	return point, nil
+}
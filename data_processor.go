@@ -50,10 +50,21 @@ type DataProcessorEntry interface {
 	Release()
 }
 
+// emittedPointObserver - implemented by the transports observing the items emitted by the flattener and the accumulator
+type emittedPointObserver interface {
+
+	// flattenedPointEmitted - observes the flattened item sent to the transport
+	flattenedPointEmitted(item interface{})
+
+	// accumulatedDataEmitted - observes the accumulated item sent to the transport
+	accumulatedDataEmitted(item interface{})
+}
+
 // dataProcessorCore - contains the common data
 type dataProcessorCore struct {
 	pointMap      sync.Map
 	transport     Transport
+	observer      emittedPointObserver
 	configuration *DataTransformerConfig
 	terminateChan chan struct{}
 	loggers       *logh.ContextualLogger
@@ -63,6 +74,7 @@ type dataProcessorCore struct {
 // SetTransport - sets the transport
 func (d *dataProcessorCore) SetTransport(transport Transport) {
 	d.transport = transport
+	d.observer, _ = transport.(emittedPointObserver)
 }
 
 // Stop - terminates the processing cycle
@@ -88,7 +100,7 @@ func (d *dataProcessorCore) Start() {
 		}
 
 		for {
-			<-time.After(d.configuration.CycleDuration.Duration)
+			<-time.After(d.nextCycle())
 
 			if logh.DebugEnabled {
 				d.loggers.Debug().Msg("entering a new process cycle")
@@ -108,6 +120,20 @@ func (d *dataProcessorCore) Start() {
 	}()
 }
 
+// nextCycle - returns the time until the next cycle (aligned to the wall clock when the bucket size is configured)
+func (d *dataProcessorCore) nextCycle() time.Duration {
+
+	cycle := d.configuration.CycleDuration.Duration
+
+	if d.configuration.BucketSize.Duration <= 0 || cycle <= 0 {
+		return cycle
+	}
+
+	now := time.Now()
+
+	return now.Truncate(cycle).Add(cycle).Sub(now)
+}
+
 // ProcessCycle - forces a new cycle process
 func (d *dataProcessorCore) ProcessCycle() {
 
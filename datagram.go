@@ -0,0 +1,50 @@
+package timeline
+
+import "strings"
+
+/**
+* Packs serialized points into datagrams.
+* @author rnojiri
+**/
+
+const (
+	// defaultMaxDatagramSize - fits in the ethernet MTU with the ip and udp headers
+	defaultMaxDatagramSize int = 1432
+
+	// defaultDatagramSeparator - separates the points packed in the same datagram
+	defaultDatagramSeparator string = "\n"
+)
+
+// packPayload - concatenates the serialized points using the separator, no packet is larger than maxSize (larger points are dropped)
+func packPayload(serialized []string, separator string, maxSize int) (packets []string, dropped int) {
+
+	var b strings.Builder
+
+	for _, s := range serialized {
+
+		if len(s) > maxSize {
+			dropped++
+			continue
+		}
+
+		if b.Len() > 0 {
+
+			if b.Len()+len(separator)+len(s) <= maxSize {
+				b.WriteString(separator)
+				b.WriteString(s)
+				continue
+			}
+
+			packets = append(packets, b.String())
+			b.Reset()
+		}
+
+		b.WriteString(s)
+	}
+
+	if b.Len() > 0 {
+		packets = append(packets, b.String())
+	}
+
+	return
+}
@@ -0,0 +1,128 @@
+package timeline
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uol/logh"
+)
+
+/**
+* Removes the identical points (metric, tags, timestamp and value) within a batch and across a time window.
+* @author rnojiri
+**/
+
+// hashWindow - a set of point hashes seen in the last window, stored in two generations
+type hashWindow struct {
+	size      time.Duration
+	current   map[uint64]struct{}
+	previous  map[uint64]struct{}
+	rotatedAt time.Time
+	sync.Mutex
+}
+
+// newHashWindow - creates a new hash window
+func newHashWindow(size time.Duration) *hashWindow {
+
+	return &hashWindow{
+		size:      size,
+		current:   map[uint64]struct{}{},
+		previous:  map[uint64]struct{}{},
+		rotatedAt: time.Now(),
+	}
+}
+
+// rotate - discards the oldest generation (a hash is kept at least for the window size)
+func (w *hashWindow) rotate(now time.Time) {
+
+	elapsed := now.Sub(w.rotatedAt)
+	if elapsed < w.size {
+		return
+	}
+
+	if elapsed >= 2*w.size {
+		w.previous = map[uint64]struct{}{}
+	} else {
+		w.previous = w.current
+	}
+
+	w.current = map[uint64]struct{}{}
+	w.rotatedAt = now
+}
+
+// contains - checks if the hash was seen in the window
+func (w *hashWindow) contains(hash uint64) bool {
+
+	if _, ok := w.current[hash]; ok {
+		return true
+	}
+
+	_, ok := w.previous[hash]
+
+	return ok
+}
+
+// deduplicate - removes the duplicated points from the batch
+func (t *transportCore) deduplicate(dataList []interface{}) []interface{} {
+
+	if !t.defaultConfiguration.DeduplicateBatch && t.dedupeWindow == nil {
+		return dataList
+	}
+
+	converter, ok := t.transport.(seriesPointConverter)
+	if !ok {
+		return dataList
+	}
+
+	if t.dedupeWindow != nil {
+		t.dedupeWindow.Lock()
+		defer t.dedupeWindow.Unlock()
+		t.dedupeWindow.rotate(time.Now())
+	}
+
+	batch := make(map[uint64]struct{}, len(dataList))
+	result := make([]interface{}, 0, len(dataList))
+	var duplicated uint64
+
+	for _, item := range dataList {
+
+		point, err := converter.DataChannelItemToSeriesPoint(item)
+		if err != nil {
+			result = append(result, item)
+			continue
+		}
+
+		hash := point.pointHash()
+
+		if _, ok := batch[hash]; ok {
+			duplicated++
+			continue
+		}
+
+		if t.dedupeWindow != nil && t.dedupeWindow.contains(hash) {
+			duplicated++
+			continue
+		}
+
+		batch[hash] = struct{}{}
+		result = append(result, item)
+	}
+
+	if t.dedupeWindow != nil {
+		for hash := range batch {
+			t.dedupeWindow.current[hash] = struct{}{}
+		}
+	}
+
+	if duplicated > 0 {
+
+		atomic.AddUint64(&t.duplicatedPoints, duplicated)
+
+		if logh.DebugEnabled {
+			t.loggers.Debug().Msgf("%d duplicated points were removed", duplicated)
+		}
+	}
+
+	return result
+}
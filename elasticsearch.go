@@ -0,0 +1,472 @@
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/logh"
+	jsonSerializer "github.com/uol/serializer/json"
+	"github.com/uol/serializer/serializer"
+)
+
+/**
+* The Elasticsearch / OpenSearch bulk API transport implementation (accepts the json items).
+* The index name can contain a date layout between braces (ex: "timeline-{2006.01.02}") rendered using the point timestamp.
+* @author rnojiri
+**/
+
+const (
+	// ElasticsearchIndex - the bulk "index" action (replaces documents with the same id)
+	ElasticsearchIndex string = "index"
+
+	// ElasticsearchCreate - the bulk "create" action (required by the data streams)
+	ElasticsearchCreate string = "create"
+
+	defaultElasticsearchEndpoint   string        = "/_bulk"
+	defaultElasticsearchRetries    int           = 3
+	defaultElasticsearchMinBackoff time.Duration = 100 * time.Millisecond
+	defaultElasticsearchMaxBackoff time.Duration = 5 * time.Second
+)
+
+// ErrBulkItemsFailed - raised when some documents could not be sent after all retries (the rejected documents are only counted)
+var ErrBulkItemsFailed error = errors.New("some documents could not be sent to the bulk api")
+
+// indexPatternPart - a literal text or a date layout
+type indexPatternPart struct {
+	text   string
+	layout bool
+}
+
+// bulkResponse - the relevant part of the bulk api response
+type bulkResponse struct {
+	Errors bool                              `json:"errors"`
+	Items  []map[string]bulkResponseItemData `json:"items"`
+}
+
+// bulkResponseItemData - the result from a bulk action
+type bulkResponseItemData struct {
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// ElasticsearchTransport - implements the elasticsearch/opensearch bulk transport
+type ElasticsearchTransport struct {
+	core                transportCore
+	configuration       *ElasticsearchTransportConfig
+	httpClient          *http.Client
+	serviceURL          string
+	headers             map[string]string
+	indexPattern        []indexPatternPart
+	serializer          serializer.Serializer
+	serializerTransport *customSerializerTransport
+}
+
+// NewElasticsearchTransport - creates a new elasticsearch event manager with a customized json serializer
+func NewElasticsearchTransport(configuration *ElasticsearchTransportConfig, customSerializer serializer.Serializer) (*ElasticsearchTransport, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+
+	if customSerializer == nil {
+		return nil, fmt.Errorf("no serializer was configured")
+	}
+
+	if len(configuration.TimestampProperty) == 0 {
+		return nil, fmt.Errorf("timestamp property is not configured")
+	}
+
+	if len(configuration.ValueProperty) == 0 {
+		return nil, fmt.Errorf("value property is not configured")
+	}
+
+	indexPattern, err := parseIndexPattern(configuration.IndexPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	switch configuration.Action {
+	case empty:
+		configuration.Action = ElasticsearchIndex
+	case ElasticsearchIndex, ElasticsearchCreate:
+	default:
+		return nil, fmt.Errorf("invalid bulk action: %s", configuration.Action)
+	}
+
+	if configuration.MaxRetries < 0 {
+		return nil, fmt.Errorf("invalid maximum number of retries: %d", configuration.MaxRetries)
+	}
+
+	if configuration.MaxRetries == 0 {
+		configuration.MaxRetries = defaultElasticsearchRetries
+	}
+
+	if configuration.MinBackoff.Duration == 0 {
+		configuration.MinBackoff.Duration = defaultElasticsearchMinBackoff
+	}
+
+	if configuration.MaxBackoff.Duration == 0 {
+		configuration.MaxBackoff.Duration = defaultElasticsearchMaxBackoff
+	}
+
+	if configuration.MinBackoff.Duration < 0 || configuration.MaxBackoff.Duration < configuration.MinBackoff.Duration {
+		return nil, fmt.Errorf("invalid backoff interval: %s - %s", configuration.MinBackoff, configuration.MaxBackoff)
+	}
+
+	if len(configuration.ServiceEndpoint) == 0 {
+		configuration.ServiceEndpoint = defaultElasticsearchEndpoint
+	}
+
+	headers := map[string]string{}
+	for k, v := range configuration.Headers {
+		headers[k] = v
+	}
+
+	headers["Content-Type"] = "application/x-ndjson"
+
+	t := &ElasticsearchTransport{
+		core: transportCore{
+			batchSendInterval:    configuration.BatchSendInterval.Duration,
+			defaultConfiguration: &configuration.DefaultTransportConfig,
+		},
+		serializerTransport: &customSerializerTransport{
+			configuration: &configuration.CustomSerializerConfig,
+		},
+		configuration: configuration,
+		httpClient:    funks.CreateHTTPClient(configuration.RequestTimeout.Duration, true),
+		headers:       headers,
+		indexPattern:  indexPattern,
+		serializer:    customSerializer,
+	}
+
+	t.core.transport = t
+
+	return t, nil
+}
+
+// parseIndexPattern - splits the index pattern in literal texts and date layouts
+func parseIndexPattern(pattern string) ([]indexPatternPart, error) {
+
+	if len(pattern) == 0 {
+		return nil, fmt.Errorf("index pattern is not configured")
+	}
+
+	parts := []indexPatternPart{}
+
+	for len(pattern) > 0 {
+
+		start := strings.IndexByte(pattern, '{')
+		if start == -1 {
+			parts = append(parts, indexPatternPart{text: pattern})
+			break
+		}
+
+		end := strings.IndexByte(pattern[start:], '}')
+		if end <= 1 {
+			return nil, fmt.Errorf("invalid date layout in index pattern: %s", pattern)
+		}
+
+		if start > 0 {
+			parts = append(parts, indexPatternPart{text: pattern[:start]})
+		}
+
+		parts = append(parts, indexPatternPart{text: pattern[start+1 : start+end], layout: true})
+		pattern = pattern[start+end+1:]
+	}
+
+	return parts, nil
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (t *ElasticsearchTransport) BuildContextualLogger(path ...string) {
+
+	if t.core.loggers != nil {
+		return
+	}
+
+	logContext := []string{"pkg", "timeline/elasticsearch"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	t.core.loggers = logh.CreateContextualLogger(logContext...)
+}
+
+// ConfigureBackend - configures the backend
+func (t *ElasticsearchTransport) ConfigureBackend(backend *Backend) error {
+
+	if backend == nil {
+		return fmt.Errorf("no backend was configured")
+	}
+
+	t.serviceURL = fmt.Sprintf("http://%s:%d/%s", backend.Host, backend.Port, strings.TrimPrefix(t.configuration.ServiceEndpoint, "/"))
+
+	if logh.InfoEnabled {
+		t.core.loggers.Info().Msg(fmt.Sprintf("backend was configured to use service: %s", t.serviceURL))
+	}
+
+	return nil
+}
+
+// indexName - renders the index name using the point timestamp (UTC)
+func (t *ElasticsearchTransport) indexName(item *jsonSerializer.ArrayItem) string {
+
+	timestamp := time.Now().Unix()
+
+	for i := 0; i+1 < len(item.Parameters); i += 2 {
+		if key, ok := item.Parameters[i].(string); ok && key == t.configuration.TimestampProperty {
+			if ts, ok := item.Parameters[i+1].(int64); ok {
+				timestamp = ts
+			}
+			break
+		}
+	}
+
+	date := time.Unix(timestamp, 0).UTC()
+
+	var b strings.Builder
+
+	for _, part := range t.indexPattern {
+		if part.layout {
+			b.WriteString(date.Format(part.text))
+		} else {
+			b.WriteString(part.text)
+		}
+	}
+
+	return b.String()
+}
+
+// Serialize - renders the bulk action and the document lines
+func (t *ElasticsearchTransport) Serialize(item interface{}) (string, error) {
+
+	casted, ok := item.(*jsonSerializer.ArrayItem)
+	if !ok {
+		return empty, fmt.Errorf("unexpected instance type: %+v", item)
+	}
+
+	document, err := t.serializer.SerializeGeneric(casted)
+	if err != nil {
+		return empty, err
+	}
+
+	var b strings.Builder
+
+	b.WriteString(`{"`)
+	b.WriteString(t.configuration.Action)
+	b.WriteString(`":{"_index":`)
+	b.WriteString(strconv.Quote(t.indexName(casted)))
+	b.WriteString("}}\n")
+	b.WriteString(document)
+	b.WriteByte('\n')
+
+	return b.String(), nil
+}
+
+// SerializePayload - serializes a list of generic data (one bulk action per payload item)
+func (t *ElasticsearchTransport) SerializePayload(dataList []interface{}) (payload []string, err error) {
+
+	payload = make([]string, len(dataList))
+
+	for i, data := range dataList {
+
+		payload[i], err = t.Serialize(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return payload, nil
+}
+
+// backoff - returns the time to wait before the next retry
+func (t *ElasticsearchTransport) backoff(attempt int) time.Duration {
+
+	wait := t.configuration.MinBackoff.Duration << uint(attempt)
+	if wait <= 0 || wait > t.configuration.MaxBackoff.Duration {
+		wait = t.configuration.MaxBackoff.Duration
+	}
+
+	return wait
+}
+
+// retryableStatus - only the rate limit and the server errors are retried
+func retryableStatus(status int) bool {
+
+	return status == http.StatusTooManyRequests || status/100 == 5
+}
+
+// bulk - sends the bulk request and returns the actions to be retried
+func (t *ElasticsearchTransport) bulk(payload []string) (retry []string, rejected int, err error) {
+
+	req, err := http.NewRequest(http.MethodPost, t.serviceURL, strings.NewReader(strings.Join(payload, empty)))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return payload, 0, err
+	}
+
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return payload, 0, fmt.Errorf("error reading body: %s", err.Error())
+	}
+
+	if res.StatusCode/100 != 2 {
+
+		statusErr := &httpStatusError{
+			statusCode: res.StatusCode,
+			body:       string(body),
+		}
+
+		if retryableStatus(res.StatusCode) {
+			return payload, 0, statusErr
+		}
+
+		// the whole request failed (wrong endpoint, credentials...), it is not a document rejection
+		return nil, 0, statusErr
+	}
+
+	response := bulkResponse{}
+
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error parsing the bulk response: %s", err.Error())
+	}
+
+	if !response.Errors {
+		return nil, 0, nil
+	}
+
+	if len(response.Items) != len(payload) {
+		return nil, 0, fmt.Errorf("expected %d items in the bulk response, found %d", len(payload), len(response.Items))
+	}
+
+	for i, item := range response.Items {
+
+		for _, result := range item {
+
+			if result.Status/100 == 2 {
+				continue
+			}
+
+			if retryableStatus(result.Status) {
+				retry = append(retry, payload[i])
+				continue
+			}
+
+			rejected++
+
+			if logh.WarnEnabled {
+				t.core.loggers.Warn().Msgf("document rejected with status %d: %s", result.Status, string(result.Error))
+			}
+		}
+	}
+
+	return retry, rejected, nil
+}
+
+// TransferData - transfers the data to the backend throught this transport (only the failed actions are retried, the rejected ones are counted)
+func (t *ElasticsearchTransport) TransferData(payload []string) error {
+
+	if len(payload) == 0 {
+		return ErrInvalidPayloadSize
+	}
+
+	for attempt := 0; ; attempt++ {
+
+		retry, rejected, err := t.bulk(payload)
+		if rejected > 0 {
+			atomic.AddUint64(&t.core.rejectedPoints, uint64(rejected))
+		}
+
+		if len(retry) == 0 {
+			return err
+		}
+
+		if attempt >= t.configuration.MaxRetries {
+
+			if err != nil {
+				return fmt.Errorf("%w: %d not retried: %s", ErrBulkItemsFailed, len(retry), err.Error())
+			}
+
+			return fmt.Errorf("%w: %d not retried", ErrBulkItemsFailed, len(retry))
+		}
+
+		wait := t.backoff(attempt)
+
+		if logh.WarnEnabled {
+			ev := t.core.loggers.Warn()
+			if err != nil {
+				ev = ev.Err(err)
+			}
+			ev.Msgf("retrying %d bulk actions in %s (attempt %d)", len(retry), wait, attempt+1)
+		}
+
+		<-time.After(wait)
+
+		payload = retry
+	}
+}
+
+// DataChannel - send a new point
+func (t *ElasticsearchTransport) DataChannel(item interface{}) {
+
+	t.core.dataChannel(item)
+}
+
+// AddPointFilter - adds a filter to be applied before buffering the points
+func (t *ElasticsearchTransport) AddPointFilter(filter PointFilter) {
+
+	t.core.addPointFilter(filter)
+}
+
+// GetStats - returns the transport statistics
+func (t *ElasticsearchTransport) GetStats() TransportStats {
+
+	return t.core.getStats()
+}
+
+// MatchType - checks if this transport implementation matches the given type (accepts the json items)
+func (t *ElasticsearchTransport) MatchType(tt transportType) bool {
+
+	return tt == typeHTTP
+}
+
+// Start - starts this transport
+func (t *ElasticsearchTransport) Start(manualMode bool) error {
+
+	return t.core.Start(manualMode)
+}
+
+// Close - closes this transport
+func (t *ElasticsearchTransport) Close() {
+
+	t.core.Close()
+}
+
+// SendData - releases the point buffer and send all data
+func (t *ElasticsearchTransport) SendData() error {
+	return t.core.SendData()
+}
@@ -0,0 +1,37 @@
+package timeline
+
+// DataChannelItemToFlattenerPoint - converts the data channel item to the flattened point one
+func (t *ElasticsearchTransport) DataChannelItemToFlattenerPoint(configuration *DataTransformerConfig, instance interface{}, operation FlatOperation) (Hashable, error) {
+
+	return t.serializerTransport.dataChannelItemToFlattenerPoint(configuration, instance, operation)
+}
+
+// FlattenerPointToDataChannelItem - converts the flattened point to the data channel one
+func (t *ElasticsearchTransport) FlattenerPointToDataChannelItem(point *FlattenerPoint) (interface{}, error) {
+
+	return t.serializerTransport.flattenerPointToDataChannelItem(point)
+}
+
+// DataChannelItemToAccumulatedData - converts the data channel item to the accumulated data
+func (t *ElasticsearchTransport) DataChannelItemToAccumulatedData(configuration *DataTransformerConfig, instance interface{}, calculateHash bool) (Hashable, error) {
+
+	return t.serializerTransport.dataChannelItemToAccumulatedData(configuration, instance, calculateHash)
+}
+
+// AccumulatedDataToDataChannelItem - converts the accumulated data to the data channel item
+func (t *ElasticsearchTransport) AccumulatedDataToDataChannelItem(point *accumulatedData) (interface{}, error) {
+
+	return t.serializerTransport.accumulatedDataToDataChannelItem(point)
+}
+
+// DataChannelItemToSeriesPoint - converts the data channel item to the series point
+func (t *ElasticsearchTransport) DataChannelItemToSeriesPoint(instance interface{}) (*SeriesPoint, error) {
+
+	return t.serializerTransport.dataChannelItemToSeriesPoint(instance)
+}
+
+// DataChannelItemToRollup - returns the data channel item without the tags removed by the rollup (nil if the rollup does not match)
+func (t *ElasticsearchTransport) DataChannelItemToRollup(instance interface{}, rollup *FlattenRollup) (interface{}, error) {
+
+	return t.serializerTransport.dataChannelItemToRollup(instance, rollup)
+}
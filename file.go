@@ -0,0 +1,403 @@
+package timeline
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uol/logh"
+	openTSDBSerializer "github.com/uol/serializer/opentsdb"
+	"github.com/uol/serializer/serializer"
+)
+
+/**
+* The file transport implementation, records the serialized batches in rotating files or in any io.Writer.
+* Sends the opentsdb lines when no custom serializer is given, otherwise the batch is rendered by the custom serializer (one batch per line).
+* @author rnojiri
+**/
+
+const (
+	gzipExtension     string      = ".gz"
+	rotatedFileLayout string      = "20060102T150405.000000000"
+	defaultFileMode   os.FileMode = 0644
+	defaultFileFlags  int         = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+)
+
+// FileTransport - implements the file and io.Writer transport
+type FileTransport struct {
+	core                transportCore
+	configuration       *FileTransportConfig
+	serializer          serializer.Serializer
+	itemTransport       *openTSDBItemTransport
+	serializerTransport *customSerializerTransport
+	separator           string
+	writer              io.Writer
+	file                *os.File
+	gzipWriter          *gzip.Writer
+	fileSize            int64
+	fileOpenedAt        time.Time
+	sync.Mutex
+}
+
+// countingWriter - counts the bytes written to the underlying writer
+type countingWriter struct {
+	writer io.Writer
+	count  *int64
+}
+
+// Write - writes to the underlying writer and counts the written bytes
+func (w *countingWriter) Write(p []byte) (int, error) {
+
+	n, err := w.writer.Write(p)
+	*w.count += int64(n)
+
+	return n, err
+}
+
+// NewFileTransport - creates a new file event manager (uses the opentsdb serializer if the custom one is nil)
+func NewFileTransport(configuration *FileTransportConfig, customSerializer serializer.Serializer) (*FileTransport, error) {
+
+	t, err := newFileTransport(configuration, customSerializer)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(configuration.Path) == 0 {
+		return nil, fmt.Errorf("no file path was configured")
+	}
+
+	if configuration.MaxFileSize < 0 {
+		return nil, fmt.Errorf("invalid maximum file size: %d", configuration.MaxFileSize)
+	}
+
+	if configuration.RotationInterval.Duration < 0 {
+		return nil, fmt.Errorf("invalid rotation interval: %s", configuration.RotationInterval)
+	}
+
+	if configuration.Gzip && !strings.HasSuffix(configuration.Path, gzipExtension) {
+		configuration.Path += gzipExtension
+	}
+
+	return t, nil
+}
+
+// NewWriterTransport - creates a new event manager writing to the given writer (there is no rotation)
+func NewWriterTransport(configuration *FileTransportConfig, writer io.Writer, customSerializer serializer.Serializer) (*FileTransport, error) {
+
+	if writer == nil {
+		return nil, fmt.Errorf("no writer was configured")
+	}
+
+	t, err := newFileTransport(configuration, customSerializer)
+	if err != nil {
+		return nil, err
+	}
+
+	t.writer = writer
+
+	if configuration.Gzip {
+		t.gzipWriter = gzip.NewWriter(writer)
+		t.writer = t.gzipWriter
+	}
+
+	return t, nil
+}
+
+// newFileTransport - creates the common transport structure
+func newFileTransport(configuration *FileTransportConfig, customSerializer serializer.Serializer) (*FileTransport, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+
+	t := &FileTransport{
+		core: transportCore{
+			batchSendInterval:    configuration.BatchSendInterval.Duration,
+			defaultConfiguration: &configuration.DefaultTransportConfig,
+		},
+		configuration: configuration,
+	}
+
+	if customSerializer == nil {
+		// the opentsdb lines already ends with a line break
+		t.serializer = openTSDBSerializer.New(configuration.SerializerBufferSize)
+		t.itemTransport = &openTSDBItemTransport{}
+	} else {
+
+		if len(configuration.TimestampProperty) == 0 {
+			return nil, fmt.Errorf("timestamp property is not configured")
+		}
+
+		if len(configuration.ValueProperty) == 0 {
+			return nil, fmt.Errorf("value property is not configured")
+		}
+
+		t.serializer = customSerializer
+		t.serializerTransport = &customSerializerTransport{
+			configuration: &configuration.CustomSerializerConfig,
+		}
+		t.separator = defaultDatagramSeparator
+	}
+
+	t.core.transport = t
+
+	return t, nil
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (t *FileTransport) BuildContextualLogger(path ...string) {
+
+	if t.core.loggers != nil {
+		return
+	}
+
+	logContext := []string{"pkg", "timeline/file"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	t.core.loggers = logh.CreateContextualLogger(logContext...)
+}
+
+// ConfigureBackend - the backend is not used by this transport
+func (t *FileTransport) ConfigureBackend(backend *Backend) error {
+
+	return nil
+}
+
+// SerializePayload - serializes a list of generic data (the same batch format sent by the http and opentsdb transports)
+func (t *FileTransport) SerializePayload(dataList []interface{}) (payload []string, err error) {
+
+	serialized, err := t.serializer.SerializeGenericArray(dataList...)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{serialized + t.separator}, nil
+}
+
+// Serialize - renders the text using the configured serializer
+func (t *FileTransport) Serialize(item interface{}) (string, error) {
+
+	return t.serializer.SerializeGeneric(item)
+}
+
+// rotatedFileName - returns the name used by the rotated file
+func (t *FileTransport) rotatedFileName() string {
+
+	name := strings.TrimSuffix(t.configuration.Path, gzipExtension)
+	name += "." + time.Now().UTC().Format(rotatedFileLayout)
+
+	if t.configuration.Gzip {
+		name += gzipExtension
+	}
+
+	return name
+}
+
+// closeFile - flushes and closes the current file
+func (t *FileTransport) closeFile() error {
+
+	if t.file == nil {
+		return nil
+	}
+
+	var err error
+
+	if t.gzipWriter != nil {
+		err = t.gzipWriter.Close()
+		t.gzipWriter = nil
+	}
+
+	if closeErr := t.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	t.file = nil
+	t.writer = nil
+
+	return err
+}
+
+// openFile - opens the file in append mode
+func (t *FileTransport) openFile() error {
+
+	file, err := os.OpenFile(t.configuration.Path, defaultFileFlags, defaultFileMode)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	t.file = file
+	t.fileSize = info.Size()
+	t.fileOpenedAt = time.Now()
+
+	// the written bytes are counted after the compression (the maximum size is the size on disk)
+	counter := &countingWriter{
+		writer: file,
+		count:  &t.fileSize,
+	}
+
+	t.writer = counter
+
+	if t.configuration.Gzip {
+		t.gzipWriter = gzip.NewWriter(counter)
+		t.writer = t.gzipWriter
+	}
+
+	return nil
+}
+
+// mustRotate - checks if the file reached the maximum size or the rotation interval
+func (t *FileTransport) mustRotate() bool {
+
+	if t.fileSize == 0 {
+		return false
+	}
+
+	if t.configuration.MaxFileSize > 0 && t.fileSize >= t.configuration.MaxFileSize {
+		return true
+	}
+
+	return t.configuration.RotationInterval.Duration > 0 && time.Since(t.fileOpenedAt) >= t.configuration.RotationInterval.Duration
+}
+
+// rotate - renames the current file and opens a new one
+func (t *FileTransport) rotate() error {
+
+	err := t.closeFile()
+	if err != nil {
+		return err
+	}
+
+	rotated := t.rotatedFileName()
+
+	err = os.Rename(t.configuration.Path, rotated)
+	if err != nil {
+		return err
+	}
+
+	if logh.InfoEnabled {
+		t.core.loggers.Info().Msgf("file rotated to: %s", rotated)
+	}
+
+	return t.openFile()
+}
+
+// TransferData - writes the data to the file or writer
+func (t *FileTransport) TransferData(payload []string) error {
+
+	if len(payload) == 0 {
+		return ErrInvalidPayloadSize
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if len(t.configuration.Path) > 0 {
+
+		if t.file == nil {
+			if err := t.openFile(); err != nil {
+				return err
+			}
+		}
+
+		if t.mustRotate() {
+			if err := t.rotate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, p := range payload {
+
+		if _, err := io.WriteString(t.writer, p); err != nil {
+			return err
+		}
+	}
+
+	if t.gzipWriter != nil {
+		return t.gzipWriter.Flush()
+	}
+
+	return nil
+}
+
+// DataChannel - send a new point
+func (t *FileTransport) DataChannel(item interface{}) {
+
+	t.core.dataChannel(item)
+}
+
+// AddPointFilter - adds a filter to be applied before buffering the points
+func (t *FileTransport) AddPointFilter(filter PointFilter) {
+
+	t.core.addPointFilter(filter)
+}
+
+// GetStats - returns the transport statistics
+func (t *FileTransport) GetStats() TransportStats {
+
+	return t.core.getStats()
+}
+
+// MatchType - checks if this transport implementation matches the given type (opentsdb or json items)
+func (t *FileTransport) MatchType(tt transportType) bool {
+
+	if t.itemTransport != nil {
+		return tt == typeOpenTSDB
+	}
+
+	return tt == typeHTTP
+}
+
+// Start - starts this transport
+func (t *FileTransport) Start(manualMode bool) error {
+
+	return t.core.Start(manualMode)
+}
+
+// Close - closes this transport and the current file (the given writer is not closed, only the gzip stream)
+func (t *FileTransport) Close() {
+
+	t.core.Close()
+
+	t.Lock()
+	defer t.Unlock()
+
+	var err error
+
+	if t.file != nil {
+		err = t.closeFile()
+	} else if t.gzipWriter != nil {
+		err = t.gzipWriter.Close()
+		t.gzipWriter = nil
+	}
+
+	if err != nil && logh.ErrorEnabled {
+		ev := t.core.loggers.Error()
+		if t.core.defaultConfiguration.PrintStackOnError {
+			ev = ev.Caller()
+		}
+		ev.Err(err).Msg("error closing the file")
+	}
+}
+
+// SendData - releases the point buffer and send all data
+func (t *FileTransport) SendData() error {
+	return t.core.SendData()
+}
@@ -1,8 +1,14 @@
 package timeline
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/uol/logh"
 	"github.com/uol/timeline/buffer"
@@ -34,20 +40,214 @@ const (
 
 	// Min - aggregation
 	Min FlatOperation = 4
+
+	// Median - aggregation (the 0.5 quantile)
+	Median FlatOperation = 5
+
+	// StdDev - aggregation (population standard deviation)
+	StdDev FlatOperation = 6
+
+	// Variance - aggregation (population variance)
+	Variance FlatOperation = 7
+
+	// P50 - aggregation (the 0.5 quantile)
+	P50 FlatOperation = 8
+
+	// P90 - aggregation (the 0.9 quantile)
+	P90 FlatOperation = 9
+
+	// P95 - aggregation (the 0.95 quantile)
+	P95 FlatOperation = 10
+
+	// P99 - aggregation (the 0.99 quantile)
+	P99 FlatOperation = 11
+
+	// First - aggregation (the first value by arrival order or by timestamp)
+	First FlatOperation = 12
+
+	// Last - aggregation (the last value by arrival order or by timestamp)
+	Last FlatOperation = 13
+
+	// Range - aggregation (max minus min)
+	Range FlatOperation = 14
+
+	// Rate - aggregation (sum divided by the cycle duration or the bucket size in seconds)
+	Rate FlatOperation = 15
+
+	// firstCustomQuantile - the first operation id used by the custom quantiles
+	firstCustomQuantile FlatOperation = 128
+
+	// OperationLabelSuffix - the operation name is appended to the metric (ex: "latency.avg")
+	OperationLabelSuffix string = "suffix"
+
+	// OperationLabelTag - the operation name is added as tag (ex: "operation=avg")
+	OperationLabelTag string = "tag"
+
+	// LateDrop - the points arriving after their bucket was closed are discarded (the default policy)
+	LateDrop string = "drop"
+
+	// LateCorrection - the bucket of the late points is emitted again with all values while it is retained by the correction window (discarded and counted as expired after it)
+	LateCorrection string = "correction"
+
+	// LateMerge - the late points are added to the current open bucket
+	LateMerge string = "merge"
+
+	defaultOperationTagKey string = "operation"
+	bucketKeySeparator     string = "@"
+)
+
+var (
+	// quantiles - the quantile of each quantile operation
+	quantiles = map[FlatOperation]float64{
+		Median: 0.5,
+		P50:    0.5,
+		P90:    0.9,
+		P95:    0.95,
+		P99:    0.99,
+	}
+
+	quantilesLock sync.RWMutex
+
+	// ErrNoCustomQuantiles - raised when all the custom quantile operation ids are in use
+	ErrNoCustomQuantiles error = errors.New("no more custom quantiles can be registered")
 )
 
+// QuantileOperation - returns the operation for the given quantile (0 <= quantile <= 1), the custom quantiles are registered on demand until the ids are exhausted
+func QuantileOperation(quantile float64) (FlatOperation, error) {
+
+	if quantile < 0 || quantile > 1 || math.IsNaN(quantile) {
+		return 0, fmt.Errorf("invalid quantile: %f", quantile)
+	}
+
+	quantilesLock.Lock()
+	defer quantilesLock.Unlock()
+
+	free := -1
+
+	// the ids are scanned in order, so the same registrations always get the same ids
+	for id := int(firstCustomQuantile); id <= math.MaxUint8; id++ {
+
+		q, ok := quantiles[FlatOperation(id)]
+		if !ok {
+			if free == -1 {
+				free = id
+			}
+			continue
+		}
+
+		if q == quantile {
+			return FlatOperation(id), nil
+		}
+	}
+
+	if free == -1 {
+		return 0, ErrNoCustomQuantiles
+	}
+
+	quantiles[FlatOperation(free)] = quantile
+
+	return FlatOperation(free), nil
+}
+
+// operationNames - the names used to label the operations
+var operationNames = map[FlatOperation]string{
+	Avg:      "avg",
+	Sum:      "sum",
+	Count:    "count",
+	Max:      "max",
+	Min:      "min",
+	Median:   "median",
+	StdDev:   "stddev",
+	Variance: "variance",
+	P50:      "p50",
+	P90:      "p90",
+	P95:      "p95",
+	P99:      "p99",
+	First:    "first",
+	Last:     "last",
+	Range:    "range",
+	Rate:     "rate",
+}
+
+// String - returns the operation name (the custom quantiles are named by percentile, ex: "p99.9")
+func (operation FlatOperation) String() string {
+
+	if name, ok := operationNames[operation]; ok {
+		return name
+	}
+
+	if q, ok := quantileOf(operation); ok {
+		return "p" + strconv.FormatFloat(q*100, 'f', -1, 64)
+	}
+
+	return "op" + strconv.Itoa(int(operation))
+}
+
+// quantileOf - returns the quantile of the operation (false if it is not a quantile operation)
+func quantileOf(operation FlatOperation) (float64, bool) {
+
+	quantilesLock.RLock()
+	defer quantilesLock.RUnlock()
+
+	q, ok := quantiles[operation]
+
+	return q, ok
+}
+
 // flattenerPointData - all common properties from a point
 type flattenerPointData struct {
 	operation       FlatOperation
+	operations      []FlatOperation
 	timestamp       int64
 	dataChannelItem interface{}
 }
 
+// timedValue - a value and its timestamp (used by the first and last operations)
+type timedValue struct {
+	value     float64
+	timestamp int64
+}
+
+// operationLabel - identifies the operation of a point flattened with multiple operations
+type operationLabel struct {
+	suffix   string
+	tagKey   string
+	tagValue string
+}
+
+// matches - checks if the rollup is applied to the metric
+func (r *FlattenRollup) matches(metric string) bool {
+
+	return len(r.Metric) == 0 || r.Metric == metric
+}
+
+// keepsTag - checks if the tag is kept by the rollup
+func (r *FlattenRollup) keepsTag(key string) bool {
+
+	if len(r.KeepTags) > 0 {
+		for _, kept := range r.KeepTags {
+			if kept == key {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, dropped := range r.DropTags {
+		if dropped == key {
+			return false
+		}
+	}
+
+	return true
+}
+
 // FlattenerPoint - a flattener's point containing the value
 type FlattenerPoint struct {
 	flattenerPointData
 	hash  string
 	value float64
+	label *operationLabel
 }
 
 // GetHash - returns the hash
@@ -55,25 +255,65 @@ func (fp *FlattenerPoint) GetHash() string {
 	return fp.hash
 }
 
+// FlattenerStats - the flattener statistics (the late points by policy)
+type FlattenerStats struct {
+	LateDropped   uint64
+	LateCorrected uint64
+	LateExpired   uint64
+	LateMerged    uint64
+}
+
 // Flattener - controls the timeline's point flattening
 type Flattener struct {
 	dataProcessorCore
+	lateDropped   uint64
+	lateCorrected uint64
+	lateExpired   uint64
+	lateMerged    uint64
 }
 
-// mapEntry - a map entry containing all values from a point
+// mapEntry - a map entry containing the running aggregates from a point (the values are kept only by exact quantiles)
 type mapEntry struct {
 	flattenerPointData
-	values buffer.Buffer
+	aggregates runningAggregates
+	first      timedValue
+	last       timedValue
+	values     *buffer.Buffer
+	sketch     *quantileSketch
+	bucketEnd  int64
+	emitted    bool
+	removed    bool
+	valuesLock sync.Mutex
 	sync.Mutex
 }
 
 // Release - releases the resources
 func (ad *mapEntry) Release() {
-	ad.values.Release()
+	if ad.values != nil {
+		ad.values.Release()
+	}
 	return
 }
 
-// NewFlattener - creates a new flattener
+// Validate - validates the flattener options
+func (c *DataTransformerConfig) Validate() error {
+
+	switch c.LatePolicy {
+	case "", LateDrop, LateCorrection, LateMerge:
+	default:
+		return fmt.Errorf("invalid late policy: %s", c.LatePolicy)
+	}
+
+	switch c.OperationLabel {
+	case "", OperationLabelSuffix, OperationLabelTag:
+	default:
+		return fmt.Errorf("invalid operation label: %s", c.OperationLabel)
+	}
+
+	return nil
+}
+
+// NewFlattener - creates a new flattener (the configuration is validated by NewManager)
 func NewFlattener(configuration *DataTransformerConfig) *Flattener {
 
 	configuration.isSHAKE = isShakeAlgorithm(configuration.HashingAlgorithm)
@@ -102,36 +342,263 @@ func (f *Flattener) BuildContextualLogger(path ...string) {
 	f.loggers = logh.CreateContextualLogger(logContext...)
 }
 
-// Add - adds a new entry to the flattening process
+// entryOperations - returns the operations from the entry
+func (ad *flattenerPointData) entryOperations() []FlatOperation {
+
+	if len(ad.operations) > 0 {
+		return ad.operations
+	}
+
+	return []FlatOperation{ad.operation}
+}
+
+// bucketSize - returns the bucket size in seconds (zero when the buckets are not aligned, at least one second otherwise)
+func (f *Flattener) bucketSize() int64 {
+
+	if f.configuration.BucketSize.Duration <= 0 {
+		return 0
+	}
+
+	if size := int64(f.configuration.BucketSize.Seconds()); size > 1 {
+		return size
+	}
+
+	return 1
+}
+
+// lateness - returns the allowed lateness in seconds
+func (f *Flattener) lateness() int64 {
+
+	return int64(f.configuration.AllowedLateness.Seconds())
+}
+
+// isClosed - checks if the bucket is closed (the watermark, now minus the allowed lateness, passed the bucket end)
+func (f *Flattener) isClosed(bucketEnd, now int64) bool {
+
+	return bucketEnd <= now-f.lateness()
+}
+
+// isCorrectable - checks if the emitted bucket can still be corrected by the late points
+func (f *Flattener) isCorrectable(bucketEnd, now int64) bool {
+
+	window := int64(f.configuration.CorrectionWindow.Seconds())
+	if window <= 0 {
+		window = f.bucketSize()
+	}
+
+	return bucketEnd+window > now-f.lateness()
+}
+
+// bucketKey - returns the map key of the series bucket
+func bucketKey(hash string, bucketStart int64) string {
+
+	return hash + bucketKeySeparator + strconv.FormatInt(bucketStart, 10)
+}
+
+// alignTimestamp - truncates the timestamp (in seconds) to the bucket start
+func alignTimestamp(timestamp, bucketSize int64) int64 {
+
+	aligned := timestamp - timestamp%bucketSize
+	if timestamp < 0 && aligned != timestamp {
+		aligned -= bucketSize
+	}
+
+	return aligned
+}
+
+// Add - adds a new entry to the flattening process (to the bucket of the point timestamp when the buckets are aligned)
 func (f *Flattener) Add(point *FlattenerPoint) error {
 
-	item, ok := f.pointMap.Load(point.hash)
-	if ok {
-		entry := item.(*mapEntry)
-		entry.values.Add(point.value)
-		return nil
+	key := point.hash
+	bucketSize := f.bucketSize()
+	bucketStart := int64(0)
+
+	if bucketSize > 0 {
+		bucketStart = alignTimestamp(point.timestamp, bucketSize)
 	}
 
+	for {
+
+		if bucketSize > 0 {
+
+			var handled bool
+			var err error
+
+			bucketStart, handled, err = f.addLate(point, bucketStart, bucketSize)
+			if handled || err != nil {
+				return err
+			}
+
+			key = bucketKey(point.hash, bucketStart)
+		}
+
+		item, ok := f.pointMap.Load(key)
+		if !ok {
+			item, ok = f.pointMap.LoadOrStore(key, f.newEntry(point, bucketStart, bucketSize))
+			if !ok {
+				return nil
+			}
+		}
+
+		// the cycle can emit the entry after the bucket was checked, then the point is added again (late or to a new entry)
+		if item.(*mapEntry).addPending(point, f.configuration.FirstLastByTimestamp) {
+			return nil
+		}
+	}
+}
+
+// newEntry - creates a new entry using the first point
+func (f *Flattener) newEntry(point *FlattenerPoint, bucketStart, bucketSize int64) *mapEntry {
+
+	value := timedValue{value: point.value, timestamp: point.timestamp}
+
 	entry := &mapEntry{
-		values: buffer.Buffer{},
-		flattenerPointData: flattenerPointData{
-			operation:       point.operation,
-			timestamp:       point.timestamp,
-			dataChannelItem: point.dataChannelItem,
-		},
+		flattenerPointData: point.flattenerPointData,
+		aggregates:         newRunningAggregates(point.value),
+		first:              value,
+		last:               value,
 	}
 
-	entry.values.Add(point.value)
+	if bucketSize > 0 {
+		entry.timestamp = bucketStart
+		entry.bucketEnd = bucketStart + bucketSize
+	}
 
-	f.pointMap.Store(point.hash, entry)
+	if hasQuantiles(entry.entryOperations()) {
+		if f.configuration.QuantileSketch {
+			entry.sketch = newQuantileSketch(f.configuration.SketchRelativeAccuracy)
+			entry.sketch.add(point.value)
+		} else {
+			entry.values = buffer.New()
+			entry.values.Add(point.value)
+		}
+	}
 
-	return nil
+	return entry
+}
+
+// addLate - applies the late policy when the point bucket is closed, returns the bucket to be used and true if the point was already handled (dropped by default)
+func (f *Flattener) addLate(point *FlattenerPoint, bucketStart, bucketSize int64) (int64, bool, error) {
+
+	now := time.Now().Unix()
+	bucketEnd := bucketStart + bucketSize
+
+	if !f.isClosed(bucketEnd, now) {
+		return bucketStart, false, nil
+	}
+
+	if item, ok := f.pointMap.Load(bucketKey(point.hash, bucketStart)); ok {
+
+		entry := item.(*mapEntry)
+		entry.Lock()
+		defer entry.Unlock()
+
+		if !entry.removed {
+
+			if !entry.emitted {
+				entry.add(point, f.configuration.FirstLastByTimestamp)
+				return bucketStart, true, nil
+			}
+
+			if f.configuration.LatePolicy == LateCorrection {
+				entry.add(point, f.configuration.FirstLastByTimestamp)
+				entry.emitted = false
+				atomic.AddUint64(&f.lateCorrected, 1)
+				return bucketStart, true, nil
+			}
+		}
+	}
+
+	switch f.configuration.LatePolicy {
+	case "", LateDrop:
+		atomic.AddUint64(&f.lateDropped, 1)
+		return bucketStart, true, nil
+	case LateCorrection:
+		// the corrections are only applied to the retained buckets (never to a partial one)
+		atomic.AddUint64(&f.lateExpired, 1)
+		return bucketStart, true, nil
+	case LateMerge:
+		atomic.AddUint64(&f.lateMerged, 1)
+		return alignTimestamp(now-f.lateness(), bucketSize), false, nil
+	default:
+		// unreachable, the policy is validated by the manager
+		return bucketStart, true, fmt.Errorf("invalid late policy: %s", f.configuration.LatePolicy)
+	}
+}
+
+// hasQuantiles - checks if there is some quantile operation
+func hasQuantiles(operations []FlatOperation) bool {
+
+	for _, operation := range operations {
+		if _, isQuantile := quantileOf(operation); isQuantile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addPending - adds the point if the entry was not emitted by the cycle, returns false otherwise
+func (ad *mapEntry) addPending(point *FlattenerPoint, byTimestamp bool) bool {
+
+	ad.Lock()
+	defer ad.Unlock()
+
+	if ad.removed || ad.emitted {
+		return false
+	}
+
+	ad.add(point, byTimestamp)
+
+	return true
+}
+
+// add - adds the point value to the entry aggregates
+func (ad *mapEntry) add(point *FlattenerPoint, byTimestamp bool) {
+
+	ad.valuesLock.Lock()
+	defer ad.valuesLock.Unlock()
+
+	ad.aggregates.add(point.value)
+
+	if ad.values != nil {
+		ad.values.Add(point.value)
+	}
+
+	if ad.sketch != nil {
+		ad.sketch.add(point.value)
+	}
+
+	value := timedValue{value: point.value, timestamp: point.timestamp}
+
+	if !byTimestamp || value.timestamp >= ad.last.timestamp {
+		ad.last = value
+	}
+
+	if byTimestamp && value.timestamp < ad.first.timestamp {
+		ad.first = value
+	}
 }
 
-// ProcessMapEntry - process the values from an entry
+// ProcessMapEntry - process the values from an entry (the aligned buckets are kept until they are closed and retained by the correction window after emitted)
 func (f *Flattener) ProcessMapEntry(entry DataProcessorEntry) bool {
 
-	newValue, err := f.flatten(entry.(*mapEntry))
+	casted := entry.(*mapEntry)
+	now := time.Now().Unix()
+
+	if casted.bucketEnd > 0 {
+
+		if !f.isClosed(casted.bucketEnd, now) {
+			return false
+		}
+
+		if casted.emitted {
+			casted.removed = !f.isCorrectable(casted.bucketEnd, now)
+			return casted.removed
+		}
+	}
+
+	newValues, err := f.flatten(casted)
 	if err != nil {
 		if logh.ErrorEnabled {
 			ev := f.loggers.Error()
@@ -144,80 +611,187 @@ func (f *Flattener) ProcessMapEntry(entry DataProcessorEntry) bool {
 		return false
 	}
 
-	item, err := f.transport.FlattenerPointToDataChannelItem(newValue)
-	if err != nil {
-		if logh.ErrorEnabled {
-			ev := f.loggers.Error()
-			if f.dataProcessorCore.configuration.PrintStackOnError {
-				ev = ev.Caller()
+	for _, newValue := range newValues {
+
+		item, err := f.transport.FlattenerPointToDataChannelItem(newValue)
+		if err != nil {
+			if logh.ErrorEnabled {
+				ev := f.loggers.Error()
+				if f.dataProcessorCore.configuration.PrintStackOnError {
+					ev = ev.Caller()
+				}
+				ev.Err(err).Msg("error on casting operation")
 			}
-			ev.Err(err).Msg("error on casting operation")
+
+			return false
 		}
 
+		if f.observer != nil {
+			f.observer.flattenedPointEmitted(item)
+		}
+
+		f.transport.DataChannel(item)
+	}
+
+	if casted.bucketEnd > 0 && f.configuration.LatePolicy == LateCorrection && f.isCorrectable(casted.bucketEnd, now) {
+		casted.emitted = true
 		return false
 	}
 
-	f.transport.DataChannel(item)
+	casted.removed = true
 
 	return true
 }
 
-// flatten - flats the values using the specified operation
-func (f *Flattener) flatten(entry *mapEntry) (*FlattenerPoint, error) {
+// flatten - flats the values using the entry operations (one point per operation)
+func (f *Flattener) flatten(entry *mapEntry) ([]*FlattenerPoint, error) {
 
-	var flatValue float64
-	var size float64
-	values := entry.values.GetAll()
+	operations := entry.entryOperations()
+	points := make([]*FlattenerPoint, len(operations))
 
-	switch entry.operation {
+	entry.valuesLock.Lock()
+	defer entry.valuesLock.Unlock()
 
-	case Avg, Sum, Count:
+	aggregates := entry.aggregates
 
-		for i := 0; i < len(values); i++ {
-			flatValue += values[i].(float64)
-			size++
-		}
+	var sorted []float64
+	if entry.values != nil {
+		sorted = sortedValues(entry.values.GetAll())
+	}
 
-	case Min:
+	for i, operation := range operations {
 
-		flatValue = values[0].(float64)
+		point := &FlattenerPoint{
+			flattenerPointData: entry.flattenerPointData,
+		}
 
-		for i := 1; i < len(values); i++ {
-			current := values[i].(float64)
-			if current < flatValue {
-				flatValue = current
+		point.operation = operation
+		point.operations = nil
+
+		if q, isQuantile := quantileOf(operation); isQuantile {
+			if entry.sketch != nil {
+				point.value = entry.sketch.quantile(q)
+			} else {
+				point.value = quantile(sorted, q)
+			}
+		} else {
+			var err error
+			point.value, point.timestamp, err = f.compute(operation, entry, aggregates)
+			if err != nil {
+				return nil, err
 			}
-			size++
 		}
 
-	case Max:
+		if entry.bucketEnd > 0 {
+			point.timestamp = entry.timestamp
+		}
 
-		flatValue = values[0].(float64)
+		if len(entry.operations) > 0 {
+			point.label = f.buildLabel(operation)
+		}
 
-		for i := 1; i < len(values); i++ {
-			current := values[i].(float64)
-			if current > flatValue {
-				flatValue = current
-			}
-			size++
+		points[i] = point
+	}
+
+	return points, nil
+}
+
+// buildLabel - builds the label identifying the operation (metric suffix or operation tag)
+func (f *Flattener) buildLabel(operation FlatOperation) *operationLabel {
+
+	if f.configuration.OperationLabel == OperationLabelTag {
+
+		tagKey := f.configuration.OperationTagKey
+		if len(tagKey) == 0 {
+			tagKey = defaultOperationTagKey
 		}
 
-	default:
+		return &operationLabel{
+			tagKey:   tagKey,
+			tagValue: operation.String(),
+		}
+	}
 
-		return nil, fmt.Errorf("operation id %d is not mapped", entry.operation)
+	return &operationLabel{
+		suffix: "." + operation.String(),
 	}
+}
+
+// compute - computes the value of one operation using the aggregates, returns the value and the point timestamp
+func (f *Flattener) compute(operation FlatOperation, entry *mapEntry, aggregates runningAggregates) (float64, int64, error) {
 
-	switch entry.operation {
+	switch operation {
 	case Avg:
-		flatValue /= size
+		return aggregates.sum / aggregates.count, entry.timestamp, nil
+	case Sum:
+		return aggregates.sum, entry.timestamp, nil
 	case Count:
-		flatValue = size
+		return aggregates.count, entry.timestamp, nil
+	case Max:
+		return aggregates.max, entry.timestamp, nil
+	case Min:
+		return aggregates.min, entry.timestamp, nil
+	case Range:
+		return aggregates.max - aggregates.min, entry.timestamp, nil
+	case Variance:
+		return aggregates.variance(), entry.timestamp, nil
+	case StdDev:
+		return math.Sqrt(aggregates.variance()), entry.timestamp, nil
+	case First:
+		return entry.first.value, entry.first.timestamp, nil
+	case Last:
+		return entry.last.value, entry.last.timestamp, nil
+	case Rate:
+		seconds := f.configuration.CycleDuration.Seconds()
+		if entry.bucketEnd > 0 {
+			seconds = float64(entry.bucketEnd - entry.timestamp)
+		}
+		if seconds <= 0 {
+			return 0, entry.timestamp, fmt.Errorf("the rate operation requires a cycle duration")
+		}
+		return aggregates.sum / seconds, entry.timestamp, nil
+	default:
+		return 0, entry.timestamp, fmt.Errorf("operation id %d is not mapped", operation)
 	}
+}
+
+// sortedValues - returns the buffered values sorted
+func sortedValues(values []interface{}) []float64 {
+
+	sorted := make([]float64, len(values))
+	for i := 0; i < len(values); i++ {
+		sorted[i] = values[i].(float64)
+	}
+
+	sort.Float64s(sorted)
+
+	return sorted
+}
+
+// quantile - returns the quantile of the sorted values using linear interpolation between the closest ranks
+func quantile(sorted []float64, q float64) float64 {
 
-	return &FlattenerPoint{
-		flattenerPointData: entry.flattenerPointData,
-		value:              flatValue,
-	}, nil
+	size := len(sorted)
+	if size == 0 {
+		return 0
+	}
+
+	rank := q * float64(size-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// GetStats - returns the number of late points by policy
+func (f *Flattener) GetStats() FlattenerStats {
+
+	return FlattenerStats{
+		LateDropped:   atomic.LoadUint64(&f.lateDropped),
+		LateCorrected: atomic.LoadUint64(&f.lateCorrected),
+		LateExpired:   atomic.LoadUint64(&f.lateExpired),
+		LateMerged:    atomic.LoadUint64(&f.lateMerged),
+	}
 }
 
 // GetName - returns the processor's name
@@ -4,6 +4,7 @@ go 1.14
 
 require (
 	github.com/BurntSushi/toml v0.3.1
+	github.com/golang/snappy v0.0.2
 	github.com/rs/zerolog v1.20.0 // indirect
 	github.com/stretchr/testify v1.6.1
 	github.com/uol/funks v1.3.1
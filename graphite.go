@@ -0,0 +1,315 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/uol/logh"
+	serializer "github.com/uol/serializer/opentsdb"
+)
+
+/**
+* The Graphite plaintext protocol transport implementation (tcp or udp).
+* @author rnojiri
+**/
+
+const (
+	// GraphiteTCP - sends the points using a tcp connection
+	GraphiteTCP string = "tcp"
+
+	// GraphiteUDP - sends the points using udp datagrams
+	GraphiteUDP string = "udp"
+)
+
+var (
+	graphitePathSanitizer     = strings.NewReplacer(" ", "_", "\t", "_", "\n", "_", ";", "_")
+	graphiteTagKeySanitizer   = strings.NewReplacer(" ", "_", "\t", "_", "\n", "_", ";", "_", "!", "_", "^", "_", "=", "_")
+	graphiteTagValueSanitizer = strings.NewReplacer(" ", "_", "\t", "_", "\n", "_", ";", "_")
+)
+
+// GraphiteTransport - implements the graphite plaintext transport
+type GraphiteTransport struct {
+	core          transportCore
+	configuration *GraphiteTransportConfig
+	address       net.Addr
+	networkConn   *rawNetworkConnection
+	itemTransport *openTSDBItemTransport
+}
+
+// NewGraphiteTransport - creates a new graphite event manager
+func NewGraphiteTransport(configuration *GraphiteTransportConfig) (*GraphiteTransport, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch configuration.Protocol {
+
+	case GraphiteTCP, empty:
+
+		configuration.Protocol = GraphiteTCP
+
+	case GraphiteUDP:
+
+		if configuration.MaxDatagramSize < 0 {
+			return nil, fmt.Errorf("invalid maximum datagram size: %d", configuration.MaxDatagramSize)
+		}
+
+		if configuration.MaxDatagramSize == 0 {
+			configuration.MaxDatagramSize = defaultMaxDatagramSize
+		}
+
+	default:
+
+		return nil, fmt.Errorf("invalid protocol: %s", configuration.Protocol)
+	}
+
+	if configuration.ReconnectionTimeout.Seconds() <= 0 {
+		return nil, fmt.Errorf("invalid connection reconnection timeout: %s", configuration.ReconnectionTimeout)
+	}
+
+	if configuration.MaxReconnectionRetries == 0 {
+		configuration.MaxReconnectionRetries = defaultConnRetries
+	}
+
+	t := &GraphiteTransport{
+		core: transportCore{
+			batchSendInterval:    configuration.BatchSendInterval.Duration,
+			defaultConfiguration: &configuration.DefaultTransportConfig,
+		},
+		networkConn: &rawNetworkConnection{
+			transportConfiguration: &configuration.DefaultTransportConfig,
+			configuration:          &configuration.TCPUDPTransportConfig,
+		},
+		itemTransport: &openTSDBItemTransport{},
+		configuration: configuration,
+	}
+
+	t.core.transport = t
+	t.networkConn.custom = t
+
+	return t, nil
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (t *GraphiteTransport) BuildContextualLogger(path ...string) {
+
+	if t.core.loggers != nil {
+		return
+	}
+
+	logContext := []string{"pkg", "timeline/graphite"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	t.core.loggers = logh.CreateContextualLogger(logContext...)
+	t.networkConn.loggers = t.core.loggers
+}
+
+// ConfigureBackend - configures the backend
+func (t *GraphiteTransport) ConfigureBackend(backend *Backend) error {
+
+	if backend == nil {
+		return fmt.Errorf("no backend was configured")
+	}
+
+	var err error
+	hostPort := fmt.Sprintf("%s:%d", backend.Host, backend.Port)
+
+	if t.configuration.Protocol == GraphiteUDP {
+		t.address, err = net.ResolveUDPAddr("udp", hostPort)
+	} else {
+		t.address, err = net.ResolveTCPAddr("tcp", hostPort)
+	}
+
+	return err
+}
+
+// serializeLine - serializes a point using the plaintext protocol
+func (t *GraphiteTransport) serializeLine(b *strings.Builder, item *serializer.ArrayItem) error {
+
+	numTags := len(item.Tags)
+	if numTags%2 != 0 {
+		return fmt.Errorf("the number of tags must be even")
+	}
+
+	if len(item.Metric) == 0 {
+		return fmt.Errorf("empty metric path")
+	}
+
+	if math.IsNaN(item.Value) || math.IsInf(item.Value, 0) {
+		return fmt.Errorf("the plaintext protocol does not support NaN or Inf values: %s", item.Metric)
+	}
+
+	b.WriteString(graphitePathSanitizer.Replace(item.Metric))
+
+	if t.configuration.Tagged && numTags > 0 {
+
+		tags := make([]string, 0, numTags/2)
+
+		for i := 0; i < numTags; i += 2 {
+
+			key, ok := item.Tags[i].(string)
+			if !ok {
+				return fmt.Errorf("error casting tag key to string")
+			}
+
+			if item.Tags[i+1] == nil {
+				continue
+			}
+
+			value := fmt.Sprint(item.Tags[i+1])
+
+			// graphite does not accept empty tags
+			if len(key) == 0 || len(value) == 0 {
+				continue
+			}
+
+			tags = append(tags, graphiteTagKeySanitizer.Replace(key)+"="+strings.TrimLeft(graphiteTagValueSanitizer.Replace(value), "~"))
+		}
+
+		sort.Strings(tags)
+
+		for _, tag := range tags {
+			b.WriteByte(';')
+			b.WriteString(tag)
+		}
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(item.Value, 'f', -1, 64))
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(item.Timestamp, 10))
+	b.WriteByte('\n')
+
+	return nil
+}
+
+// SerializePayload - serializes a list of generic data
+func (t *GraphiteTransport) SerializePayload(dataList []interface{}) (payload []string, err error) {
+
+	lines := make([]string, len(dataList))
+
+	for i, data := range dataList {
+
+		lines[i], err = t.Serialize(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if t.configuration.Protocol == GraphiteTCP {
+		return []string{strings.Join(lines, empty)}, nil
+	}
+
+	return t.core.packDatagrams(lines, empty, t.configuration.MaxDatagramSize), nil
+}
+
+// Serialize - renders the plaintext protocol line
+func (t *GraphiteTransport) Serialize(item interface{}) (string, error) {
+
+	casted, ok := item.(*serializer.ArrayItem)
+	if !ok {
+		return empty, fmt.Errorf("unexpected instance type: %+v", item)
+	}
+
+	var b strings.Builder
+
+	err := t.serializeLine(&b, casted)
+	if err != nil {
+		return empty, err
+	}
+
+	return b.String(), nil
+}
+
+func (t *GraphiteTransport) getAddress() net.Addr {
+
+	return t.address
+}
+
+// read - graphite does not send responses
+func (t *GraphiteTransport) read(conn net.Conn, logConnError func(error, rwOp)) bool {
+
+	return true
+}
+
+func (t *GraphiteTransport) dial() (net.Conn, error) {
+
+	if t.configuration.Protocol == GraphiteUDP {
+		return net.DialUDP("udp", nil, t.address.(*net.UDPAddr))
+	}
+
+	return net.DialTCP("tcp", nil, t.address.(*net.TCPAddr))
+}
+
+// TransferData - transfers the data to the backend throught this transport
+func (t *GraphiteTransport) TransferData(payload []string) error {
+
+	size := len(payload)
+	if size == 0 || (size > 1 && t.configuration.Protocol == GraphiteTCP) {
+		return ErrInvalidPayloadSize
+	}
+
+	for _, p := range payload {
+
+		err := t.networkConn.transferData(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DataChannel - send a new point
+func (t *GraphiteTransport) DataChannel(item interface{}) {
+
+	t.core.dataChannel(item)
+}
+
+// AddPointFilter - adds a filter to be applied before buffering the points
+func (t *GraphiteTransport) AddPointFilter(filter PointFilter) {
+
+	t.core.addPointFilter(filter)
+}
+
+// GetStats - returns the transport statistics
+func (t *GraphiteTransport) GetStats() TransportStats {
+
+	return t.core.getStats()
+}
+
+// MatchType - checks if this transport implementation matches the given type
+func (t *GraphiteTransport) MatchType(tt transportType) bool {
+
+	return tt == typeGraphite
+}
+
+// Start - starts this transport
+func (t *GraphiteTransport) Start(manualMode bool) error {
+
+	return t.core.Start(manualMode)
+}
+
+// Close - closes this transport
+func (t *GraphiteTransport) Close() {
+
+	t.core.Close()
+	t.networkConn.closeConnection()
+}
+
+// SendData - releases the point buffer and send all data
+func (t *GraphiteTransport) SendData() error {
+	return t.core.SendData()
+}
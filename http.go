@@ -123,37 +123,84 @@ func (t *HTTPTransport) TransferData(payload []string) error {
 		return ErrInvalidPayloadSize
 	}
 
-	req, err := http.NewRequest(t.configuration.Method, t.serviceURL, bytes.NewBuffer([]byte(payload[0])))
+	return doHTTPRequest(t.httpClient, t.configuration.Method, t.serviceURL, payload[0], t.configuration.Headers, exactStatus(t.configuration.ExpectedResponseStatus))
+}
+
+// exactStatus - accepts only the expected response status
+func exactStatus(expected int) func(status int) bool {
+
+	return func(status int) bool {
+		return status == expected
+	}
+}
+
+// successStatus - accepts any 2xx response status
+func successStatus(status int) bool {
+
+	return status/100 == 2
+}
+
+// doHTTPRequest - does the request and checks the response status
+func doHTTPRequest(client *http.Client, method, url, payload string, headers map[string]string, accepted func(status int) bool) error {
+
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(payload))
 	if err != nil {
 		return err
 	}
 
-	if len(t.configuration.Headers) > 0 {
-		for k, v := range t.configuration.Headers {
-			req.Header.Set(k, v)
-		}
+	for k, v := range headers {
+		req.Header.Set(k, v)
 	}
 
-	res, err := t.httpClient.Do(req)
+	res, err := client.Do(req)
 	if err != nil {
 		return err
 	}
 
-	if res.StatusCode != t.configuration.ExpectedResponseStatus {
+	defer res.Body.Close()
+
+	if !accepted(res.StatusCode) {
 
 		reqResponse, err := ioutil.ReadAll(res.Body)
 		if err != nil {
 			return fmt.Errorf("error reading body: %s", err.Error())
 		}
 
-		return fmt.Errorf("error body: %s", string(reqResponse))
+		return &httpStatusError{
+			statusCode: res.StatusCode,
+			body:       string(reqResponse),
+			retryAfter: res.Header.Get("Retry-After"),
+		}
 	}
 
-	res.Body.Close()
-
 	return nil
 }
 
+// httpStatusError - raised when the response status is not the expected one
+type httpStatusError struct {
+	statusCode int
+	body       string
+	retryAfter string
+}
+
+// Error - returns the error message
+func (e *httpStatusError) Error() string {
+
+	return fmt.Sprintf("error body: %s", e.body)
+}
+
+// AddPointFilter - adds a filter to be applied before buffering the points
+func (t *HTTPTransport) AddPointFilter(filter PointFilter) {
+
+	t.core.addPointFilter(filter)
+}
+
+// GetStats - returns the transport statistics
+func (t *HTTPTransport) GetStats() TransportStats {
+
+	return t.core.getStats()
+}
+
 // MatchType - checks if this transport implementation matches the given type
 func (t *HTTPTransport) MatchType(tt transportType) bool {
 
@@ -0,0 +1,90 @@
+package timeline
+
+import (
+	"math"
+	"math/bits"
+)
+
+/**
+* A small HyperLogLog sketch to estimate the number of distinct hashes using constant memory.
+* @author rnojiri
+**/
+
+const (
+	// defaultHLLPrecision - 2^12 registers, ~1.6% of standard error using 4KB
+	defaultHLLPrecision uint8 = 12
+
+	// tagHLLPrecision - 2^10 registers, ~3.2% of standard error using 1KB
+	tagHLLPrecision uint8 = 10
+)
+
+// hyperLogLog - the sketch
+type hyperLogLog struct {
+	registers []uint8
+	precision uint8
+	estimate  uint64
+	changed   bool
+}
+
+// newHyperLogLog - creates a new sketch with 2^precision registers
+func newHyperLogLog(precision uint8) *hyperLogLog {
+
+	return &hyperLogLog{
+		registers: make([]uint8, 1<<precision),
+		precision: precision,
+	}
+}
+
+// add - adds a (well distributed) hash to the sketch
+func (h *hyperLogLog) add(hash uint64) {
+
+	index := hash >> (64 - h.precision)
+	rank := uint8(bits.LeadingZeros64(hash<<h.precision|1<<(h.precision-1))) + 1
+
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+		h.changed = true
+	}
+}
+
+// count - returns the estimated number of distinct hashes
+func (h *hyperLogLog) count() uint64 {
+
+	if !h.changed {
+		return h.estimate
+	}
+
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+
+	for _, r := range h.registers {
+		sum += 1.0 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := (0.7213 / (1 + 1.079/m)) * m * m / sum
+
+	// small range correction (linear counting)
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	h.estimate = uint64(estimate + 0.5)
+	h.changed = false
+
+	return h.estimate
+}
+
+// reset - clears the sketch
+func (h *hyperLogLog) reset() {
+
+	for i := range h.registers {
+		h.registers[i] = 0
+	}
+
+	h.estimate = 0
+	h.changed = false
+}
@@ -0,0 +1,382 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/uol/funks"
+	"github.com/uol/logh"
+	serializer "github.com/uol/serializer/opentsdb"
+)
+
+/**
+* The InfluxDB line protocol transport implementation (http or udp).
+* @author rnojiri
+**/
+
+const (
+	// InfluxHTTP - sends the points using the http /write endpoint
+	InfluxHTTP string = "http"
+
+	// InfluxUDP - sends the points using the udp listener
+	InfluxUDP string = "udp"
+
+	defaultInfluxEndpoint string = "/write"
+	defaultInfluxFieldKey string = "value"
+	defaultInfluxStatus   int    = http.StatusNoContent
+)
+
+var (
+	// influxPrecisions - the number of time units in a second for each precision
+	influxPrecisions = map[string]int64{
+		"s":  1,
+		"ms": 1e3,
+		"u":  1e6,
+		"ns": 1e9,
+	}
+
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+// InfluxTransport - implements the influxdb line protocol transport
+type InfluxTransport struct {
+	core                transportCore
+	configuration       *InfluxTransportConfig
+	httpClient          *http.Client
+	serviceURL          string
+	address             *net.UDPAddr
+	udpNetworkConn      *rawNetworkConnection
+	itemTransport       *openTSDBItemTransport
+	timestampMultiplier int64
+	fieldKey            string
+}
+
+// NewInfluxTransport - creates a new influxdb event manager
+func NewInfluxTransport(configuration *InfluxTransportConfig) (*InfluxTransport, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(configuration.Precision) == 0 {
+		configuration.Precision = "s"
+	}
+
+	multiplier, ok := influxPrecisions[configuration.Precision]
+	if !ok {
+		return nil, fmt.Errorf("invalid precision: %s", configuration.Precision)
+	}
+
+	if len(configuration.FieldKey) == 0 {
+		configuration.FieldKey = defaultInfluxFieldKey
+	}
+
+	t := &InfluxTransport{
+		core: transportCore{
+			batchSendInterval:    configuration.BatchSendInterval.Duration,
+			defaultConfiguration: &configuration.DefaultTransportConfig,
+		},
+		itemTransport:       &openTSDBItemTransport{},
+		configuration:       configuration,
+		timestampMultiplier: multiplier,
+		fieldKey:            influxTagEscaper.Replace(configuration.FieldKey),
+	}
+
+	switch configuration.Protocol {
+
+	case InfluxHTTP, empty:
+
+		configuration.Protocol = InfluxHTTP
+
+		if len(configuration.Database) == 0 {
+			return nil, fmt.Errorf("database is not configured")
+		}
+
+		if len(configuration.ServiceEndpoint) == 0 {
+			configuration.ServiceEndpoint = defaultInfluxEndpoint
+		}
+
+		if configuration.ExpectedResponseStatus == 0 {
+			configuration.ExpectedResponseStatus = defaultInfluxStatus
+		}
+
+		t.httpClient = funks.CreateHTTPClient(configuration.RequestTimeout.Duration, true)
+
+	case InfluxUDP:
+
+		if configuration.ReconnectionTimeout.Seconds() <= 0 {
+			return nil, fmt.Errorf("invalid connection reconnection timeout: %s", configuration.ReconnectionTimeout)
+		}
+
+		if configuration.MaxReconnectionRetries == 0 {
+			configuration.MaxReconnectionRetries = defaultConnRetries
+		}
+
+		t.udpNetworkConn = &rawNetworkConnection{
+			transportConfiguration: &configuration.DefaultTransportConfig,
+			configuration:          &configuration.TCPUDPTransportConfig,
+			custom:                 t,
+		}
+
+	default:
+
+		return nil, fmt.Errorf("invalid protocol: %s", configuration.Protocol)
+	}
+
+	t.core.transport = t
+
+	return t, nil
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (t *InfluxTransport) BuildContextualLogger(path ...string) {
+
+	if t.core.loggers != nil {
+		return
+	}
+
+	logContext := []string{"pkg", "timeline/influx"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	t.core.loggers = logh.CreateContextualLogger(logContext...)
+
+	if t.udpNetworkConn != nil {
+		t.udpNetworkConn.loggers = t.core.loggers
+	}
+}
+
+// ConfigureBackend - configures the backend
+func (t *InfluxTransport) ConfigureBackend(backend *Backend) error {
+
+	if backend == nil {
+		return fmt.Errorf("no backend was configured")
+	}
+
+	if t.configuration.Protocol == InfluxUDP {
+
+		var err error
+		t.address, err = net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", backend.Host, backend.Port))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	query := url.Values{}
+	query.Set("db", t.configuration.Database)
+	query.Set("precision", t.configuration.Precision)
+
+	if len(t.configuration.RetentionPolicy) > 0 {
+		query.Set("rp", t.configuration.RetentionPolicy)
+	}
+
+	t.serviceURL = fmt.Sprintf("http://%s:%d/%s?%s", backend.Host, backend.Port, strings.TrimPrefix(t.configuration.ServiceEndpoint, "/"), query.Encode())
+
+	if logh.InfoEnabled {
+		t.core.loggers.Info().Msg(fmt.Sprintf("backend was configured to use service: %s", t.serviceURL))
+	}
+
+	return nil
+}
+
+// serializeLine - serializes a point using the line protocol
+func (t *InfluxTransport) serializeLine(b *strings.Builder, item *serializer.ArrayItem) error {
+
+	numTags := len(item.Tags)
+	if numTags%2 != 0 {
+		return fmt.Errorf("the number of tags must be even")
+	}
+
+	if len(item.Metric) == 0 {
+		return fmt.Errorf("empty measurement name")
+	}
+
+	if math.IsNaN(item.Value) || math.IsInf(item.Value, 0) {
+		return fmt.Errorf("the line protocol does not support NaN or Inf values: %s", item.Metric)
+	}
+
+	tags := make([][2]string, 0, numTags/2)
+
+	for i := 0; i < numTags; i += 2 {
+
+		key, ok := item.Tags[i].(string)
+		if !ok {
+			return fmt.Errorf("error casting tag key to string")
+		}
+
+		value := fmt.Sprint(item.Tags[i+1])
+
+		// empty tag values are not accepted by the influxdb
+		if len(key) == 0 || len(value) == 0 || item.Tags[i+1] == nil {
+			continue
+		}
+
+		tags = append(tags, [2]string{key, value})
+	}
+
+	// the influxdb recommends sorting the tags by key
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i][0] < tags[j][0]
+	})
+
+	b.WriteString(influxMeasurementEscaper.Replace(item.Metric))
+
+	for _, tag := range tags {
+		b.WriteByte(',')
+		b.WriteString(influxTagEscaper.Replace(tag[0]))
+		b.WriteByte('=')
+		b.WriteString(influxTagEscaper.Replace(tag[1]))
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(t.fieldKey)
+	b.WriteByte('=')
+	b.WriteString(strconv.FormatFloat(item.Value, 'f', -1, 64))
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(item.Timestamp*t.timestampMultiplier, 10))
+	b.WriteByte('\n')
+
+	return nil
+}
+
+// SerializePayload - serializes a list of generic data
+func (t *InfluxTransport) SerializePayload(dataList []interface{}) (payload []string, err error) {
+
+	lines := make([]string, len(dataList))
+
+	for i, data := range dataList {
+
+		lines[i], err = t.Serialize(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if t.configuration.Protocol == InfluxHTTP {
+		return []string{strings.Join(lines, empty)}, nil
+	}
+
+	// one datagram per line
+	return lines, nil
+}
+
+// Serialize - renders the line protocol text
+func (t *InfluxTransport) Serialize(item interface{}) (string, error) {
+
+	casted, ok := item.(*serializer.ArrayItem)
+	if !ok {
+		return empty, fmt.Errorf("unexpected instance type: %+v", item)
+	}
+
+	var b strings.Builder
+
+	err := t.serializeLine(&b, casted)
+	if err != nil {
+		return empty, err
+	}
+
+	return b.String(), nil
+}
+
+func (t *InfluxTransport) getAddress() net.Addr {
+
+	return t.address
+}
+
+func (t *InfluxTransport) read(conn net.Conn, logConnError func(error, rwOp)) bool {
+
+	return true
+}
+
+func (t *InfluxTransport) dial() (net.Conn, error) {
+
+	return net.DialUDP("udp", nil, t.address)
+}
+
+// TransferData - transfers the data to the backend throught this transport
+func (t *InfluxTransport) TransferData(payload []string) error {
+
+	size := len(payload)
+	if size == 0 {
+		return ErrInvalidPayloadSize
+	}
+
+	if t.configuration.Protocol == InfluxHTTP {
+
+		if size > 1 {
+			return ErrInvalidPayloadSize
+		}
+
+		return doHTTPRequest(t.httpClient, http.MethodPost, t.serviceURL, payload[0], t.configuration.Headers, exactStatus(t.configuration.ExpectedResponseStatus))
+	}
+
+	for _, p := range payload {
+
+		err := t.udpNetworkConn.transferData(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DataChannel - send a new point
+func (t *InfluxTransport) DataChannel(item interface{}) {
+
+	t.core.dataChannel(item)
+}
+
+// AddPointFilter - adds a filter to be applied before buffering the points
+func (t *InfluxTransport) AddPointFilter(filter PointFilter) {
+
+	t.core.addPointFilter(filter)
+}
+
+// GetStats - returns the transport statistics
+func (t *InfluxTransport) GetStats() TransportStats {
+
+	return t.core.getStats()
+}
+
+// MatchType - checks if this transport implementation matches the given type
+func (t *InfluxTransport) MatchType(tt transportType) bool {
+
+	return tt == typeInflux
+}
+
+// Start - starts this transport
+func (t *InfluxTransport) Start(manualMode bool) error {
+
+	return t.core.Start(manualMode)
+}
+
+// Close - closes this transport
+func (t *InfluxTransport) Close() {
+
+	t.core.Close()
+
+	if t.udpNetworkConn != nil {
+		t.udpNetworkConn.closeConnection()
+	}
+}
+
+// SendData - releases the point buffer and send all data
+func (t *InfluxTransport) SendData() error {
+	return t.core.SendData()
+}
@@ -0,0 +1,37 @@
+package timeline
+
+// DataChannelItemToFlattenerPoint - converts the data channel item to the flattened point one
+func (t *InfluxTransport) DataChannelItemToFlattenerPoint(configuration *DataTransformerConfig, instance interface{}, operation FlatOperation) (Hashable, error) {
+
+	return t.itemTransport.dataChannelItemToFlattenerPoint(configuration, instance, operation)
+}
+
+// FlattenerPointToDataChannelItem - converts the flattened point to the data channel one
+func (t *InfluxTransport) FlattenerPointToDataChannelItem(point *FlattenerPoint) (interface{}, error) {
+
+	return t.itemTransport.flattenerPointToDataChannelItem(point)
+}
+
+// DataChannelItemToAccumulatedData - converts the data channel item to the accumulated data
+func (t *InfluxTransport) DataChannelItemToAccumulatedData(configuration *DataTransformerConfig, instance interface{}, calculateHash bool) (Hashable, error) {
+
+	return t.itemTransport.dataChannelItemToAccumulatedData(configuration, instance, calculateHash)
+}
+
+// AccumulatedDataToDataChannelItem - converts the accumulated data to the data channel item
+func (t *InfluxTransport) AccumulatedDataToDataChannelItem(point *accumulatedData) (interface{}, error) {
+
+	return t.itemTransport.accumulatedDataToDataChannelItem(point)
+}
+
+// DataChannelItemToSeriesPoint - converts the data channel item to the series point
+func (t *InfluxTransport) DataChannelItemToSeriesPoint(instance interface{}) (*SeriesPoint, error) {
+
+	return t.itemTransport.dataChannelItemToSeriesPoint(instance)
+}
+
+// DataChannelItemToRollup - returns the data channel item without the tags removed by the rollup (nil if the rollup does not match)
+func (t *InfluxTransport) DataChannelItemToRollup(instance interface{}, rollup *FlattenRollup) (interface{}, error) {
+
+	return t.itemTransport.dataChannelItemToRollup(instance, rollup)
+}
@@ -14,11 +14,14 @@ const logContextID string = "id"
 
 // Manager - the parent of all event managers
 type Manager struct {
-	transport   Transport
-	flattener   *Flattener
-	accumulator *Accumulator
-	name        string
-	manualMode  uint32
+	transport     Transport
+	flattener     *Flattener
+	accumulator   *Accumulator
+	validator     *PointValidator
+	sampler       *Sampler
+	name          string
+	manualMode    uint32
+	loggerContext []string
 }
 
 // NewManager - creates a timeline manager
@@ -47,9 +50,15 @@ func NewManager(transport Transport, flattener, accumulator DataProcessor, backe
 
 	var f *Flattener
 	if flattener != nil {
+		f = flattener.(*Flattener)
+
+		err = f.configuration.Validate()
+		if err != nil {
+			return nil, err
+		}
+
 		flattener.BuildContextualLogger(loggerContext...)
 		flattener.SetTransport(transport)
-		f = flattener.(*Flattener)
 	}
 
 	var a *Accumulator
@@ -60,12 +69,47 @@ func NewManager(transport Transport, flattener, accumulator DataProcessor, backe
 	}
 
 	return &Manager{
-		transport:   transport,
-		flattener:   f,
-		accumulator: a,
+		transport:     transport,
+		flattener:     f,
+		accumulator:   a,
+		loggerContext: loggerContext,
 	}, nil
 }
 
+// SetPointValidator - sets the validator used by the opentsdb functions (nil disables the validation), the influxdb, graphite, statsd and json points are not validated
+func (m *Manager) SetPointValidator(validator *PointValidator) {
+
+	if validator != nil {
+		validator.BuildContextualLogger(m.loggerContext...)
+	}
+
+	m.validator = validator
+}
+
+// SetSampler - sets the sampler applied before the flattener and the transport (nil disables the sampling)
+func (m *Manager) SetSampler(sampler *Sampler) {
+
+	if sampler != nil {
+		sampler.BuildContextualLogger(m.loggerContext...)
+	}
+
+	m.sampler = sampler
+}
+
+// AddPointFilter - adds a filter to the transport (call it before Start())
+func (m *Manager) AddPointFilter(filter PointFilter) error {
+
+	filterer, ok := m.transport.(pointFilterer)
+	if !ok {
+		return ErrUnsupportedOperation
+	}
+
+	filter.BuildContextualLogger(m.loggerContext...)
+	filterer.AddPointFilter(filter)
+
+	return nil
+}
+
 // Start - starts the manager
 func (m *Manager) Start(manualMode bool) error {
 
@@ -111,6 +155,16 @@ func (m *Manager) GetTransport() Transport {
 	return m.transport
 }
 
+// GetTransportStats - returns the transport statistics (empty if the transport does not keep them)
+func (m *Manager) GetTransportStats() TransportStats {
+
+	if provider, ok := m.transport.(statsProvider); ok {
+		return provider.GetStats()
+	}
+
+	return TransportStats{}
+}
+
 // ProcessCycle - call process cycle manually
 func (m *Manager) ProcessCycle() {
 
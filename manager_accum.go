@@ -42,23 +42,35 @@ func (m *Manager) StoreHashedDataToAccumulateJSON(hash string, ttl time.Duration
 // StoreDataToAccumulateOpenTSDB - stores a data to accumulate
 func (m *Manager) StoreDataToAccumulateOpenTSDB(ttl time.Duration, value float64, timestamp int64, metric string, tags ...interface{}) (string, error) {
 
-	return m.accumulator.Store(&openTSDBSerializer.ArrayItem{
+	item := &openTSDBSerializer.ArrayItem{
 		Metric:    metric,
 		Tags:      tags,
 		Timestamp: timestamp,
 		Value:     value,
-	}, ttl)
+	}
+
+	if err := m.validateOpenTSDB(item); err != nil {
+		return empty, err
+	}
+
+	return m.accumulator.Store(item, ttl)
 }
 
 // StoreHashedDataToAccumulateOpenTSDB - stores a data with custom hash to accumulate
 func (m *Manager) StoreHashedDataToAccumulateOpenTSDB(hash string, ttl time.Duration, value float64, timestamp int64, metric string, tags ...interface{}) error {
 
-	return m.accumulator.StoreCustomHash(&openTSDBSerializer.ArrayItem{
+	item := &openTSDBSerializer.ArrayItem{
 		Metric:    metric,
 		Tags:      tags,
 		Timestamp: timestamp,
 		Value:     value,
-	}, ttl, hash)
+	}
+
+	if err := m.validateOpenTSDB(item); err != nil {
+		return err
+	}
+
+	return m.accumulator.StoreCustomHash(item, ttl, hash)
 }
 
 // IncrementAccumulatedData - stores a data to accumulate
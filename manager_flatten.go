@@ -12,20 +12,125 @@ import (
 * @author rnojiri
 **/
 
-// Flatten - flatten a point
+// Flatten - flatten a point (and its rollups)
 func (m *Manager) Flatten(operation FlatOperation, genericItem interface{}) error {
 
-	point, err := m.transport.DataChannelItemToFlattenerPoint(
-		m.flattener.configuration,
-		genericItem,
-		operation,
-	)
+	if !m.sample(genericItem) {
+		return nil
+	}
 
-	if err != nil {
-		return err
+	return m.flattenRollups(genericItem, func(item interface{}) error {
+
+		point, err := m.transport.DataChannelItemToFlattenerPoint(
+			m.flattener.configuration,
+			item,
+			operation,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		return m.flattener.Add(point.(*FlattenerPoint))
+	})
+}
+
+// FlattenMultiple - flatten a point (and its rollups) using multiple operations sharing the same values (one point is sent per operation)
+func (m *Manager) FlattenMultiple(operations []FlatOperation, genericItem interface{}) error {
+
+	if len(operations) == 0 {
+		return fmt.Errorf("no operations were specified")
 	}
 
-	return m.flattener.Add(point.(*FlattenerPoint))
+	hashParameters := make([]interface{}, 0, len(operations)+1)
+
+	for i, operation := range operations {
+		for j := 0; j < i; j++ {
+			if operations[j] == operation {
+				return fmt.Errorf("duplicated operation: %s", operation)
+			}
+		}
+		hashParameters = append(hashParameters, operation)
+	}
+
+	if !m.sample(genericItem) {
+		return nil
+	}
+
+	return m.flattenRollups(genericItem, func(item interface{}) error {
+
+		instance, err := m.transport.DataChannelItemToFlattenerPoint(
+			m.flattener.configuration,
+			item,
+			operations[0],
+		)
+
+		if err != nil {
+			return err
+		}
+
+		point := instance.(*FlattenerPoint)
+		point.operations = operations
+
+		// the single operation hash is combined with all operations
+		point.hash, err = getHash(m.flattener.configuration, append([]interface{}{point.hash}, hashParameters...)...)
+		if err != nil {
+			return err
+		}
+
+		return m.flattener.Add(point)
+	})
+}
+
+// flattenRollups - flattens the items rolled up by the matching rollups and the original item (when no rollup matches or some of them keeps the original)
+func (m *Manager) flattenRollups(genericItem interface{}, flatten func(item interface{}) error) error {
+
+	rollups := m.flattener.configuration.Rollups
+	if len(rollups) == 0 {
+		return flatten(genericItem)
+	}
+
+	converter, ok := m.transport.(rollupConverter)
+	if !ok {
+		return ErrUnsupportedOperation
+	}
+
+	matched := false
+	keepOriginal := false
+
+	for i := range rollups {
+
+		rolled, err := converter.DataChannelItemToRollup(genericItem, &rollups[i])
+		if err != nil {
+			return err
+		}
+
+		if rolled == nil {
+			continue
+		}
+
+		matched = true
+
+		// no tag was removed, so the rolled up series is the original one
+		if rolled == genericItem {
+			keepOriginal = true
+			continue
+		}
+
+		if rollups[i].KeepOriginal {
+			keepOriginal = true
+		}
+
+		if err := flatten(rolled); err != nil {
+			return err
+		}
+	}
+
+	if !matched || keepOriginal {
+		return flatten(genericItem)
+	}
+
+	return nil
 }
 
 // FlattenJSON - flatten a point
@@ -44,20 +149,114 @@ func (m *Manager) FlattenJSON(operation FlatOperation, name string, parameters .
 	)
 }
 
+// FlattenJSONMultiple - flatten a point using multiple operations
+func (m *Manager) FlattenJSONMultiple(operations []FlatOperation, name string, parameters ...interface{}) error {
+
+	if !m.transport.MatchType(typeHTTP) && !m.transport.MatchType(typeUDP) {
+		return fmt.Errorf("this transport does not accepts json messages")
+	}
+
+	return m.FlattenMultiple(
+		operations,
+		&jsonSerializer.ArrayItem{
+			Name:       name,
+			Parameters: parameters,
+		},
+	)
+}
+
 // FlattenOpenTSDB - flatten a point
 func (m *Manager) FlattenOpenTSDB(operation FlatOperation, value float64, timestamp int64, metric string, tags ...interface{}) error {
 
+	item, err := m.buildFlattenOpenTSDBItem(value, timestamp, metric, tags)
+	if err != nil {
+		return err
+	}
+
+	return m.Flatten(operation, item)
+}
+
+// FlattenOpenTSDBMultiple - flatten a point using multiple operations
+func (m *Manager) FlattenOpenTSDBMultiple(operations []FlatOperation, value float64, timestamp int64, metric string, tags ...interface{}) error {
+
+	item, err := m.buildFlattenOpenTSDBItem(value, timestamp, metric, tags)
+	if err != nil {
+		return err
+	}
+
+	return m.FlattenMultiple(operations, item)
+}
+
+// buildFlattenOpenTSDBItem - builds and validates the opentsdb item
+func (m *Manager) buildFlattenOpenTSDBItem(value float64, timestamp int64, metric string, tags []interface{}) (*openTSDBSerializer.ArrayItem, error) {
+
 	if !m.transport.MatchType(typeOpenTSDB) {
-		return fmt.Errorf("this transport does not accepts opentsdb messages")
+		return nil, fmt.Errorf("this transport does not accepts opentsdb messages")
+	}
+
+	item := &openTSDBSerializer.ArrayItem{
+		Metric:    metric,
+		Tags:      tags,
+		Timestamp: timestamp,
+		Value:     value,
+	}
+
+	if err := m.validateOpenTSDB(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// FlattenInflux - flatten a point (not validated, the point validator covers the opentsdb points only)
+func (m *Manager) FlattenInflux(operation FlatOperation, value float64, timestamp int64, measurement string, tags ...interface{}) error {
+
+	if !m.transport.MatchType(typeInflux) {
+		return fmt.Errorf("this transport does not accepts influxdb messages")
+	}
+
+	return m.Flatten(
+		operation,
+		&openTSDBSerializer.ArrayItem{
+			Metric:    measurement,
+			Tags:      tags,
+			Timestamp: timestamp,
+			Value:     value,
+		},
+	)
+}
+
+// FlattenGraphite - flatten a point (not validated, the point validator covers the opentsdb points only)
+func (m *Manager) FlattenGraphite(operation FlatOperation, value float64, timestamp int64, path string, tags ...interface{}) error {
+
+	if !m.transport.MatchType(typeGraphite) {
+		return fmt.Errorf("this transport does not accepts graphite messages")
 	}
 
 	return m.Flatten(
 		operation,
 		&openTSDBSerializer.ArrayItem{
-			Metric:    metric,
+			Metric:    path,
 			Tags:      tags,
 			Timestamp: timestamp,
 			Value:     value,
 		},
 	)
 }
+
+// FlattenStatsD - flatten a point (sums and counts are sent as counters, the other operations as gauges, not validated by the point validator)
+func (m *Manager) FlattenStatsD(operation FlatOperation, value float64, metric string, tags ...interface{}) error {
+
+	if !m.transport.MatchType(typeStatsD) {
+		return fmt.Errorf("this transport does not accepts statsd messages")
+	}
+
+	return m.Flatten(
+		operation,
+		&openTSDBSerializer.ArrayItem{
+			Metric: metric,
+			Tags:   tags,
+			Value:  value,
+		},
+	)
+}
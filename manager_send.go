@@ -2,6 +2,7 @@ package timeline
 
 import (
 	"fmt"
+	"reflect"
 	"time"
 
 	jsonSerializer "github.com/uol/serializer/json"
@@ -16,7 +17,30 @@ import (
 // Send - sends a new data using the current transport
 func (m *Manager) Send(genericItem interface{}) {
 
-	m.transport.DataChannel(genericItem)
+	if m.sampler == nil || genericItem == nil {
+		m.transport.DataChannel(genericItem)
+		return
+	}
+
+	k := reflect.TypeOf(genericItem).Kind()
+	if k != reflect.Array && k != reflect.Slice {
+		if m.sample(genericItem) {
+			m.transport.DataChannel(genericItem)
+		}
+		return
+	}
+
+	v := reflect.ValueOf(genericItem)
+	sampled := make([]interface{}, 0, v.Len())
+
+	for i := 0; i < v.Len(); i++ {
+		item := v.Index(i).Interface()
+		if m.sample(item) {
+			sampled = append(sampled, item)
+		}
+	}
+
+	m.transport.DataChannel(sampled)
 }
 
 // SendJSON - sends a new data using the json transport
@@ -26,12 +50,14 @@ func (m *Manager) SendJSON(schemaName string, parameters ...interface{}) error {
 		return fmt.Errorf("this transport does not accepts json messages")
 	}
 
-	m.transport.DataChannel(
-		&jsonSerializer.ArrayItem{
-			Name:       schemaName,
-			Parameters: parameters,
-		},
-	)
+	item := &jsonSerializer.ArrayItem{
+		Name:       schemaName,
+		Parameters: parameters,
+	}
+
+	if m.sample(item) {
+		m.transport.DataChannel(item)
+	}
 
 	return nil
 }
@@ -47,12 +73,124 @@ func (m *Manager) SendOpenTSDB(value float64, timestamp int64, metric string, ta
 		timestamp = time.Now().Unix()
 	}
 
-	m.transport.DataChannel(&openTSDBSerializer.ArrayItem{
+	item := &openTSDBSerializer.ArrayItem{
 		Metric:    metric,
 		Tags:      tags,
 		Timestamp: timestamp,
 		Value:     value,
-	})
+	}
+
+	if err := m.validateOpenTSDB(item); err != nil {
+		return err
+	}
+
+	if m.sample(item) {
+		m.transport.DataChannel(item)
+	}
+
+	return nil
+}
+
+// SendInflux - sends a new data using the influxdb transport (not validated, the point validator covers the opentsdb points only)
+func (m *Manager) SendInflux(value float64, timestamp int64, measurement string, tags ...interface{}) error {
+
+	if !m.transport.MatchType(typeInflux) {
+		return fmt.Errorf("this transport does not accepts influxdb messages")
+	}
+
+	if timestamp == 0 {
+		timestamp = time.Now().Unix()
+	}
+
+	item := &openTSDBSerializer.ArrayItem{
+		Metric:    measurement,
+		Tags:      tags,
+		Timestamp: timestamp,
+		Value:     value,
+	}
+
+	if m.sample(item) {
+		m.transport.DataChannel(item)
+	}
 
 	return nil
 }
+
+// SendGraphite - sends a new data using the graphite transport (not validated, the point validator covers the opentsdb points only)
+func (m *Manager) SendGraphite(value float64, timestamp int64, path string, tags ...interface{}) error {
+
+	if !m.transport.MatchType(typeGraphite) {
+		return fmt.Errorf("this transport does not accepts graphite messages")
+	}
+
+	if timestamp == 0 {
+		timestamp = time.Now().Unix()
+	}
+
+	item := &openTSDBSerializer.ArrayItem{
+		Metric:    path,
+		Tags:      tags,
+		Timestamp: timestamp,
+		Value:     value,
+	}
+
+	if m.sample(item) {
+		m.transport.DataChannel(item)
+	}
+
+	return nil
+}
+
+// SendStatsD - sends a new data using the statsd transport (not validated, the point validator covers the opentsdb points only)
+func (m *Manager) SendStatsD(statsDType StatsDType, value float64, metric string, tags ...interface{}) error {
+
+	if !m.transport.MatchType(typeStatsD) {
+		return fmt.Errorf("this transport does not accepts statsd messages")
+	}
+
+	item := &StatsDItem{
+		ArrayItem: openTSDBSerializer.ArrayItem{
+			Metric:    metric,
+			Tags:      tags,
+			Timestamp: time.Now().Unix(),
+			Value:     value,
+		},
+		Type: statsDType,
+	}
+
+	if m.sample(item) {
+		m.transport.DataChannel(item)
+	}
+
+	return nil
+}
+
+// validateOpenTSDB - validates the point if a validator was configured
+func (m *Manager) validateOpenTSDB(item *openTSDBSerializer.ArrayItem) error {
+
+	if m.validator == nil {
+		return nil
+	}
+
+	return m.validator.ValidateOpenTSDB(item)
+}
+
+// sample - returns true if the item must be kept or if no sampler was configured
+func (m *Manager) sample(item interface{}) bool {
+
+	if m.sampler == nil {
+		return true
+	}
+
+	converter, ok := m.transport.(seriesPointConverter)
+	if !ok {
+		return true
+	}
+
+	point, err := converter.DataChannelItemToSeriesPoint(item)
+	if err != nil {
+		return true
+	}
+
+	return m.sampler.Filter(point)
+}
@@ -22,6 +22,7 @@ type OpenTSDBTransport struct {
 	serializer     *serializer.Serializer
 	address        *net.TCPAddr
 	tcpNetworkConn *rawNetworkConnection
+	itemTransport  *openTSDBItemTransport
 }
 
 // NewOpenTSDBTransport - creates a new openTSDB event manager
@@ -62,6 +63,7 @@ func NewOpenTSDBTransport(configuration *OpenTSDBTransportConfig) (*OpenTSDBTran
 			transportConfiguration: &configuration.DefaultTransportConfig,
 			configuration:          &configuration.TCPUDPTransportConfig,
 		},
+		itemTransport: &openTSDBItemTransport{},
 		configuration: configuration,
 		serializer:    s,
 	}
@@ -176,6 +178,18 @@ func (t *OpenTSDBTransport) DataChannel(item interface{}) {
 	t.core.dataChannel(item)
 }
 
+// AddPointFilter - adds a filter to be applied before buffering the points
+func (t *OpenTSDBTransport) AddPointFilter(filter PointFilter) {
+
+	t.core.addPointFilter(filter)
+}
+
+// GetStats - returns the transport statistics
+func (t *OpenTSDBTransport) GetStats() TransportStats {
+
+	return t.core.getStats()
+}
+
 // MatchType - checks if this transport implementation matches the given type
 func (t *OpenTSDBTransport) MatchType(tt transportType) bool {
 
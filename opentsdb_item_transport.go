@@ -0,0 +1,179 @@
+package timeline
+
+import (
+	"fmt"
+	"time"
+
+	serializer "github.com/uol/serializer/opentsdb"
+)
+
+/**
+* Has common translation functions for transports using the opentsdb's data channel item (metric, tags, timestamp and value).
+* @author rnojiri
+**/
+
+type openTSDBItemTransport struct{}
+
+// extractData - extracts the hash from the instance
+func (t *openTSDBItemTransport) extractData(instance interface{}, operation *FlatOperation) (*serializer.ArrayItem, []interface{}, error) {
+
+	item, ok := instance.(*serializer.ArrayItem)
+	if !ok {
+		return nil, nil, fmt.Errorf("error casting instance to data channel item: %+v", instance)
+	}
+
+	hashParameters := []interface{}{}
+	hashParameters = append(hashParameters, item.Metric)
+	hashParameters = append(hashParameters, item.Tags...)
+
+	if operation != nil {
+		hashParameters = append(hashParameters, *operation)
+	}
+
+	return item, hashParameters, nil
+}
+
+// dataChannelItemToFlattenerPoint - converts the data channel item to the flattened point one
+func (t *openTSDBItemTransport) dataChannelItemToFlattenerPoint(configuration *DataTransformerConfig, instance interface{}, operation FlatOperation) (Hashable, error) {
+
+	item, hashParameters, err := t.extractData(instance, &operation)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.Timestamp <= 0 {
+		item.Timestamp = time.Now().Unix()
+	}
+
+	hash, err := getHash(configuration, hashParameters...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FlattenerPoint{
+		value: item.Value,
+		hash:  hash,
+		flattenerPointData: flattenerPointData{
+			operation:       operation,
+			timestamp:       item.Timestamp,
+			dataChannelItem: item,
+		},
+	}, nil
+}
+
+// flattenerPointToDataChannelItem - converts the flattened point to the data channel one
+func (t *openTSDBItemTransport) flattenerPointToDataChannelItem(point *FlattenerPoint) (interface{}, error) {
+
+	item, ok := point.dataChannelItem.(*serializer.ArrayItem)
+	if !ok {
+		return nil, fmt.Errorf("error casting point's data channel item: %+v", point)
+	}
+
+	// a new item for each emission (the buckets can be emitted again by the late corrections)
+	flattened := *item
+
+	if point.label != nil {
+		flattened.Metric += point.label.suffix
+		if len(point.label.tagKey) > 0 {
+			flattened.Tags = append(append([]interface{}{}, item.Tags...), point.label.tagKey, point.label.tagValue)
+		}
+	}
+
+	flattened.Value = point.value
+	flattened.Timestamp = point.timestamp
+
+	return &flattened, nil
+}
+
+// dataChannelItemToRollup - returns a copy of the item without the tags removed by the rollup (the same item if no tag was removed)
+func (t *openTSDBItemTransport) dataChannelItemToRollup(instance interface{}, rollup *FlattenRollup) (interface{}, error) {
+
+	item, ok := instance.(*serializer.ArrayItem)
+	if !ok {
+		return nil, fmt.Errorf("error casting instance to data channel item: %+v", instance)
+	}
+
+	if !rollup.matches(item.Metric) {
+		return nil, nil
+	}
+
+	tags := make([]interface{}, 0, len(item.Tags))
+
+	for i := 0; i+1 < len(item.Tags); i += 2 {
+
+		key, ok := item.Tags[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("expecting a string as tag key: %+v", item.Tags[i])
+		}
+
+		if rollup.keepsTag(key) {
+			tags = append(tags, item.Tags[i], item.Tags[i+1])
+		}
+	}
+
+	if len(tags) == len(item.Tags) {
+		return item, nil
+	}
+
+	rolled := *item
+	rolled.Tags = tags
+
+	return &rolled, nil
+}
+
+// dataChannelItemToAccumulatedData - converts the data channel item to the accumulated data
+func (t *openTSDBItemTransport) dataChannelItemToAccumulatedData(configuration *DataTransformerConfig, instance interface{}, calculateHash bool) (Hashable, error) {
+
+	item, hashParameters, err := t.extractData(instance, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var hash string
+
+	if calculateHash {
+		hash, err = getHash(configuration, hashParameters...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &accumulatedData{
+		count: 0,
+		hash:  hash,
+		data:  item,
+	}, nil
+}
+
+// accumulatedDataToDataChannelItem - converts the accumulated data to the data channel item
+func (t *openTSDBItemTransport) accumulatedDataToDataChannelItem(point *accumulatedData) (interface{}, error) {
+
+	item, ok := point.data.(*serializer.ArrayItem)
+	if !ok {
+		return nil, fmt.Errorf("error casting accumulated data to data channel item: %+v", point)
+	}
+
+	return &serializer.ArrayItem{
+		Metric:    item.Metric,
+		Tags:      item.Tags,
+		Timestamp: time.Now().Unix(),
+		Value:     float64(point.count),
+	}, nil
+}
+
+// dataChannelItemToSeriesPoint - converts the data channel item to the series point
+func (t *openTSDBItemTransport) dataChannelItemToSeriesPoint(instance interface{}) (*SeriesPoint, error) {
+
+	item, ok := instance.(*serializer.ArrayItem)
+	if !ok {
+		return nil, fmt.Errorf("error casting instance to data channel item: %+v", instance)
+	}
+
+	return &SeriesPoint{
+		Metric:    item.Metric,
+		Tags:      item.Tags,
+		Value:     item.Value,
+		Timestamp: item.Timestamp,
+		source:    &item.Tags,
+	}, nil
+}
@@ -1,104 +1,39 @@
 package timeline
 
-import (
-	"fmt"
-	"time"
-
-	serializer "github.com/uol/serializer/opentsdb"
-)
-
-// extractData - extracts the hash from the instance
-func (t *OpenTSDBTransport) extractData(instance interface{}, operation *FlatOperation) (*serializer.ArrayItem, []interface{}, error) {
-
-	item, ok := instance.(*serializer.ArrayItem)
-	if !ok {
-		return nil, nil, fmt.Errorf("error casting instance to data channel item: %+v", instance)
-	}
-
-	hashParameters := []interface{}{}
-	hashParameters = append(hashParameters, item.Metric)
-	hashParameters = append(hashParameters, item.Tags...)
-
-	if operation != nil {
-		hashParameters = append(hashParameters, *operation)
-	}
-
-	return item, hashParameters, nil
-}
-
 // DataChannelItemToFlattenerPoint - converts the data channel item to the flattened point one
 func (t *OpenTSDBTransport) DataChannelItemToFlattenerPoint(configuration *DataTransformerConfig, instance interface{}, operation FlatOperation) (Hashable, error) {
 
-	item, hashParameters, err := t.extractData(instance, &operation)
-
-	if item.Timestamp <= 0 {
-		item.Timestamp = time.Now().Unix()
-	}
-
-	hash, err := getHash(configuration, hashParameters...)
-	if err != nil {
-		return nil, err
-	}
-
-	return &FlattenerPoint{
-		value: item.Value,
-		hash:  hash,
-		flattenerPointData: flattenerPointData{
-			operation:       operation,
-			timestamp:       item.Timestamp,
-			dataChannelItem: item,
-		},
-	}, nil
+	return t.itemTransport.dataChannelItemToFlattenerPoint(configuration, instance, operation)
 }
 
 // FlattenerPointToDataChannelItem - converts the flattened point to the data channel one
 func (t *OpenTSDBTransport) FlattenerPointToDataChannelItem(point *FlattenerPoint) (interface{}, error) {
 
-	item, ok := point.dataChannelItem.(*serializer.ArrayItem)
-	if !ok {
-		return nil, fmt.Errorf("error casting point's data channel item: %+v", point)
-	}
-
-	item.Value = point.value
-
-	return item, nil
+	return t.itemTransport.flattenerPointToDataChannelItem(point)
 }
 
 // DataChannelItemToAccumulatedData - converts the data channel item to the accumulated data
 func (t *OpenTSDBTransport) DataChannelItemToAccumulatedData(configuration *DataTransformerConfig, instance interface{}, calculateHash bool) (Hashable, error) {
 
-	item, hashParameters, err := t.extractData(instance, nil)
-
-	var hash string
-
-	if calculateHash {
-		hash, err = getHash(configuration, hashParameters...)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return &accumulatedData{
-		count: 0,
-		hash:  hash,
-		data:  item,
-	}, nil
+	return t.itemTransport.dataChannelItemToAccumulatedData(configuration, instance, calculateHash)
 }
 
 // AccumulatedDataToDataChannelItem - converts the accumulated data to the data channel item
 func (t *OpenTSDBTransport) AccumulatedDataToDataChannelItem(point *accumulatedData) (interface{}, error) {
 
-	item, ok := point.data.(*serializer.ArrayItem)
-	if !ok {
-		return nil, fmt.Errorf("error casting accumulated data to data channel item: %+v", point)
-	}
+	return t.itemTransport.accumulatedDataToDataChannelItem(point)
+}
+
+// DataChannelItemToSeriesPoint - converts the data channel item to the series point
+func (t *OpenTSDBTransport) DataChannelItemToSeriesPoint(instance interface{}) (*SeriesPoint, error) {
+
+	return t.itemTransport.dataChannelItemToSeriesPoint(instance)
+}
+
+// DataChannelItemToRollup - returns the data channel item without the tags removed by the rollup (nil if the rollup does not match)
+func (t *OpenTSDBTransport) DataChannelItemToRollup(instance interface{}, rollup *FlattenRollup) (interface{}, error) {
 
-	return &serializer.ArrayItem{
-		Metric:    item.Metric,
-		Tags:      item.Tags,
-		Timestamp: time.Now().Unix(),
-		Value:     float64(point.count),
-	}, nil
+	return t.itemTransport.dataChannelItemToRollup(instance, rollup)
 }
 
 // Serialize - renders the text using the configured serializer
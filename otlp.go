@@ -0,0 +1,518 @@
+package timeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/logh"
+	serializer "github.com/uol/serializer/opentsdb"
+)
+
+/**
+* The OpenTelemetry OTLP/HTTP metrics transport implementation (accepts the opentsdb items).
+* Accumulated data is sent as delta sums and the other points as gauges.
+* @author rnojiri
+**/
+
+const (
+	// OTLPProtobuf - encodes the request using protocol buffers
+	OTLPProtobuf string = "protobuf"
+
+	// OTLPJSON - encodes the request using the otlp json mapping
+	OTLPJSON string = "json"
+
+	defaultOTLPEndpoint  string = "/v1/metrics"
+	defaultOTLPScopeName string = "github.com/uol/timeline"
+	otlpDeltaTemporality int    = 1
+)
+
+// otlpSumItem - an accumulated item sent as a delta sum (the window times in nanoseconds)
+type otlpSumItem struct {
+	*serializer.ArrayItem
+	startTime int64
+	time      int64
+}
+
+// otlpDataPoint - a number data point
+type otlpDataPoint struct {
+	attributes [][2]string
+	startTime  int64
+	time       int64
+	value      float64
+}
+
+// otlpMetric - a metric and its data points
+type otlpMetric struct {
+	name       string
+	sum        bool
+	dataPoints []otlpDataPoint
+}
+
+// OTLPTransport - implements the otlp/http metrics transport
+type OTLPTransport struct {
+	core          transportCore
+	configuration *OTLPTransportConfig
+	httpClient    *http.Client
+	serviceURL    string
+	headers       map[string]string
+	itemTransport *openTSDBItemTransport
+	resource      [][2]string
+	startTime     int64
+	lastEmissions sync.Map
+}
+
+// NewOTLPTransport - creates a new otlp event manager
+func NewOTLPTransport(configuration *OTLPTransportConfig) (*OTLPTransport, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{}
+	for k, v := range configuration.Headers {
+		headers[k] = v
+	}
+
+	switch configuration.Encoding {
+	case OTLPProtobuf, empty:
+		configuration.Encoding = OTLPProtobuf
+		headers["Content-Type"] = "application/x-protobuf"
+	case OTLPJSON:
+		headers["Content-Type"] = "application/json"
+	default:
+		return nil, fmt.Errorf("invalid encoding: %s", configuration.Encoding)
+	}
+
+	if len(configuration.ServiceEndpoint) == 0 {
+		configuration.ServiceEndpoint = defaultOTLPEndpoint
+	}
+
+	if len(configuration.ScopeName) == 0 {
+		configuration.ScopeName = defaultOTLPScopeName
+	}
+
+	t := &OTLPTransport{
+		core: transportCore{
+			batchSendInterval:    configuration.BatchSendInterval.Duration,
+			defaultConfiguration: &configuration.DefaultTransportConfig,
+		},
+		configuration: configuration,
+		httpClient:    funks.CreateHTTPClient(configuration.RequestTimeout.Duration, true),
+		headers:       headers,
+		itemTransport: &openTSDBItemTransport{},
+		resource:      sortedAttributes(configuration.GlobalTags),
+		startTime:     time.Now().UnixNano(),
+	}
+
+	t.core.transport = t
+
+	return t, nil
+}
+
+// sortedAttributes - converts the map to a list of attributes sorted by key
+func sortedAttributes(tags map[string]string) [][2]string {
+
+	attributes := make([][2]string, 0, len(tags))
+	for k, v := range tags {
+		attributes = append(attributes, [2]string{k, v})
+	}
+
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i][0] < attributes[j][0]
+	})
+
+	return attributes
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (t *OTLPTransport) BuildContextualLogger(path ...string) {
+
+	if t.core.loggers != nil {
+		return
+	}
+
+	logContext := []string{"pkg", "timeline/otlp"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	t.core.loggers = logh.CreateContextualLogger(logContext...)
+}
+
+// ConfigureBackend - configures the backend
+func (t *OTLPTransport) ConfigureBackend(backend *Backend) error {
+
+	if backend == nil {
+		return fmt.Errorf("no backend was configured")
+	}
+
+	t.serviceURL = fmt.Sprintf("http://%s:%d/%s", backend.Host, backend.Port, strings.TrimPrefix(t.configuration.ServiceEndpoint, "/"))
+
+	if logh.InfoEnabled {
+		t.core.loggers.Info().Msg(fmt.Sprintf("backend was configured to use service: %s", t.serviceURL))
+	}
+
+	return nil
+}
+
+// toDataPoint - converts the item to a data point
+func (t *OTLPTransport) toDataPoint(item *serializer.ArrayItem, startTime, pointTime int64) (*otlpDataPoint, error) {
+
+	numTags := len(item.Tags)
+	if numTags%2 != 0 {
+		return nil, fmt.Errorf("the number of tags must be even")
+	}
+
+	if len(item.Metric) == 0 {
+		return nil, fmt.Errorf("empty metric name")
+	}
+
+	attributes := make([][2]string, 0, numTags/2)
+
+	for i := 0; i < numTags; i += 2 {
+
+		key, ok := item.Tags[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("error casting tag key to string")
+		}
+
+		if len(key) == 0 || item.Tags[i+1] == nil {
+			continue
+		}
+
+		attributes = append(attributes, [2]string{key, fmt.Sprint(item.Tags[i+1])})
+	}
+
+	return &otlpDataPoint{
+		attributes: attributes,
+		startTime:  startTime,
+		time:       pointTime,
+		value:      item.Value,
+	}, nil
+}
+
+// toMetrics - groups the data points by metric name and type
+func (t *OTLPTransport) toMetrics(dataList []interface{}) ([]*otlpMetric, error) {
+
+	metricMap := map[string]*otlpMetric{}
+	metrics := []*otlpMetric{}
+
+	for _, data := range dataList {
+
+		var item *serializer.ArrayItem
+		var startTime, pointTime int64
+		sum := false
+
+		switch casted := data.(type) {
+		case *serializer.ArrayItem:
+			item = casted
+			pointTime = casted.Timestamp * int64(time.Second)
+		case *otlpSumItem:
+			item = casted.ArrayItem
+			startTime = casted.startTime
+			pointTime = casted.time
+			sum = true
+		default:
+			return nil, fmt.Errorf("unexpected instance type: %+v", data)
+		}
+
+		dataPoint, err := t.toDataPoint(item, startTime, pointTime)
+		if err != nil {
+			return nil, err
+		}
+
+		key := item.Metric
+		if sum {
+			key += "\x00sum"
+		}
+
+		metric, ok := metricMap[key]
+		if !ok {
+			metric = &otlpMetric{
+				name: item.Metric,
+				sum:  sum,
+			}
+			metricMap[key] = metric
+			metrics = append(metrics, metric)
+		}
+
+		metric.dataPoints = append(metric.dataPoints, *dataPoint)
+	}
+
+	return metrics, nil
+}
+
+// encodeKeyValues - encodes the attributes as protobuf key values
+func encodeKeyValues(e *protoEncoder, field int, attributes [][2]string) {
+
+	for _, attribute := range attributes {
+		e.messageField(field, func(kv *protoEncoder) {
+			kv.stringField(1, attribute[0])
+			kv.messageField(2, func(av *protoEncoder) {
+				av.stringField(1, attribute[1])
+			})
+		})
+	}
+}
+
+// encodeProtobuf - encodes the export metrics service request using protocol buffers
+func (t *OTLPTransport) encodeProtobuf(metrics []*otlpMetric) []byte {
+
+	e := protoEncoder{}
+
+	e.messageField(1, func(rm *protoEncoder) {
+
+		rm.messageField(1, func(r *protoEncoder) {
+			encodeKeyValues(r, 1, t.resource)
+		})
+
+		rm.messageField(2, func(sm *protoEncoder) {
+
+			sm.messageField(1, func(s *protoEncoder) {
+				s.stringField(1, t.configuration.ScopeName)
+			})
+
+			for _, metric := range metrics {
+
+				sm.messageField(2, func(m *protoEncoder) {
+
+					m.stringField(1, metric.name)
+
+					encodeDataPoints := func(d *protoEncoder) {
+						for _, dp := range metric.dataPoints {
+							d.messageField(1, func(p *protoEncoder) {
+								p.fixed64Field(2, uint64(dp.startTime))
+								p.fixed64Field(3, uint64(dp.time))
+								p.fixed64Value(4, math.Float64bits(dp.value))
+								encodeKeyValues(p, 7, dp.attributes)
+							})
+						}
+					}
+
+					if metric.sum {
+						m.messageField(7, func(s *protoEncoder) {
+							encodeDataPoints(s)
+							s.uint64Field(2, uint64(otlpDeltaTemporality))
+							s.uint64Field(3, 1)
+						})
+					} else {
+						m.messageField(5, encodeDataPoints)
+					}
+				})
+			}
+		})
+	})
+
+	return e.buf
+}
+
+// otlpJSONKeyValue - the json key value
+type otlpJSONKeyValue struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+// otlpJSONDataPoint - the json number data point
+type otlpJSONDataPoint struct {
+	Attributes        []otlpJSONKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string             `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string             `json:"timeUnixNano"`
+	AsDouble          interface{}        `json:"asDouble"`
+}
+
+// otlpJSONData - the json gauge or sum
+type otlpJSONData struct {
+	DataPoints             []otlpJSONDataPoint `json:"dataPoints"`
+	AggregationTemporality int                 `json:"aggregationTemporality,omitempty"`
+	IsMonotonic            bool                `json:"isMonotonic,omitempty"`
+}
+
+// otlpJSONMetric - the json metric
+type otlpJSONMetric struct {
+	Name  string        `json:"name"`
+	Gauge *otlpJSONData `json:"gauge,omitempty"`
+	Sum   *otlpJSONData `json:"sum,omitempty"`
+}
+
+// toJSONKeyValues - converts the attributes to the json format
+func toJSONKeyValues(attributes [][2]string) []otlpJSONKeyValue {
+
+	kvs := make([]otlpJSONKeyValue, len(attributes))
+	for i, attribute := range attributes {
+		kvs[i] = otlpJSONKeyValue{
+			Key:   attribute[0],
+			Value: map[string]string{"stringValue": attribute[1]},
+		}
+	}
+
+	return kvs
+}
+
+// toJSONNumber - NaN and Inf are encoded as strings by the protobuf json mapping
+func toJSONNumber(v float64) interface{} {
+
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	default:
+		return v
+	}
+}
+
+// encodeJSON - encodes the export metrics service request using the json mapping
+func (t *OTLPTransport) encodeJSON(metrics []*otlpMetric) ([]byte, error) {
+
+	jsonMetrics := make([]otlpJSONMetric, len(metrics))
+
+	for i, metric := range metrics {
+
+		data := &otlpJSONData{
+			DataPoints: make([]otlpJSONDataPoint, len(metric.dataPoints)),
+		}
+
+		for j, dp := range metric.dataPoints {
+
+			data.DataPoints[j] = otlpJSONDataPoint{
+				Attributes:   toJSONKeyValues(dp.attributes),
+				TimeUnixNano: strconv.FormatInt(dp.time, 10),
+				AsDouble:     toJSONNumber(dp.value),
+			}
+
+			if dp.startTime > 0 {
+				data.DataPoints[j].StartTimeUnixNano = strconv.FormatInt(dp.startTime, 10)
+			}
+		}
+
+		jsonMetrics[i].Name = metric.name
+
+		if metric.sum {
+			data.AggregationTemporality = otlpDeltaTemporality
+			data.IsMonotonic = true
+			jsonMetrics[i].Sum = data
+		} else {
+			jsonMetrics[i].Gauge = data
+		}
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"resourceMetrics": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": toJSONKeyValues(t.resource),
+				},
+				"scopeMetrics": []interface{}{
+					map[string]interface{}{
+						"scope":   map[string]string{"name": t.configuration.ScopeName},
+						"metrics": jsonMetrics,
+					},
+				},
+			},
+		},
+	})
+}
+
+// SerializePayload - serializes a list of generic data
+func (t *OTLPTransport) SerializePayload(dataList []interface{}) (payload []string, err error) {
+
+	metrics, err := t.toMetrics(dataList)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.configuration.Encoding == OTLPJSON {
+
+		encoded, err := t.encodeJSON(metrics)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{string(encoded)}, nil
+	}
+
+	return []string{string(t.encodeProtobuf(metrics))}, nil
+}
+
+// Serialize - renders the item using the json mapping (used only for debugging)
+func (t *OTLPTransport) Serialize(item interface{}) (string, error) {
+
+	metrics, err := t.toMetrics([]interface{}{item})
+	if err != nil {
+		return empty, err
+	}
+
+	encoded, err := t.encodeJSON(metrics)
+	if err != nil {
+		return empty, err
+	}
+
+	return string(encoded), nil
+}
+
+// TransferData - transfers the data to the backend throught this transport
+func (t *OTLPTransport) TransferData(payload []string) error {
+
+	size := len(payload)
+	if size == 0 || size > 1 {
+		return ErrInvalidPayloadSize
+	}
+
+	return doHTTPRequest(t.httpClient, http.MethodPost, t.serviceURL, payload[0], t.headers, successStatus)
+}
+
+// DataChannel - send a new point
+func (t *OTLPTransport) DataChannel(item interface{}) {
+
+	t.core.dataChannel(item)
+}
+
+// AddPointFilter - adds a filter to be applied before buffering the points
+func (t *OTLPTransport) AddPointFilter(filter PointFilter) {
+
+	t.core.addPointFilter(filter)
+}
+
+// GetStats - returns the transport statistics
+func (t *OTLPTransport) GetStats() TransportStats {
+
+	return t.core.getStats()
+}
+
+// MatchType - checks if this transport implementation matches the given type (accepts the opentsdb items)
+func (t *OTLPTransport) MatchType(tt transportType) bool {
+
+	return tt == typeOpenTSDB
+}
+
+// Start - starts this transport
+func (t *OTLPTransport) Start(manualMode bool) error {
+
+	return t.core.Start(manualMode)
+}
+
+// Close - closes this transport
+func (t *OTLPTransport) Close() {
+
+	t.core.Close()
+}
+
+// SendData - releases the point buffer and send all data
+func (t *OTLPTransport) SendData() error {
+	return t.core.SendData()
+}
@@ -0,0 +1,93 @@
+package timeline
+
+import (
+	"fmt"
+	"time"
+
+	serializer "github.com/uol/serializer/opentsdb"
+)
+
+// unwrapOTLPItem - returns the opentsdb item inside the sum item
+func unwrapOTLPItem(instance interface{}) interface{} {
+
+	if item, ok := instance.(*otlpSumItem); ok {
+		return item.ArrayItem
+	}
+
+	return instance
+}
+
+// DataChannelItemToFlattenerPoint - converts the data channel item to the flattened point one
+func (t *OTLPTransport) DataChannelItemToFlattenerPoint(configuration *DataTransformerConfig, instance interface{}, operation FlatOperation) (Hashable, error) {
+
+	return t.itemTransport.dataChannelItemToFlattenerPoint(configuration, unwrapOTLPItem(instance), operation)
+}
+
+// FlattenerPointToDataChannelItem - converts the flattened point to the data channel one (sent as gauge)
+func (t *OTLPTransport) FlattenerPointToDataChannelItem(point *FlattenerPoint) (interface{}, error) {
+
+	return t.itemTransport.flattenerPointToDataChannelItem(point)
+}
+
+// DataChannelItemToAccumulatedData - converts the data channel item to the accumulated data
+func (t *OTLPTransport) DataChannelItemToAccumulatedData(configuration *DataTransformerConfig, instance interface{}, calculateHash bool) (Hashable, error) {
+
+	return t.itemTransport.dataChannelItemToAccumulatedData(configuration, unwrapOTLPItem(instance), calculateHash)
+}
+
+// AccumulatedDataToDataChannelItem - converts the accumulated data to the data channel item (sent as delta sum)
+func (t *OTLPTransport) AccumulatedDataToDataChannelItem(point *accumulatedData) (interface{}, error) {
+
+	item, err := t.itemTransport.accumulatedDataToDataChannelItem(point)
+	if err != nil {
+		return nil, err
+	}
+
+	casted, ok := item.(*serializer.ArrayItem)
+	if !ok {
+		return nil, fmt.Errorf("error casting data channel item: %+v", item)
+	}
+
+	// the delta starts at the previous emission from the same hash (the same instant ends this window)
+	now := time.Now().UnixNano()
+	startTime := t.startTime
+
+	if previous, loaded := t.lastEmissions.Load(point.hash); loaded {
+		startTime = previous.(int64)
+	}
+
+	t.lastEmissions.Store(point.hash, now)
+
+	casted.Timestamp = now / int64(time.Second)
+
+	return &otlpSumItem{
+		ArrayItem: casted,
+		startTime: startTime,
+		time:      now,
+	}, nil
+}
+
+// accumulatedDataRemoved - removes the last emission of the expired accumulated data
+func (t *OTLPTransport) accumulatedDataRemoved(hash string) {
+
+	t.lastEmissions.Delete(hash)
+}
+
+// DataChannelItemToSeriesPoint - converts the data channel item to the series point
+func (t *OTLPTransport) DataChannelItemToSeriesPoint(instance interface{}) (*SeriesPoint, error) {
+
+	return t.itemTransport.dataChannelItemToSeriesPoint(unwrapOTLPItem(instance))
+}
+
+// DataChannelItemToRollup - returns the data channel item without the tags removed by the rollup (nil if the rollup does not match)
+func (t *OTLPTransport) DataChannelItemToRollup(instance interface{}, rollup *FlattenRollup) (interface{}, error) {
+
+	unwrapped := unwrapOTLPItem(instance)
+
+	rolled, err := t.itemTransport.dataChannelItemToRollup(unwrapped, rollup)
+	if err != nil || rolled != unwrapped {
+		return rolled, err
+	}
+
+	return instance, nil
+}
@@ -0,0 +1,135 @@
+package timeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	openTSDBSerializer "github.com/uol/serializer/opentsdb"
+)
+
+/**
+* Parses the opentsdb telnet lines and the /api/put json points to opentsdb items.
+* @author rnojiri
+**/
+
+const (
+	openTSDBPutCommand string = "put"
+)
+
+// openTSDBJSONPoint - the /api/put json point
+type openTSDBJSONPoint struct {
+	Metric    string            `json:"metric"`
+	Timestamp json.Number       `json:"timestamp"`
+	Value     json.Number       `json:"value"`
+	Tags      map[string]string `json:"tags"`
+}
+
+// ParseOpenTSDBLine - parses a telnet line like "put <metric> <timestamp> <value> <tagk=tagv ...>" (the "put" is optional)
+func ParseOpenTSDBLine(line string) (*openTSDBSerializer.ArrayItem, error) {
+
+	fields := strings.Fields(line)
+
+	if len(fields) > 0 && fields[0] == openTSDBPutCommand {
+		fields = fields[1:]
+	}
+
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("invalid opentsdb line: %s", line)
+	}
+
+	timestamp, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp \"%s\": %s", fields[1], err.Error())
+	}
+
+	value, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value \"%s\": %s", fields[2], err.Error())
+	}
+
+	tags := make([]interface{}, 0, (len(fields)-3)*2)
+
+	for _, tag := range fields[3:] {
+
+		equal := strings.IndexByte(tag, '=')
+		if equal <= 0 || equal == len(tag)-1 {
+			return nil, fmt.Errorf("invalid tag: %s", tag)
+		}
+
+		tags = append(tags, tag[:equal], tag[equal+1:])
+	}
+
+	return &openTSDBSerializer.ArrayItem{
+		Metric:    fields[0],
+		Timestamp: timestamp,
+		Value:     value,
+		Tags:      tags,
+	}, nil
+}
+
+// ParseOpenTSDBJSON - parses a /api/put json body, a single point or an array of points (the tags are sorted by key)
+func ParseOpenTSDBJSON(data []byte) ([]*openTSDBSerializer.ArrayItem, error) {
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty json")
+	}
+
+	var points []openTSDBJSONPoint
+
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &points); err != nil {
+			return nil, err
+		}
+	} else {
+		point := openTSDBJSONPoint{}
+		if err := json.Unmarshal(data, &point); err != nil {
+			return nil, err
+		}
+		points = []openTSDBJSONPoint{point}
+	}
+
+	items := make([]*openTSDBSerializer.ArrayItem, len(points))
+
+	for i, point := range points {
+
+		if len(point.Metric) == 0 {
+			return nil, fmt.Errorf("empty metric name on index %d", i)
+		}
+
+		timestamp, err := point.Timestamp.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp \"%s\" on index %d", point.Timestamp, i)
+		}
+
+		value, err := point.Value.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid value \"%s\" on index %d", point.Value, i)
+		}
+
+		keys := make([]string, 0, len(point.Tags))
+		for k := range point.Tags {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		tags := make([]interface{}, 0, len(keys)*2)
+		for _, k := range keys {
+			tags = append(tags, k, point.Tags[k])
+		}
+
+		items[i] = &openTSDBSerializer.ArrayItem{
+			Metric:    point.Metric,
+			Timestamp: timestamp,
+			Value:     value,
+			Tags:      tags,
+		}
+	}
+
+	return items, nil
+}
@@ -0,0 +1,455 @@
+package timeline
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uol/logh"
+)
+
+/**
+* Exposes the flattened values and the accumulated counts in the prometheus text exposition format.
+* It wraps a push transport (or works alone) observing the points emitted by the flattener and the accumulator.
+* @author rnojiri
+**/
+
+const (
+	prometheusGauge       string = "gauge"
+	prometheusCounter     string = "counter"
+	prometheusContentType string = "text/plain; version=0.0.4; charset=utf-8"
+	prometheusTotalSuffix string = "_total"
+)
+
+var (
+	prometheusLabelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// exportedSeries - the last value from a series
+type exportedSeries struct {
+	labels     []remoteWriteLabel
+	value      float64
+	lastUpdate time.Time
+}
+
+// exportedFamily - all series from a metric
+type exportedFamily struct {
+	metricType string
+	series     map[string]*exportedSeries
+}
+
+// PrometheusExporter - exposes the processed points using a http handler
+type PrometheusExporter struct {
+	configuration *PrometheusExporterConfig
+	transport     Transport
+	itemTransport *openTSDBItemTransport
+	families      map[string]*exportedFamily
+	loggers       *logh.ContextualLogger
+	sync.Mutex
+}
+
+// NewPrometheusExporter - creates a new exporter, the transport is optional (without it the points are only exposed)
+func NewPrometheusExporter(configuration *PrometheusExporterConfig, transport Transport) (*PrometheusExporter, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	if configuration.SeriesTTL.Duration < 0 {
+		return nil, fmt.Errorf("invalid series ttl: %s", configuration.SeriesTTL)
+	}
+
+	return &PrometheusExporter{
+		configuration: configuration,
+		transport:     transport,
+		itemTransport: &openTSDBItemTransport{},
+		families:      map[string]*exportedFamily{},
+	}, nil
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (e *PrometheusExporter) BuildContextualLogger(path ...string) {
+
+	logContext := []string{"pkg", "timeline/exporter"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	e.loggers = logh.CreateContextualLogger(logContext...)
+
+	if e.transport != nil {
+		e.transport.BuildContextualLogger(path...)
+	}
+}
+
+// logError - logs an error
+func (e *PrometheusExporter) logError(err error, msg string) {
+
+	if logh.ErrorEnabled {
+		ev := e.loggers.Error()
+		if e.configuration.PrintStackOnError {
+			ev = ev.Caller()
+		}
+		ev.Err(err).Msg(msg)
+	}
+}
+
+// dataChannelItemToSeriesPoint - uses the wrapped transport to convert the item
+func (e *PrometheusExporter) dataChannelItemToSeriesPoint(item interface{}) (*SeriesPoint, error) {
+
+	if e.transport == nil {
+		return e.itemTransport.dataChannelItemToSeriesPoint(item)
+	}
+
+	if converter, ok := e.transport.(seriesPointConverter); ok {
+		return converter.DataChannelItemToSeriesPoint(item)
+	}
+
+	return nil, ErrUnsupportedOperation
+}
+
+// record - stores the item value (counters are incremented)
+func (e *PrometheusExporter) record(item interface{}, metricType string) {
+
+	point, err := e.dataChannelItemToSeriesPoint(item)
+	if err != nil {
+		e.logError(err, "error converting item to series point")
+		return
+	}
+
+	labels, err := prometheusLabels(point.Metric, point.Tags)
+	if err != nil {
+		e.logError(err, "error building the prometheus labels")
+		return
+	}
+
+	name := sanitizePrometheusName(point.Metric, true)
+
+	if metricType == prometheusCounter && !strings.HasSuffix(name, prometheusTotalSuffix) {
+		name += prometheusTotalSuffix
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	family, ok := e.families[name]
+	if !ok {
+		family = &exportedFamily{
+			metricType: metricType,
+			series:     map[string]*exportedSeries{},
+		}
+		e.families[name] = family
+	}
+
+	if family.metricType != metricType {
+		if logh.WarnEnabled {
+			e.loggers.Warn().Msgf("metric \"%s\" is already exported as %s, ignoring the %s value", name, family.metricType, metricType)
+		}
+		return
+	}
+
+	key := prometheusLabelsKey(labels)
+
+	series, ok := family.series[key]
+	if !ok {
+		series = &exportedSeries{labels: labels}
+		family.series[key] = series
+	}
+
+	if metricType == prometheusCounter {
+		series.value += point.Value
+	} else {
+		series.value = point.Value
+	}
+
+	series.lastUpdate = time.Now()
+}
+
+// ServeHTTP - writes the exported series using the text exposition format
+func (e *PrometheusExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", prometheusContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(e.Export()))
+}
+
+// Export - returns the exported series using the text exposition format
+func (e *PrometheusExporter) Export() string {
+
+	e.Lock()
+	defer e.Unlock()
+
+	ttl := e.configuration.SeriesTTL.Duration
+	now := time.Now()
+
+	names := make([]string, 0, len(e.families))
+	for name := range e.families {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var b strings.Builder
+
+	for _, name := range names {
+
+		family := e.families[name]
+
+		keys := make([]string, 0, len(family.series))
+		for key, series := range family.series {
+			if ttl > 0 && now.Sub(series.lastUpdate) > ttl {
+				delete(family.series, key)
+				continue
+			}
+			keys = append(keys, key)
+		}
+
+		if len(keys) == 0 {
+			delete(e.families, name)
+			continue
+		}
+
+		sort.Strings(keys)
+
+		b.WriteString("# TYPE ")
+		b.WriteString(name)
+		b.WriteByte(' ')
+		b.WriteString(family.metricType)
+		b.WriteByte('\n')
+
+		for _, key := range keys {
+
+			series := family.series[key]
+
+			b.WriteString(name)
+
+			first := true
+
+			for _, l := range series.labels {
+
+				if l.name == prometheusMetricNameLabel {
+					continue
+				}
+
+				if first {
+					b.WriteByte('{')
+					first = false
+				} else {
+					b.WriteByte(',')
+				}
+
+				b.WriteString(l.name)
+				b.WriteString(`="`)
+				b.WriteString(prometheusLabelValueEscaper.Replace(l.value))
+				b.WriteByte('"')
+			}
+
+			if !first {
+				b.WriteByte('}')
+			}
+
+			b.WriteByte(' ')
+			b.WriteString(strconv.FormatFloat(series.value, 'g', -1, 64))
+			b.WriteByte('\n')
+		}
+	}
+
+	return b.String()
+}
+
+// DataChannelItemToFlattenerPoint - converts the data channel item to the flattened point one
+func (e *PrometheusExporter) DataChannelItemToFlattenerPoint(configuration *DataTransformerConfig, instance interface{}, operation FlatOperation) (Hashable, error) {
+
+	if e.transport != nil {
+		return e.transport.DataChannelItemToFlattenerPoint(configuration, instance, operation)
+	}
+
+	return e.itemTransport.dataChannelItemToFlattenerPoint(configuration, instance, operation)
+}
+
+// FlattenerPointToDataChannelItem - converts the flattened point to the data channel one
+func (e *PrometheusExporter) FlattenerPointToDataChannelItem(point *FlattenerPoint) (interface{}, error) {
+
+	if e.transport != nil {
+		return e.transport.FlattenerPointToDataChannelItem(point)
+	}
+
+	return e.itemTransport.flattenerPointToDataChannelItem(point)
+}
+
+// flattenedPointEmitted - records the flattened value (exposed as gauge) and forwards it to the wrapped transport
+func (e *PrometheusExporter) flattenedPointEmitted(item interface{}) {
+
+	e.record(item, prometheusGauge)
+
+	if observer, ok := e.transport.(emittedPointObserver); ok {
+		observer.flattenedPointEmitted(item)
+	}
+}
+
+// DataChannelItemToAccumulatedData - converts the data channel item to the accumulated data
+func (e *PrometheusExporter) DataChannelItemToAccumulatedData(configuration *DataTransformerConfig, instance interface{}, calculateHash bool) (Hashable, error) {
+
+	if e.transport != nil {
+		return e.transport.DataChannelItemToAccumulatedData(configuration, instance, calculateHash)
+	}
+
+	return e.itemTransport.dataChannelItemToAccumulatedData(configuration, instance, calculateHash)
+}
+
+// AccumulatedDataToDataChannelItem - converts the accumulated data to the data channel item
+func (e *PrometheusExporter) AccumulatedDataToDataChannelItem(point *accumulatedData) (interface{}, error) {
+
+	if e.transport != nil {
+		return e.transport.AccumulatedDataToDataChannelItem(point)
+	}
+
+	return e.itemTransport.accumulatedDataToDataChannelItem(point)
+}
+
+// accumulatedDataEmitted - records the accumulated count (exposed as cumulative counter) and forwards it to the wrapped transport
+func (e *PrometheusExporter) accumulatedDataEmitted(item interface{}) {
+
+	e.record(item, prometheusCounter)
+
+	if observer, ok := e.transport.(emittedPointObserver); ok {
+		observer.accumulatedDataEmitted(item)
+	}
+}
+
+// DataChannelItemToSeriesPoint - converts the data channel item to the series point
+func (e *PrometheusExporter) DataChannelItemToSeriesPoint(instance interface{}) (*SeriesPoint, error) {
+
+	return e.dataChannelItemToSeriesPoint(instance)
+}
+
+// DataChannelItemToRollup - returns the data channel item without the tags removed by the rollup (nil if the rollup does not match)
+func (e *PrometheusExporter) DataChannelItemToRollup(instance interface{}, rollup *FlattenRollup) (interface{}, error) {
+
+	if e.transport == nil {
+		return e.itemTransport.dataChannelItemToRollup(instance, rollup)
+	}
+
+	if converter, ok := e.transport.(rollupConverter); ok {
+		return converter.DataChannelItemToRollup(instance, rollup)
+	}
+
+	return nil, ErrUnsupportedOperation
+}
+
+// accumulatedDataRemoved - forwards the expired accumulated data to the wrapped transport
+func (e *PrometheusExporter) accumulatedDataRemoved(hash string) {
+
+	if observer, ok := e.transport.(accumulatedDataObserver); ok {
+		observer.accumulatedDataRemoved(hash)
+	}
+}
+
+// DataChannel - sends the point to the wrapped transport (discarded if there is no transport)
+func (e *PrometheusExporter) DataChannel(item interface{}) {
+
+	if e.transport != nil {
+		e.transport.DataChannel(item)
+	}
+}
+
+// ConfigureBackend - configures the wrapped transport backend
+func (e *PrometheusExporter) ConfigureBackend(backend *Backend) error {
+
+	if e.transport != nil {
+		return e.transport.ConfigureBackend(backend)
+	}
+
+	return nil
+}
+
+// TransferData - transfers the data using the wrapped transport
+func (e *PrometheusExporter) TransferData(payload []string) error {
+
+	if e.transport != nil {
+		return e.transport.TransferData(payload)
+	}
+
+	return nil
+}
+
+// SerializePayload - serializes a list of generic data using the wrapped transport
+func (e *PrometheusExporter) SerializePayload(dataList []interface{}) (payload []string, err error) {
+
+	if e.transport != nil {
+		return e.transport.SerializePayload(dataList)
+	}
+
+	return nil, nil
+}
+
+// Serialize - renders the text using the wrapped transport
+func (e *PrometheusExporter) Serialize(item interface{}) (string, error) {
+
+	if e.transport != nil {
+		return e.transport.Serialize(item)
+	}
+
+	return empty, nil
+}
+
+// Start - starts the wrapped transport
+func (e *PrometheusExporter) Start(manualMode bool) error {
+
+	if e.transport != nil {
+		return e.transport.Start(manualMode)
+	}
+
+	return nil
+}
+
+// SendData - sends the data using the wrapped transport
+func (e *PrometheusExporter) SendData() error {
+
+	if e.transport != nil {
+		return e.transport.SendData()
+	}
+
+	return nil
+}
+
+// Close - closes the wrapped transport
+func (e *PrometheusExporter) Close() {
+
+	if e.transport != nil {
+		e.transport.Close()
+	}
+}
+
+// MatchType - checks the wrapped transport type (accepts the opentsdb items if there is no transport)
+func (e *PrometheusExporter) MatchType(tt transportType) bool {
+
+	if e.transport != nil {
+		return e.transport.MatchType(tt)
+	}
+
+	return tt == typeOpenTSDB
+}
+
+// AddPointFilter - adds a filter to the wrapped transport
+func (e *PrometheusExporter) AddPointFilter(filter PointFilter) {
+
+	if filterer, ok := e.transport.(pointFilterer); ok {
+		filterer.AddPointFilter(filter)
+	}
+}
+
+// GetStats - returns the wrapped transport statistics
+func (e *PrometheusExporter) GetStats() TransportStats {
+
+	if provider, ok := e.transport.(statsProvider); ok {
+		return provider.GetStats()
+	}
+
+	return TransportStats{}
+}
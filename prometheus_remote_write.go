@@ -0,0 +1,490 @@
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/uol/funks"
+	"github.com/uol/logh"
+	serializer "github.com/uol/serializer/opentsdb"
+)
+
+/**
+* The Prometheus remote write transport implementation (accepts the opentsdb items).
+* @author rnojiri
+**/
+
+const (
+	defaultRemoteWriteEndpoint   string        = "/api/v1/write"
+	defaultRemoteWriteRetries    int           = 3
+	defaultRemoteWriteMinBackoff time.Duration = 30 * time.Millisecond
+	defaultRemoteWriteMaxBackoff time.Duration = 5 * time.Second
+	remoteWriteVersion           string        = "0.1.0"
+	prometheusMetricNameLabel    string        = "__name__"
+	prometheusReservedPrefix     string        = "__"
+	prometheusTagLabelPrefix     string        = "tag"
+)
+
+// PrometheusRemoteWriteTransport - implements the prometheus remote write transport
+type PrometheusRemoteWriteTransport struct {
+	core          transportCore
+	configuration *PrometheusRemoteWriteConfig
+	httpClient    *http.Client
+	serviceURL    string
+	headers       map[string]string
+	itemTransport *openTSDBItemTransport
+}
+
+// remoteWriteLabel - a prometheus label
+type remoteWriteLabel struct {
+	name  string
+	value string
+}
+
+// remoteWriteSample - a prometheus sample
+type remoteWriteSample struct {
+	value     float64
+	timestamp int64
+}
+
+// remoteWriteSeries - a prometheus time series and its samples
+type remoteWriteSeries struct {
+	labels  []remoteWriteLabel
+	samples []remoteWriteSample
+}
+
+// NewPrometheusRemoteWriteTransport - creates a new prometheus remote write event manager
+func NewPrometheusRemoteWriteTransport(configuration *PrometheusRemoteWriteConfig) (*PrometheusRemoteWriteTransport, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+
+	if configuration.MaxRetries < 0 {
+		return nil, fmt.Errorf("invalid maximum number of retries: %d", configuration.MaxRetries)
+	}
+
+	if configuration.MaxRetries == 0 {
+		configuration.MaxRetries = defaultRemoteWriteRetries
+	}
+
+	if configuration.MinBackoff.Duration == 0 {
+		configuration.MinBackoff.Duration = defaultRemoteWriteMinBackoff
+	}
+
+	if configuration.MaxBackoff.Duration == 0 {
+		configuration.MaxBackoff.Duration = defaultRemoteWriteMaxBackoff
+	}
+
+	if configuration.MinBackoff.Duration < 0 || configuration.MaxBackoff.Duration < configuration.MinBackoff.Duration {
+		return nil, fmt.Errorf("invalid backoff interval: %s - %s", configuration.MinBackoff, configuration.MaxBackoff)
+	}
+
+	if len(configuration.ServiceEndpoint) == 0 {
+		configuration.ServiceEndpoint = defaultRemoteWriteEndpoint
+	}
+
+	headers := map[string]string{}
+	for k, v := range configuration.Headers {
+		headers[k] = v
+	}
+
+	// the headers required by the specification
+	headers["Content-Encoding"] = "snappy"
+	headers["Content-Type"] = "application/x-protobuf"
+	headers["X-Prometheus-Remote-Write-Version"] = remoteWriteVersion
+
+	if _, ok := headers["User-Agent"]; !ok {
+		headers["User-Agent"] = "timeline"
+	}
+
+	t := &PrometheusRemoteWriteTransport{
+		core: transportCore{
+			batchSendInterval:    configuration.BatchSendInterval.Duration,
+			defaultConfiguration: &configuration.DefaultTransportConfig,
+		},
+		configuration: configuration,
+		httpClient:    funks.CreateHTTPClient(configuration.RequestTimeout.Duration, true),
+		headers:       headers,
+		itemTransport: &openTSDBItemTransport{},
+	}
+
+	t.core.transport = t
+
+	return t, nil
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (t *PrometheusRemoteWriteTransport) BuildContextualLogger(path ...string) {
+
+	if t.core.loggers != nil {
+		return
+	}
+
+	logContext := []string{"pkg", "timeline/remotewrite"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	t.core.loggers = logh.CreateContextualLogger(logContext...)
+}
+
+// ConfigureBackend - configures the backend
+func (t *PrometheusRemoteWriteTransport) ConfigureBackend(backend *Backend) error {
+
+	if backend == nil {
+		return fmt.Errorf("no backend was configured")
+	}
+
+	t.serviceURL = fmt.Sprintf("http://%s:%d/%s", backend.Host, backend.Port, strings.TrimPrefix(t.configuration.ServiceEndpoint, "/"))
+
+	if logh.InfoEnabled {
+		t.core.loggers.Info().Msg(fmt.Sprintf("backend was configured to use service: %s", t.serviceURL))
+	}
+
+	return nil
+}
+
+// sanitizePrometheusName - replaces the invalid characters by '_' (colons are only valid in metric names)
+func sanitizePrometheusName(name string, allowColon bool) string {
+
+	var b strings.Builder
+	b.Grow(len(name) + 1)
+
+	for i, r := range name {
+
+		valid := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' || (allowColon && r == ':') || (i > 0 && r >= '0' && r <= '9')
+
+		if i == 0 && r >= '0' && r <= '9' {
+			b.WriteByte('_')
+			valid = true
+		}
+
+		if valid {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+
+	return b.String()
+}
+
+// toSeries - converts the opentsdb item to the prometheus series labels
+func (t *PrometheusRemoteWriteTransport) toSeries(item *serializer.ArrayItem) (*remoteWriteSeries, error) {
+
+	labels, err := prometheusLabels(item.Metric, item.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &remoteWriteSeries{labels: labels}, nil
+}
+
+// prometheusLabels - converts the metric and tags to the sorted prometheus labels (including the metric name)
+func prometheusLabels(metric string, tags []interface{}) ([]remoteWriteLabel, error) {
+
+	numTags := len(tags)
+	if numTags%2 != 0 {
+		return nil, fmt.Errorf("the number of tags must be even")
+	}
+
+	if len(metric) == 0 {
+		return nil, fmt.Errorf("empty metric name")
+	}
+
+	labels := make([]remoteWriteLabel, 0, numTags/2+1)
+	labels = append(labels, remoteWriteLabel{name: prometheusMetricNameLabel, value: sanitizePrometheusName(metric, true)})
+
+	seen := map[string]bool{prometheusMetricNameLabel: true}
+
+	for i := 0; i < numTags; i += 2 {
+
+		key, ok := tags[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("error casting tag key to string")
+		}
+
+		if len(key) == 0 || tags[i+1] == nil {
+			continue
+		}
+
+		value := fmt.Sprint(tags[i+1])
+
+		// empty labels are the same as missing labels for prometheus
+		if len(value) == 0 {
+			continue
+		}
+
+		name := sanitizePrometheusName(key, false)
+
+		// the labels starting with "__" are reserved for the prometheus internal use
+		if strings.HasPrefix(name, prometheusReservedPrefix) {
+			name = prometheusTagLabelPrefix + name
+		}
+
+		if seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		labels = append(labels, remoteWriteLabel{name: name, value: value})
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+
+	return labels, nil
+}
+
+// key - builds a key to group the samples from the same series
+func (s *remoteWriteSeries) key() string {
+
+	return prometheusLabelsKey(s.labels)
+}
+
+// prometheusLabelsKey - builds a key identifying the label set
+func prometheusLabelsKey(labels []remoteWriteLabel) string {
+
+	var b strings.Builder
+
+	for _, l := range labels {
+		b.WriteString(l.name)
+		b.WriteByte(0)
+		b.WriteString(l.value)
+		b.WriteByte(0)
+	}
+
+	return b.String()
+}
+
+// encodeWriteRequest - encodes the series as a remote write protobuf message
+func encodeWriteRequest(seriesList []*remoteWriteSeries) []byte {
+
+	e := protoEncoder{}
+
+	for _, series := range seriesList {
+
+		e.messageField(1, func(ts *protoEncoder) {
+
+			for _, l := range series.labels {
+				ts.messageField(1, func(le *protoEncoder) {
+					le.stringField(1, l.name)
+					le.stringField(2, l.value)
+				})
+			}
+
+			for _, s := range series.samples {
+				ts.messageField(2, func(se *protoEncoder) {
+					se.doubleField(1, s.value)
+					se.int64Field(2, s.timestamp)
+				})
+			}
+		})
+	}
+
+	return e.buf
+}
+
+// SerializePayload - serializes a list of generic data
+func (t *PrometheusRemoteWriteTransport) SerializePayload(dataList []interface{}) (payload []string, err error) {
+
+	seriesMap := map[string]*remoteWriteSeries{}
+	seriesList := []*remoteWriteSeries{}
+
+	for _, data := range dataList {
+
+		item, ok := data.(*serializer.ArrayItem)
+		if !ok {
+			return nil, fmt.Errorf("unexpected instance type: %+v", data)
+		}
+
+		series, err := t.toSeries(item)
+		if err != nil {
+			return nil, err
+		}
+
+		key := series.key()
+
+		if stored, exists := seriesMap[key]; exists {
+			series = stored
+		} else {
+			seriesMap[key] = series
+			seriesList = append(seriesList, series)
+		}
+
+		series.samples = append(series.samples, remoteWriteSample{
+			value:     item.Value,
+			timestamp: item.Timestamp * 1000,
+		})
+	}
+
+	for _, series := range seriesList {
+		sort.SliceStable(series.samples, func(i, j int) bool {
+			return series.samples[i].timestamp < series.samples[j].timestamp
+		})
+	}
+
+	return []string{string(snappy.Encode(nil, encodeWriteRequest(seriesList)))}, nil
+}
+
+// Serialize - renders the prometheus exposition format line (used only for debugging)
+func (t *PrometheusRemoteWriteTransport) Serialize(item interface{}) (string, error) {
+
+	casted, ok := item.(*serializer.ArrayItem)
+	if !ok {
+		return empty, fmt.Errorf("unexpected instance type: %+v", item)
+	}
+
+	series, err := t.toSeries(casted)
+	if err != nil {
+		return empty, err
+	}
+
+	var b strings.Builder
+
+	b.WriteString(sanitizePrometheusName(casted.Metric, true))
+
+	labels := make([]string, 0, len(series.labels))
+	for _, l := range series.labels {
+		if l.name != prometheusMetricNameLabel {
+			labels = append(labels, fmt.Sprintf("%s=%q", l.name, l.value))
+		}
+	}
+
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		b.WriteString(strings.Join(labels, ","))
+		b.WriteByte('}')
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(casted.Value, 'g', -1, 64))
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(casted.Timestamp*1000, 10))
+
+	return b.String(), nil
+}
+
+// backoff - returns the time to wait before the next retry (the retry after header is also limited by the maximum backoff)
+func (t *PrometheusRemoteWriteTransport) backoff(attempt int, statusErr *httpStatusError) time.Duration {
+
+	if statusErr != nil && len(statusErr.retryAfter) > 0 {
+		if seconds, err := strconv.Atoi(statusErr.retryAfter); err == nil && seconds >= 0 {
+			if wait := time.Duration(seconds) * time.Second; wait >= 0 && wait < t.configuration.MaxBackoff.Duration {
+				return wait
+			}
+			return t.configuration.MaxBackoff.Duration
+		}
+	}
+
+	wait := t.configuration.MinBackoff.Duration << uint(attempt)
+	if wait <= 0 || wait > t.configuration.MaxBackoff.Duration {
+		wait = t.configuration.MaxBackoff.Duration
+	}
+
+	return wait
+}
+
+// retryable - following the specification, only 5xx, 429 (if enabled) and connection errors are retried
+func (t *PrometheusRemoteWriteTransport) retryable(statusErr *httpStatusError) bool {
+
+	if statusErr == nil {
+		return true
+	}
+
+	if statusErr.statusCode == http.StatusTooManyRequests {
+		return t.configuration.RetryOnRateLimit
+	}
+
+	return statusErr.statusCode/100 == 5
+}
+
+// TransferData - transfers the data to the backend throught this transport
+func (t *PrometheusRemoteWriteTransport) TransferData(payload []string) error {
+
+	size := len(payload)
+	if size == 0 || size > 1 {
+		return ErrInvalidPayloadSize
+	}
+
+	var err error
+
+	for attempt := 0; ; attempt++ {
+
+		err = doHTTPRequest(t.httpClient, http.MethodPost, t.serviceURL, payload[0], t.headers, successStatus)
+		if err == nil {
+			return nil
+		}
+
+		var statusErr *httpStatusError
+		if !errors.As(err, &statusErr) {
+			statusErr = nil
+		}
+
+		if attempt >= t.configuration.MaxRetries || !t.retryable(statusErr) {
+			return err
+		}
+
+		wait := t.backoff(attempt, statusErr)
+
+		if logh.WarnEnabled {
+			t.core.loggers.Warn().Err(err).Msgf("retrying the remote write in %s (attempt %d)", wait, attempt+1)
+		}
+
+		<-time.After(wait)
+	}
+}
+
+// DataChannel - send a new point
+func (t *PrometheusRemoteWriteTransport) DataChannel(item interface{}) {
+
+	t.core.dataChannel(item)
+}
+
+// AddPointFilter - adds a filter to be applied before buffering the points
+func (t *PrometheusRemoteWriteTransport) AddPointFilter(filter PointFilter) {
+
+	t.core.addPointFilter(filter)
+}
+
+// GetStats - returns the transport statistics
+func (t *PrometheusRemoteWriteTransport) GetStats() TransportStats {
+
+	return t.core.getStats()
+}
+
+// MatchType - checks if this transport implementation matches the given type (accepts the opentsdb items)
+func (t *PrometheusRemoteWriteTransport) MatchType(tt transportType) bool {
+
+	return tt == typeOpenTSDB
+}
+
+// Start - starts this transport
+func (t *PrometheusRemoteWriteTransport) Start(manualMode bool) error {
+
+	return t.core.Start(manualMode)
+}
+
+// Close - closes this transport
+func (t *PrometheusRemoteWriteTransport) Close() {
+
+	t.core.Close()
+}
+
+// SendData - releases the point buffer and send all data
+func (t *PrometheusRemoteWriteTransport) SendData() error {
+	return t.core.SendData()
+}
@@ -0,0 +1,103 @@
+package timeline
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+/**
+* A minimal protocol buffers encoder used by the remote write and otlp transports.
+* @author rnojiri
+**/
+
+const (
+	protoVarint  uint64 = 0
+	protoFixed64 uint64 = 1
+	protoBytes   uint64 = 2
+)
+
+// protoEncoder - appends protocol buffers fields to a buffer
+type protoEncoder struct {
+	buf []byte
+}
+
+// varint - appends an unsigned varint
+func (e *protoEncoder) varint(v uint64) {
+
+	for v >= 0x80 {
+		e.buf = append(e.buf, byte(v)|0x80)
+		v >>= 7
+	}
+
+	e.buf = append(e.buf, byte(v))
+}
+
+// tag - appends the field number and wire type
+func (e *protoEncoder) tag(field int, wireType uint64) {
+
+	e.varint(uint64(field)<<3 | wireType)
+}
+
+// uint64Field - appends a varint field (zero values are omitted)
+func (e *protoEncoder) uint64Field(field int, v uint64) {
+
+	if v == 0 {
+		return
+	}
+
+	e.tag(field, protoVarint)
+	e.varint(v)
+}
+
+// int64Field - appends a int64 varint field (zero values are omitted)
+func (e *protoEncoder) int64Field(field int, v int64) {
+
+	e.uint64Field(field, uint64(v))
+}
+
+// fixed64Field - appends a fixed64 field (zero values are omitted)
+func (e *protoEncoder) fixed64Field(field int, v uint64) {
+
+	if v == 0 {
+		return
+	}
+
+	e.fixed64Value(field, v)
+}
+
+// fixed64Value - appends a fixed64 field even if it is zero (used by oneof fields)
+func (e *protoEncoder) fixed64Value(field int, v uint64) {
+
+	e.tag(field, protoFixed64)
+	e.buf = append(e.buf, make([]byte, 8)...)
+	binary.LittleEndian.PutUint64(e.buf[len(e.buf)-8:], v)
+}
+
+// doubleField - appends a double field (zero values are omitted)
+func (e *protoEncoder) doubleField(field int, v float64) {
+
+	e.fixed64Field(field, math.Float64bits(v))
+}
+
+// stringField - appends a string field (empty values are omitted)
+func (e *protoEncoder) stringField(field int, v string) {
+
+	if len(v) == 0 {
+		return
+	}
+
+	e.tag(field, protoBytes)
+	e.varint(uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// messageField - appends an embedded message encoded by the function
+func (e *protoEncoder) messageField(field int, encode func(e *protoEncoder)) {
+
+	inner := protoEncoder{}
+	encode(&inner)
+
+	e.tag(field, protoBytes)
+	e.varint(uint64(len(inner.buf)))
+	e.buf = append(e.buf, inner.buf...)
+}
@@ -3,4 +3,15 @@ go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/buffe
 go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/opentsdb
 go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/http
 go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/config
-go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/udp
\ No newline at end of file
+go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/udp
+go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/influx
+go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/graphite
+go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/statsd
+go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/prometheus
+go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/otlp
+go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/elasticsearch
+go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/unix
+go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/file
+go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/parser
+go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/tests/flattener
+go test -race -v -p 1 -count 1 -timeout 360s github.com/uol/timeline/cmd/...
@@ -0,0 +1,156 @@
+package timeline
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/uol/logh"
+)
+
+/**
+* Samples the points before the flattener and the transport's buffer.
+* @author rnojiri
+**/
+
+// samplingRule - a rule and its state
+type samplingRule struct {
+	*SamplingRule
+	window   int64
+	counters map[uint64]int
+	sync.Mutex
+}
+
+// Sampler - keeps only a fraction of the points using the configured rules
+type Sampler struct {
+	rules       map[string]*samplingRule
+	defaultRule *samplingRule
+	loggers     *logh.ContextualLogger
+}
+
+// NewSampler - creates a new sampler
+func NewSampler(configuration *SamplerConfig) (*Sampler, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	s := &Sampler{
+		rules: map[string]*samplingRule{},
+	}
+
+	for i := range configuration.Rules {
+
+		rule := &configuration.Rules[i]
+
+		switch rule.Strategy {
+
+		case SamplingRandom, SamplingHash:
+
+			if rule.Rate < 0 || rule.Rate > 1 {
+				return nil, fmt.Errorf("invalid sampling rate for metric \"%s\": %f", rule.Metric, rule.Rate)
+			}
+
+		case SamplingFirstN:
+
+			if rule.MaxPoints <= 0 {
+				return nil, fmt.Errorf("invalid maximum number of points for metric \"%s\": %d", rule.Metric, rule.MaxPoints)
+			}
+
+			if rule.Interval.Duration <= 0 {
+				return nil, fmt.Errorf("invalid sampling interval for metric \"%s\": %s", rule.Metric, rule.Interval)
+			}
+
+		default:
+
+			return nil, fmt.Errorf("invalid sampling strategy for metric \"%s\": %s", rule.Metric, rule.Strategy)
+		}
+
+		sr := &samplingRule{
+			SamplingRule: rule,
+			counters:     map[uint64]int{},
+		}
+
+		if len(rule.Metric) == 0 {
+			if s.defaultRule != nil {
+				return nil, fmt.Errorf("only one default sampling rule is allowed")
+			}
+			s.defaultRule = sr
+			continue
+		}
+
+		if _, exists := s.rules[rule.Metric]; exists {
+			return nil, fmt.Errorf("duplicated sampling rule for metric: %s", rule.Metric)
+		}
+
+		s.rules[rule.Metric] = sr
+	}
+
+	s.BuildContextualLogger()
+
+	return s, nil
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (s *Sampler) BuildContextualLogger(path ...string) {
+
+	logContext := []string{"pkg", "timeline/sampler"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	s.loggers = logh.CreateContextualLogger(logContext...)
+}
+
+// Filter - returns true if the point must be kept
+func (s *Sampler) Filter(point *SeriesPoint) bool {
+
+	rule, ok := s.rules[point.Metric]
+	if !ok {
+		rule = s.defaultRule
+	}
+
+	if rule == nil {
+		return true
+	}
+
+	switch rule.Strategy {
+
+	case SamplingRandom:
+
+		return rand.Float64() < rule.Rate
+
+	case SamplingHash:
+
+		// uses the 53 most significant bits to build a float in [0, 1)
+		return float64(point.seriesHash()>>11)/(1<<53) < rule.Rate
+
+	default:
+
+		return rule.keepFirst(point.seriesHash())
+	}
+}
+
+// keepFirst - counts the points from the series in the current interval
+func (r *samplingRule) keepFirst(hash uint64) bool {
+
+	window := time.Now().UnixNano() / int64(r.Interval.Duration)
+
+	r.Lock()
+	defer r.Unlock()
+
+	if window != r.window {
+		r.window = window
+		r.counters = map[uint64]int{}
+	}
+
+	if r.counters[hash] >= r.MaxPoints {
+		return false
+	}
+
+	r.counters[hash]++
+
+	return true
+}
@@ -0,0 +1,142 @@
+package timeline
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+)
+
+/**
+* A transport independent view of the points and the filters applied to them.
+* @author rnojiri
+**/
+
+// PointFilter - a stage applied to every point before it is stored in the transport's buffer
+type PointFilter interface {
+
+	// Filter - returns false to discard the point (the point's tags can be changed using SetTagValue)
+	Filter(point *SeriesPoint) bool
+
+	// BuildContextualLogger - build the contextual logger using more info
+	BuildContextualLogger(path ...string)
+}
+
+// SeriesPoint - the series identity, value and timestamp of a data channel item
+type SeriesPoint struct {
+	// Metric - the metric name or the json schema name
+	Metric string
+
+	// Tags - the tag keys and values (or the json properties)
+	Tags []interface{}
+
+	Value     float64
+	Timestamp int64
+
+	source     *[]interface{}
+	tagIndexes []int
+}
+
+// SetTagValue - replaces a tag value in this point and in its data channel item, returns false if the tag was not found
+func (p *SeriesPoint) SetTagValue(key string, value interface{}) bool {
+
+	for i := 0; i+1 < len(p.Tags); i += 2 {
+
+		if p.Tags[i] != key {
+			continue
+		}
+
+		// the slices may be shared with other items, so they are copied before changing
+		tags := make([]interface{}, len(p.Tags))
+		copy(tags, p.Tags)
+		tags[i+1] = value
+
+		source := make([]interface{}, len(*p.source))
+		copy(source, *p.source)
+		source[p.sourceIndex(i+1)] = value
+
+		p.Tags = tags
+		*p.source = source
+
+		return true
+	}
+
+	return false
+}
+
+// sourceIndex - returns the index of the tag in the data channel item
+func (p *SeriesPoint) sourceIndex(i int) int {
+
+	if p.tagIndexes == nil {
+		return i
+	}
+
+	return p.tagIndexes[i]
+}
+
+// seriesHash - returns a hash of the metric and tags
+func (p *SeriesPoint) seriesHash() uint64 {
+
+	h := fnv.New64a()
+
+	io.WriteString(h, p.Metric)
+
+	for _, tag := range p.Tags {
+		h.Write([]byte{0})
+		writeHashValue(h, tag)
+	}
+
+	return mixHash(h.Sum64())
+}
+
+// pointHash - returns a hash of the metric, tags, timestamp and value
+func (p *SeriesPoint) pointHash() uint64 {
+
+	h := fnv.New64a()
+
+	io.WriteString(h, p.Metric)
+
+	for _, tag := range p.Tags {
+		h.Write([]byte{0})
+		writeHashValue(h, tag)
+	}
+
+	h.Write([]byte{0})
+	io.WriteString(h, strconv.FormatInt(p.Timestamp, 10))
+	h.Write([]byte{0})
+	io.WriteString(h, strconv.FormatFloat(p.Value, 'g', -1, 64))
+
+	return mixHash(h.Sum64())
+}
+
+// writeHashValue - writes a generic value to the hash
+func writeHashValue(w io.Writer, value interface{}) {
+
+	switch casted := value.(type) {
+	case string:
+		io.WriteString(w, casted)
+	default:
+		fmt.Fprint(w, casted)
+	}
+}
+
+// hashString - returns the hash of a single value
+func hashString(value interface{}) uint64 {
+
+	h := fnv.New64a()
+	writeHashValue(h, value)
+
+	return mixHash(h.Sum64())
+}
+
+// mixHash - spreads the fnv bits (murmur3 finalizer), required by the sketches
+func mixHash(h uint64) uint64 {
+
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+
+	return h
+}
@@ -0,0 +1,117 @@
+package timeline
+
+import (
+	"math"
+	"sort"
+)
+
+/**
+* A streaming quantile sketch using logarithmic buckets (the returned quantiles have a bounded relative error).
+* The memory depends on the range of the values and not on the number of values.
+* @author rnojiri
+**/
+
+const (
+	defaultSketchRelativeAccuracy float64 = 0.01
+)
+
+// quantileSketch - counts the values by logarithmic bucket
+type quantileSketch struct {
+	gamma     float64
+	logGamma  float64
+	positives map[int]uint64
+	negatives map[int]uint64
+	zeros     uint64
+	count     uint64
+}
+
+// newQuantileSketch - creates a new sketch with the given relative accuracy (0 < accuracy < 1)
+func newQuantileSketch(relativeAccuracy float64) *quantileSketch {
+
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 {
+		relativeAccuracy = defaultSketchRelativeAccuracy
+	}
+
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+
+	return &quantileSketch{
+		gamma:     gamma,
+		logGamma:  math.Log(gamma),
+		positives: map[int]uint64{},
+		negatives: map[int]uint64{},
+	}
+}
+
+// bucket - returns the bucket index of a positive value
+func (s *quantileSketch) bucket(value float64) int {
+
+	return int(math.Ceil(math.Log(value) / s.logGamma))
+}
+
+// bucketValue - returns the value represented by the bucket
+func (s *quantileSketch) bucketValue(index int) float64 {
+
+	return 2 * math.Pow(s.gamma, float64(index)) / (s.gamma + 1)
+}
+
+// add - adds a value to the sketch
+func (s *quantileSketch) add(value float64) {
+
+	s.count++
+
+	switch {
+	case value > 0:
+		s.positives[s.bucket(value)]++
+	case value < 0:
+		s.negatives[s.bucket(-value)]++
+	default:
+		s.zeros++
+	}
+}
+
+// quantile - returns the approximated quantile (0 <= q <= 1)
+func (s *quantileSketch) quantile(q float64) float64 {
+
+	if s.count == 0 {
+		return 0
+	}
+
+	rank := uint64(q * float64(s.count-1))
+	var seen uint64
+
+	negatives := sortedBuckets(s.negatives)
+	for i := len(negatives) - 1; i >= 0; i-- {
+		seen += s.negatives[negatives[i]]
+		if seen > rank {
+			return -s.bucketValue(negatives[i])
+		}
+	}
+
+	seen += s.zeros
+	if seen > rank {
+		return 0
+	}
+
+	positives := sortedBuckets(s.positives)
+	for _, index := range positives {
+		seen += s.positives[index]
+		if seen > rank {
+			return s.bucketValue(index)
+		}
+	}
+
+	return s.bucketValue(positives[len(positives)-1])
+}
+
+// sortedBuckets - returns the bucket indexes in ascending order
+func sortedBuckets(buckets map[int]uint64) []int {
+
+	indexes := make([]int, 0, len(buckets))
+	for index := range buckets {
+		indexes = append(indexes, index)
+	}
+
+	sort.Ints(indexes)
+
+	return indexes
+}
@@ -0,0 +1,339 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/uol/logh"
+	serializer "github.com/uol/serializer/opentsdb"
+)
+
+/**
+* The StatsD / DogStatsD udp transport implementation.
+* @author rnojiri
+**/
+
+// StatsDType - the statsd metric type
+type StatsDType string
+
+const (
+	// StatsDCounter - the counter type
+	StatsDCounter StatsDType = "c"
+
+	// StatsDGauge - the gauge type
+	StatsDGauge StatsDType = "g"
+
+	// StatsDTimer - the timer type (milliseconds)
+	StatsDTimer StatsDType = "ms"
+
+	// StatsDSet - the set type (counts unique values)
+	StatsDSet StatsDType = "s"
+
+	// StatsDHistogram - the dogstatsd histogram type
+	StatsDHistogram StatsDType = "h"
+
+	// StatsDDistribution - the dogstatsd distribution type
+	StatsDDistribution StatsDType = "d"
+)
+
+var (
+	statsDMetricSanitizer   = strings.NewReplacer(":", "_", "|", "_", "@", "_", "\n", "_")
+	statsDTagKeySanitizer   = strings.NewReplacer(":", "_", "|", "_", ",", "_", "#", "_", "\n", "_")
+	statsDTagValueSanitizer = strings.NewReplacer("|", "_", ",", "_", "\n", "_")
+)
+
+// StatsDItem - a point with its statsd type and sample rate (sample rates equal to zero or one are not sent)
+type StatsDItem struct {
+	serializer.ArrayItem
+	Type       StatsDType
+	SampleRate float64
+}
+
+// StatsDTransport - implements the statsd transport
+type StatsDTransport struct {
+	core           transportCore
+	configuration  *StatsDTransportConfig
+	address        *net.UDPAddr
+	udpNetworkConn *rawNetworkConnection
+	itemTransport  *openTSDBItemTransport
+}
+
+// NewStatsDTransport - creates a new statsd event manager
+func NewStatsDTransport(configuration *StatsDTransportConfig) (*StatsDTransport, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+
+	if configuration.ReconnectionTimeout.Seconds() <= 0 {
+		return nil, fmt.Errorf("invalid connection reconnection timeout: %s", configuration.ReconnectionTimeout)
+	}
+
+	if configuration.MaxReconnectionRetries == 0 {
+		configuration.MaxReconnectionRetries = defaultConnRetries
+	}
+
+	if configuration.MaxDatagramSize < 0 {
+		return nil, fmt.Errorf("invalid maximum datagram size: %d", configuration.MaxDatagramSize)
+	}
+
+	if configuration.MaxDatagramSize == 0 {
+		configuration.MaxDatagramSize = defaultMaxDatagramSize
+	}
+
+	t := &StatsDTransport{
+		core: transportCore{
+			batchSendInterval:    configuration.BatchSendInterval.Duration,
+			defaultConfiguration: &configuration.DefaultTransportConfig,
+		},
+		udpNetworkConn: &rawNetworkConnection{
+			transportConfiguration: &configuration.DefaultTransportConfig,
+			configuration:          &configuration.TCPUDPTransportConfig,
+		},
+		itemTransport: &openTSDBItemTransport{},
+		configuration: configuration,
+	}
+
+	t.core.transport = t
+	t.udpNetworkConn.custom = t
+
+	return t, nil
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (t *StatsDTransport) BuildContextualLogger(path ...string) {
+
+	if t.core.loggers != nil {
+		return
+	}
+
+	logContext := []string{"pkg", "timeline/statsd"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	t.core.loggers = logh.CreateContextualLogger(logContext...)
+	t.udpNetworkConn.loggers = t.core.loggers
+}
+
+// ConfigureBackend - configures the backend
+func (t *StatsDTransport) ConfigureBackend(backend *Backend) error {
+
+	if backend == nil {
+		return fmt.Errorf("no backend was configured")
+	}
+
+	var err error
+	t.address, err = net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", backend.Host, backend.Port))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// statsDTypeFromOperation - returns the statsd type matching the flattener operation
+func statsDTypeFromOperation(operation FlatOperation) StatsDType {
+
+	switch operation {
+	case Sum, Count:
+		return StatsDCounter
+	default:
+		return StatsDGauge
+	}
+}
+
+// serializeLine - serializes a point using the statsd protocol
+func (t *StatsDTransport) serializeLine(b *strings.Builder, item *serializer.ArrayItem, statsDType StatsDType, sampleRate float64) error {
+
+	numTags := len(item.Tags)
+	if numTags%2 != 0 {
+		return fmt.Errorf("the number of tags must be even")
+	}
+
+	if len(item.Metric) == 0 {
+		return fmt.Errorf("empty metric name")
+	}
+
+	if math.IsNaN(item.Value) || math.IsInf(item.Value, 0) {
+		return fmt.Errorf("the statsd protocol does not support NaN or Inf values: %s", item.Metric)
+	}
+
+	switch statsDType {
+	case StatsDCounter, StatsDGauge, StatsDTimer, StatsDSet, StatsDHistogram, StatsDDistribution:
+	default:
+		return fmt.Errorf("invalid statsd type: %s", statsDType)
+	}
+
+	if sampleRate < 0 || sampleRate > 1 {
+		return fmt.Errorf("invalid sample rate: %f", sampleRate)
+	}
+
+	b.WriteString(statsDMetricSanitizer.Replace(item.Metric))
+	b.WriteByte(':')
+	b.WriteString(strconv.FormatFloat(item.Value, 'f', -1, 64))
+	b.WriteByte('|')
+	b.WriteString(string(statsDType))
+
+	if sampleRate > 0 && sampleRate < 1 {
+		b.WriteString("|@")
+		b.WriteString(strconv.FormatFloat(sampleRate, 'f', -1, 64))
+	}
+
+	if !t.configuration.DogStatsD || numTags == 0 {
+		return nil
+	}
+
+	first := true
+
+	for i := 0; i < numTags; i += 2 {
+
+		key, ok := item.Tags[i].(string)
+		if !ok {
+			return fmt.Errorf("error casting tag key to string")
+		}
+
+		if len(key) == 0 || item.Tags[i+1] == nil {
+			continue
+		}
+
+		if first {
+			b.WriteString("|#")
+			first = false
+		} else {
+			b.WriteByte(',')
+		}
+
+		b.WriteString(statsDTagKeySanitizer.Replace(key))
+
+		value := fmt.Sprint(item.Tags[i+1])
+		if len(value) > 0 {
+			b.WriteByte(':')
+			b.WriteString(statsDTagValueSanitizer.Replace(value))
+		}
+	}
+
+	return nil
+}
+
+// SerializePayload - serializes a list of generic data
+func (t *StatsDTransport) SerializePayload(dataList []interface{}) (payload []string, err error) {
+
+	lines := make([]string, len(dataList))
+
+	for i, data := range dataList {
+
+		lines[i], err = t.Serialize(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return t.core.packDatagrams(lines, "\n", t.configuration.MaxDatagramSize), nil
+}
+
+// Serialize - renders the statsd line (opentsdb items are sent as gauges)
+func (t *StatsDTransport) Serialize(item interface{}) (string, error) {
+
+	var b strings.Builder
+	var err error
+
+	switch casted := item.(type) {
+	case *StatsDItem:
+		err = t.serializeLine(&b, &casted.ArrayItem, casted.Type, casted.SampleRate)
+	case *serializer.ArrayItem:
+		err = t.serializeLine(&b, casted, StatsDGauge, 0)
+	default:
+		return empty, fmt.Errorf("unexpected instance type: %+v", item)
+	}
+
+	if err != nil {
+		return empty, err
+	}
+
+	return b.String(), nil
+}
+
+func (t *StatsDTransport) getAddress() net.Addr {
+
+	return t.address
+}
+
+func (t *StatsDTransport) read(conn net.Conn, logConnError func(error, rwOp)) bool {
+
+	return true
+}
+
+func (t *StatsDTransport) dial() (net.Conn, error) {
+
+	return net.DialUDP("udp", nil, t.address)
+}
+
+// TransferData - transfers the data to the backend throught this transport
+func (t *StatsDTransport) TransferData(payload []string) error {
+
+	size := len(payload)
+	if size == 0 {
+		return ErrInvalidPayloadSize
+	}
+
+	for _, p := range payload {
+
+		err := t.udpNetworkConn.transferData(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DataChannel - send a new point
+func (t *StatsDTransport) DataChannel(item interface{}) {
+
+	t.core.dataChannel(item)
+}
+
+// AddPointFilter - adds a filter to be applied before buffering the points
+func (t *StatsDTransport) AddPointFilter(filter PointFilter) {
+
+	t.core.addPointFilter(filter)
+}
+
+// GetStats - returns the transport statistics
+func (t *StatsDTransport) GetStats() TransportStats {
+
+	return t.core.getStats()
+}
+
+// MatchType - checks if this transport implementation matches the given type
+func (t *StatsDTransport) MatchType(tt transportType) bool {
+
+	return tt == typeStatsD
+}
+
+// Start - starts this transport
+func (t *StatsDTransport) Start(manualMode bool) error {
+
+	return t.core.Start(manualMode)
+}
+
+// Close - closes this transport
+func (t *StatsDTransport) Close() {
+
+	t.core.Close()
+	t.udpNetworkConn.closeConnection()
+}
+
+// SendData - releases the point buffer and send all data
+func (t *StatsDTransport) SendData() error {
+	return t.core.SendData()
+}
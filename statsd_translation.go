@@ -0,0 +1,84 @@
+package timeline
+
+import (
+	"fmt"
+
+	serializer "github.com/uol/serializer/opentsdb"
+)
+
+// unwrapStatsDItem - returns the opentsdb item inside the statsd item
+func unwrapStatsDItem(instance interface{}) interface{} {
+
+	if item, ok := instance.(*StatsDItem); ok {
+		return &item.ArrayItem
+	}
+
+	return instance
+}
+
+// DataChannelItemToFlattenerPoint - converts the data channel item to the flattened point one
+func (t *StatsDTransport) DataChannelItemToFlattenerPoint(configuration *DataTransformerConfig, instance interface{}, operation FlatOperation) (Hashable, error) {
+
+	return t.itemTransport.dataChannelItemToFlattenerPoint(configuration, unwrapStatsDItem(instance), operation)
+}
+
+// FlattenerPointToDataChannelItem - converts the flattened point to the data channel one (sums and counts are sent as counters)
+func (t *StatsDTransport) FlattenerPointToDataChannelItem(point *FlattenerPoint) (interface{}, error) {
+
+	item, err := t.itemTransport.flattenerPointToDataChannelItem(point)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.wrapItem(item, statsDTypeFromOperation(point.operation))
+}
+
+// DataChannelItemToAccumulatedData - converts the data channel item to the accumulated data
+func (t *StatsDTransport) DataChannelItemToAccumulatedData(configuration *DataTransformerConfig, instance interface{}, calculateHash bool) (Hashable, error) {
+
+	return t.itemTransport.dataChannelItemToAccumulatedData(configuration, unwrapStatsDItem(instance), calculateHash)
+}
+
+// AccumulatedDataToDataChannelItem - converts the accumulated data to the data channel item (sent as counter)
+func (t *StatsDTransport) AccumulatedDataToDataChannelItem(point *accumulatedData) (interface{}, error) {
+
+	item, err := t.itemTransport.accumulatedDataToDataChannelItem(point)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.wrapItem(item, StatsDCounter)
+}
+
+// DataChannelItemToSeriesPoint - converts the data channel item to the series point
+func (t *StatsDTransport) DataChannelItemToSeriesPoint(instance interface{}) (*SeriesPoint, error) {
+
+	return t.itemTransport.dataChannelItemToSeriesPoint(unwrapStatsDItem(instance))
+}
+
+// DataChannelItemToRollup - returns the data channel item without the tags removed by the rollup (nil if the rollup does not match)
+func (t *StatsDTransport) DataChannelItemToRollup(instance interface{}, rollup *FlattenRollup) (interface{}, error) {
+
+	unwrapped := unwrapStatsDItem(instance)
+
+	rolled, err := t.itemTransport.dataChannelItemToRollup(unwrapped, rollup)
+	if err != nil || rolled != unwrapped {
+		return rolled, err
+	}
+
+	return instance, nil
+}
+
+// wrapItem - wraps the opentsdb item using the specified type
+func (t *StatsDTransport) wrapItem(item interface{}, statsDType StatsDType) (interface{}, error) {
+
+	casted, ok := item.(*serializer.ArrayItem)
+	if !ok {
+		return nil, fmt.Errorf("error casting data channel item: %+v", item)
+	}
+
+	return &StatsDItem{
+		ArrayItem: *casted,
+		Type:      statsDType,
+	}, nil
+}
@@ -22,7 +22,46 @@ type DataTransformerConfig struct {
 	HashingAlgorithm  hashing.Algorithm `json:"hashingAlgorithm,omitempty"`
 	HashSize          int               `json:"hashSize,omitempty"`
 	PrintStackOnError bool              `json:"printStackOnError,omitempty"`
-	isSHAKE           bool
+
+	// QuantileSketch - the quantiles use the streaming sketch (constant memory) instead of the exact buffered values
+	QuantileSketch bool `json:"quantileSketch,omitempty"`
+
+	// SketchRelativeAccuracy - the relative accuracy of the quantile sketch (0.01 by default)
+	SketchRelativeAccuracy float64 `json:"sketchRelativeAccuracy,omitempty"`
+
+	// FirstLastByTimestamp - first and last use the point timestamps instead of the arrival order
+	FirstLastByTimestamp bool `json:"firstLastByTimestamp,omitempty"`
+
+	// OperationLabel - labels the multiple operations by metric suffix or by tag (suffix by default)
+	OperationLabel string `json:"operationLabel,omitempty"`
+
+	// OperationTagKey - the tag key used by the tag label ("operation" by default)
+	OperationTagKey string `json:"operationTagKey,omitempty"`
+
+	// BucketSize - aligns the flattened timestamps and cycles to the wall clock
+	BucketSize funks.Duration `json:"bucketSize,omitempty"`
+
+	// AllowedLateness - keeps the buckets open after their end
+	AllowedLateness funks.Duration `json:"allowedLateness,omitempty"`
+
+	// LatePolicy - handles the points arriving after their bucket was closed (dropped by default)
+	LatePolicy string `json:"latePolicy,omitempty"`
+
+	// CorrectionWindow - retains the emitted buckets for the corrections (the bucket size by default)
+	CorrectionWindow funks.Duration `json:"correctionWindow,omitempty"`
+
+	// Rollups - aggregates the matching series across the removed tags
+	Rollups []FlattenRollup `json:"rollups,omitempty"`
+
+	isSHAKE bool
+}
+
+// FlattenRollup - flattens the points of a metric (an empty metric matches all) without the dropped tags or keeping only the kept tags (the kept tags take precedence), the original series are also flattened when configured
+type FlattenRollup struct {
+	Metric       string   `json:"metric,omitempty"`
+	DropTags     []string `json:"dropTags,omitempty"`
+	KeepTags     []string `json:"keepTags,omitempty"`
+	KeepOriginal bool     `json:"keepOriginal,omitempty"`
 }
 
 // DefaultTransportConfig - the default fields used by the transport configuration
@@ -35,12 +74,16 @@ type DefaultTransportConfig struct {
 	DebugOutput          bool           `json:"debugOutput,omitempty"`
 	TimeBetweenBatches   funks.Duration `json:"timeBetweenBatches,omitempty"`
 	PrintStackOnError    bool           `json:"printStackOnError,omitempty"`
+	DeduplicateBatch     bool           `json:"deduplicateBatch,omitempty"`
+	DeduplicationWindow  funks.Duration `json:"deduplicationWindow,omitempty"`
 }
 
-// CustomSerializerConfig - configures a customized serialization transport
+// CustomSerializerConfig - configures a customized serialization transport (the metric and tags properties are used to label the flattened operations, "metric" and "tags" by default)
 type CustomSerializerConfig struct {
 	TimestampProperty string `json:"timestampProperty,omitempty"`
 	ValueProperty     string `json:"valueProperty,omitempty"`
+	MetricProperty    string `json:"metricProperty,omitempty"`
+	TagsProperty      string `json:"tagsProperty,omitempty"`
 }
 
 // HTTPTransportConfig - has all http transport configurations
@@ -73,4 +116,187 @@ type UDPTransportConfig struct {
 	DefaultTransportConfig
 	TCPUDPTransportConfig
 	CustomSerializerConfig
+	MaxDatagramSize   int    `json:"maxDatagramSize,omitempty"`
+	DatagramSeparator string `json:"datagramSeparator,omitempty"`
+}
+
+// ValidationMode - defines what to do with an invalid point
+type ValidationMode string
+
+const (
+	// ValidationReject - invalid points are discarded and an error is returned
+	ValidationReject ValidationMode = "reject"
+
+	// ValidationSanitize - invalid points are fixed when possible
+	ValidationSanitize ValidationMode = "sanitize"
+
+	// ValidationLogAndPass - invalid points are logged and sent anyway
+	ValidationLogAndPass ValidationMode = "log"
+)
+
+// ValidationConfig - configures the point validation
+type ValidationConfig struct {
+	Mode              ValidationMode `json:"mode,omitempty"`
+	MaxTags           int            `json:"maxTags,omitempty"`
+	PrintStackOnError bool           `json:"printStackOnError,omitempty"`
+}
+
+// CardinalityAction - defines what to do with the points exceeding the series budget
+type CardinalityAction string
+
+const (
+	// CardinalityDrop - the point is discarded
+	CardinalityDrop CardinalityAction = "drop"
+
+	// CardinalityCollapse - the value of the tag with most distinct values is replaced by "__overflow__"
+	CardinalityCollapse CardinalityAction = "collapse"
+)
+
+// CardinalityEstimator - defines how the series are counted
+type CardinalityEstimator string
+
+const (
+	// CardinalityExact - stores the hash of each admitted series
+	CardinalityExact CardinalityEstimator = "exact"
+
+	// CardinalityHyperLogLog - estimates all series seen using a constant memory sketch per metric (the admitted series are stored as in the exact mode)
+	CardinalityHyperLogLog CardinalityEstimator = "hyperloglog"
+)
+
+// CardinalityLimiterConfig - configures the series cardinality limiter
+type CardinalityLimiterConfig struct {
+	MaxSeriesPerMetric int                  `json:"maxSeriesPerMetric,omitempty"`
+	MetricLimits       map[string]int       `json:"metricLimits,omitempty"`
+	Action             CardinalityAction    `json:"action,omitempty"`
+	Estimator          CardinalityEstimator `json:"estimator,omitempty"`
+	ResetInterval      funks.Duration       `json:"resetInterval,omitempty"`
+	TopOffenders       int                  `json:"topOffenders,omitempty"`
+}
+
+// SamplingStrategy - defines how the points are sampled
+type SamplingStrategy string
+
+const (
+	// SamplingRandom - keeps a random fraction of the points
+	SamplingRandom SamplingStrategy = "random"
+
+	// SamplingHash - keeps a fraction of the series (all points from a kept series are kept)
+	SamplingHash SamplingStrategy = "hash"
+
+	// SamplingFirstN - keeps the first N points from each series per interval
+	SamplingFirstN SamplingStrategy = "first"
+)
+
+// SamplingRule - the sampling of a metric (or json schema name), an empty metric matches the metrics with no rule
+type SamplingRule struct {
+	Metric    string           `json:"metric,omitempty"`
+	Strategy  SamplingStrategy `json:"strategy,omitempty"`
+	Rate      float64          `json:"rate,omitempty"`
+	MaxPoints int              `json:"maxPoints,omitempty"`
+	Interval  funks.Duration   `json:"interval,omitempty"`
+}
+
+// SamplerConfig - configures the point sampling
+type SamplerConfig struct {
+	Rules []SamplingRule `json:"rules,omitempty"`
+}
+
+// ChangeOnlyConfig - configures the change only emission
+type ChangeOnlyConfig struct {
+	HeartbeatInterval funks.Duration `json:"heartbeatInterval,omitempty"`
+	TTL               funks.Duration `json:"ttl,omitempty"`
+	Metrics           []string       `json:"metrics,omitempty"`
+}
+
+// InfluxTransportConfig - has all influxdb line protocol transport configurations
+type InfluxTransportConfig struct {
+	DefaultTransportConfig
+	TCPUDPTransportConfig
+	Protocol               string            `json:"protocol,omitempty"`
+	ServiceEndpoint        string            `json:"serviceEndpoint,omitempty"`
+	Database               string            `json:"database,omitempty"`
+	RetentionPolicy        string            `json:"retentionPolicy,omitempty"`
+	Precision              string            `json:"precision,omitempty"`
+	FieldKey               string            `json:"fieldKey,omitempty"`
+	ExpectedResponseStatus int               `json:"expectedResponseStatus,omitempty"`
+	Headers                map[string]string `json:"headers,omitempty"`
+}
+
+// GraphiteTransportConfig - has all graphite transport configurations
+type GraphiteTransportConfig struct {
+	DefaultTransportConfig
+	TCPUDPTransportConfig
+	Protocol        string `json:"protocol,omitempty"`
+	Tagged          bool   `json:"tagged,omitempty"`
+	MaxDatagramSize int    `json:"maxDatagramSize,omitempty"`
+}
+
+// PrometheusRemoteWriteConfig - has all prometheus remote write transport configurations
+type PrometheusRemoteWriteConfig struct {
+	DefaultTransportConfig
+	ServiceEndpoint  string            `json:"serviceEndpoint,omitempty"`
+	Headers          map[string]string `json:"headers,omitempty"`
+	MaxRetries       int               `json:"maxRetries,omitempty"`
+	MinBackoff       funks.Duration    `json:"minBackoff,omitempty"`
+	MaxBackoff       funks.Duration    `json:"maxBackoff,omitempty"`
+	RetryOnRateLimit bool              `json:"retryOnRateLimit,omitempty"`
+}
+
+// PrometheusExporterConfig - has all prometheus exporter configurations
+type PrometheusExporterConfig struct {
+	SeriesTTL         funks.Duration `json:"seriesTTL,omitempty"`
+	PrintStackOnError bool           `json:"printStackOnError,omitempty"`
+}
+
+// OTLPTransportConfig - has all opentelemetry otlp/http transport configurations
+type OTLPTransportConfig struct {
+	DefaultTransportConfig
+	ServiceEndpoint string            `json:"serviceEndpoint,omitempty"`
+	Encoding        string            `json:"encoding,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	GlobalTags      map[string]string `json:"globalTags,omitempty"`
+	ScopeName       string            `json:"scopeName,omitempty"`
+}
+
+// UnixTransportConfig - has all unix domain socket transport configurations
+type UnixTransportConfig struct {
+	DefaultTransportConfig
+	TCPUDPTransportConfig
+	Network         string         `json:"network,omitempty"`
+	SocketPath      string         `json:"socketPath,omitempty"`
+	ReadBufferSize  int            `json:"readBufferSize,omitempty"`
+	MaxReadTimeout  funks.Duration `json:"maxReadTimeout,omitempty"`
+	MaxDatagramSize int            `json:"maxDatagramSize,omitempty"`
+	CustomSerializerConfig
+}
+
+// FileTransportConfig - has all file transport configurations
+type FileTransportConfig struct {
+	DefaultTransportConfig
+	Path             string         `json:"path,omitempty"`
+	MaxFileSize      int64          `json:"maxFileSize,omitempty"`
+	RotationInterval funks.Duration `json:"rotationInterval,omitempty"`
+	Gzip             bool           `json:"gzip,omitempty"`
+	CustomSerializerConfig
+}
+
+// ElasticsearchTransportConfig - has all elasticsearch/opensearch bulk transport configurations
+type ElasticsearchTransportConfig struct {
+	DefaultTransportConfig
+	ServiceEndpoint string            `json:"serviceEndpoint,omitempty"`
+	IndexPattern    string            `json:"indexPattern,omitempty"`
+	Action          string            `json:"action,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	MaxRetries      int               `json:"maxRetries,omitempty"`
+	MinBackoff      funks.Duration    `json:"minBackoff,omitempty"`
+	MaxBackoff      funks.Duration    `json:"maxBackoff,omitempty"`
+	CustomSerializerConfig
+}
+
+// StatsDTransportConfig - has all statsd transport configurations
+type StatsDTransportConfig struct {
+	DefaultTransportConfig
+	TCPUDPTransportConfig
+	DogStatsD       bool `json:"dogStatsD,omitempty"`
+	MaxDatagramSize int  `json:"maxDatagramSize,omitempty"`
 }
@@ -0,0 +1,178 @@
+package timeline_elasticsearch_test
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/hashing"
+	jsonserializer "github.com/uol/serializer/json"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+const (
+	defaultTransportSize int    = 100
+	numberPoint          string = "numberJSON"
+)
+
+// bulkResponse - a response sent by the test backend
+type bulkResponse struct {
+	status int
+	body   string
+}
+
+// receivedRequest - a request received by the test backend
+type receivedRequest struct {
+	uri     string
+	headers http.Header
+	body    string
+}
+
+// testBackend - a bulk api backend responding in sequence (the last response is repeated)
+type testBackend struct {
+	server    *httptest.Server
+	requests  chan receivedRequest
+	responses []bulkResponse
+	calls     uint32
+}
+
+// createBackend - creates the bulk api backend
+func createBackend(t *testing.T, responses ...bulkResponse) *testBackend {
+
+	b := &testBackend{
+		requests:  make(chan receivedRequest, 20),
+		responses: responses,
+	}
+
+	b.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		b.requests <- receivedRequest{
+			uri:     r.RequestURI,
+			headers: r.Header,
+			body:    string(body),
+		}
+
+		call := int(atomic.AddUint32(&b.calls, 1)) - 1
+		if call >= len(b.responses) {
+			call = len(b.responses) - 1
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(b.responses[call].status)
+		w.Write([]byte(b.responses[call].body))
+	}))
+
+	return b
+}
+
+// backend - returns the timeline backend
+func (b *testBackend) backend(t *testing.T) *timeline.Backend {
+
+	host, port, err := net.SplitHostPort(b.server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &timeline.Backend{Host: host, Port: portNum}
+}
+
+// waitRequest - waits for the next request
+func (b *testBackend) waitRequest(timeout time.Duration) *receivedRequest {
+
+	select {
+	case r := <-b.requests:
+		return &r
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+// createElasticsearchConfig - creates the elasticsearch transport configuration
+func createElasticsearchConfig() *timeline.ElasticsearchTransportConfig {
+
+	return &timeline.ElasticsearchTransportConfig{
+		DefaultTransportConfig: timeline.DefaultTransportConfig{
+			BatchSendInterval:    funks.Duration{Duration: time.Second},
+			RequestTimeout:       funks.Duration{Duration: time.Second},
+			TransportBufferSize:  defaultTransportSize,
+			SerializerBufferSize: 1024,
+			TimeBetweenBatches:   funks.Duration{Duration: 10 * time.Millisecond},
+		},
+		IndexPattern: "timeline-{2006.01.02}",
+		MinBackoff:   funks.Duration{Duration: 10 * time.Millisecond},
+		MaxBackoff:   funks.Duration{Duration: 50 * time.Millisecond},
+		CustomSerializerConfig: timeline.CustomSerializerConfig{
+			TimestampProperty: "timestamp",
+			ValueProperty:     "value",
+		},
+	}
+}
+
+// createSerializer - creates the json serializer
+func createSerializer(t *testing.T) *jsonserializer.Serializer {
+
+	s := jsonserializer.New(256)
+
+	err := s.Add(numberPoint, jsonserializer.NumberPoint{}, "metric", "value", "timestamp", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// createElasticsearchManager - creates a manual mode manager using the elasticsearch transport
+func createElasticsearchManager(t *testing.T, conf *timeline.ElasticsearchTransportConfig, backend *timeline.Backend) *timeline.Manager {
+
+	transport, err := timeline.NewElasticsearchTransport(conf, createSerializer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dtc := &timeline.DataTransformerConfig{
+		CycleDuration:    funks.Duration{Duration: time.Hour},
+		HashingAlgorithm: hashing.SHA256,
+	}
+
+	manager, err := timeline.NewManager(transport, timeline.NewFlattener(dtc), timeline.NewAccumulator(dtc), backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = manager.Start(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager
+}
+
+// sendNumber - sends a number point
+func sendNumber(t *testing.T, m *timeline.Manager, metric string, value float64, timestamp int64) {
+
+	err := m.SendJSON(numberPoint, "metric", metric, "value", value, "timestamp", timestamp, "tags", map[string]string{"host": "h1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,196 @@
+package timeline_elasticsearch_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+const (
+	day1 int64 = 1600000000 // 2020-09-13 UTC
+	day2 int64 = 1600100000 // 2020-09-14 UTC
+)
+
+// TestBulk - tests the ndjson rendering and the date based index names
+func TestBulk(t *testing.T) {
+
+	b := createBackend(t, bulkResponse{status: http.StatusOK, body: `{"took":1,"errors":false,"items":[]}`})
+	defer b.server.Close()
+
+	m := createElasticsearchManager(t, createElasticsearchConfig(), b.backend(t))
+	defer m.Shutdown()
+
+	sendNumber(t, m, "cpu", 1, day1)
+	sendNumber(t, m, "mem", 2.5, day2)
+
+	assert.NoError(t, m.SendData(), "expected no error sending data")
+
+	r := b.waitRequest(5 * time.Second)
+	if !assert.NotNil(t, r, "expected a request") {
+		return
+	}
+
+	assert.Equal(t, "/_bulk", r.uri, "expected the default endpoint")
+	assert.Equal(t, "application/x-ndjson", r.headers.Get("Content-Type"), "expected the content type")
+
+	expected := `{"index":{"_index":"timeline-2020.09.13"}}` + "\n" +
+		`{"metric":"cpu","tags":{"host":"h1"},"timestamp":1600000000,"value":1.000000}` + "\n" +
+		`{"index":{"_index":"timeline-2020.09.14"}}` + "\n" +
+		`{"metric":"mem","tags":{"host":"h1"},"timestamp":1600100000,"value":2.500000}` + "\n"
+
+	assert.Equal(t, expected, r.body, "expected the bulk actions")
+}
+
+// TestBulkRetryFailedItems - tests if only the retryable failed items are sent again
+func TestBulkRetryFailedItems(t *testing.T) {
+
+	b := createBackend(t,
+		bulkResponse{
+			status: http.StatusOK,
+			body: `{"took":1,"errors":true,"items":[` +
+				`{"create":{"status":201}},` +
+				`{"create":{"status":429,"error":{"type":"es_rejected_execution_exception"}}},` +
+				`{"create":{"status":400,"error":{"type":"mapper_parsing_exception"}}}]}`,
+		},
+		bulkResponse{status: http.StatusOK, body: `{"took":1,"errors":false,"items":[{"create":{"status":201}}]}`},
+	)
+	defer b.server.Close()
+
+	conf := createElasticsearchConfig()
+	conf.Action = timeline.ElasticsearchCreate
+
+	m := createElasticsearchManager(t, conf, b.backend(t))
+	defer m.Shutdown()
+
+	sendNumber(t, m, "a", 1, day1)
+	sendNumber(t, m, "b", 2, day1)
+	sendNumber(t, m, "c", 3, day1)
+
+	assert.NoError(t, m.SendData(), "expected no error with the rejected document")
+	assert.Equal(t, uint64(1), m.GetTransportStats().RejectedPoints, "expected the rejected document to be counted")
+
+	r := b.waitRequest(time.Second)
+	if !assert.NotNil(t, r, "expected the first request") {
+		return
+	}
+
+	assert.Equal(t, 6, strings.Count(r.body, "\n"), "expected all actions in the first request")
+	assert.Contains(t, r.body, `{"create":{"_index":"timeline-2020.09.13"}}`, "expected the create action")
+
+	r = b.waitRequest(time.Second)
+	if !assert.NotNil(t, r, "expected the retry request") {
+		return
+	}
+
+	assert.Equal(t, 2, strings.Count(r.body, "\n"), "expected only one action in the retry")
+	assert.Contains(t, r.body, `"metric":"b"`, "expected only the rate limited document")
+
+	assert.Nil(t, b.waitRequest(200*time.Millisecond), "expected no more requests")
+}
+
+// TestBulkRetryRequest - tests if the whole request is retried on server errors
+func TestBulkRetryRequest(t *testing.T) {
+
+	b := createBackend(t,
+		bulkResponse{status: http.StatusServiceUnavailable},
+		bulkResponse{status: http.StatusOK, body: `{"took":1,"errors":false,"items":[]}`},
+	)
+	defer b.server.Close()
+
+	m := createElasticsearchManager(t, createElasticsearchConfig(), b.backend(t))
+	defer m.Shutdown()
+
+	sendNumber(t, m, "a", 1, day1)
+	sendNumber(t, m, "b", 2, day1)
+
+	assert.NoError(t, m.SendData(), "expected success after the retry")
+
+	first := b.waitRequest(time.Second)
+	second := b.waitRequest(time.Second)
+
+	if assert.NotNil(t, first, "expected the first request") && assert.NotNil(t, second, "expected the retry request") {
+		assert.Equal(t, first.body, second.body, "expected the same payload")
+	}
+}
+
+// TestBulkRetriesExhausted - tests if an error is returned when retryable items are still pending after the retries
+func TestBulkRetriesExhausted(t *testing.T) {
+
+	b := createBackend(t, bulkResponse{status: http.StatusServiceUnavailable})
+	defer b.server.Close()
+
+	conf := createElasticsearchConfig()
+	conf.MaxRetries = 2
+
+	m := createElasticsearchManager(t, conf, b.backend(t))
+	defer m.Shutdown()
+
+	sendNumber(t, m, "a", 1, day1)
+
+	err := m.SendData()
+	assert.True(t, errors.Is(err, timeline.ErrBulkItemsFailed), "expected the pending items error: %v", err)
+	assert.Equal(t, uint64(0), m.GetTransportStats().RejectedPoints, "expected no rejected documents")
+
+	for i := 0; i < 3; i++ {
+		assert.NotNil(t, b.waitRequest(time.Second), "expected the request and the retries")
+	}
+
+	assert.Nil(t, b.waitRequest(200*time.Millisecond), "expected no more retries")
+}
+
+// TestBulkNoRetry - tests if the client errors are not retried and returned
+func TestBulkNoRetry(t *testing.T) {
+
+	for _, status := range []int{http.StatusUnauthorized, http.StatusNotFound} {
+
+		b := createBackend(t, bulkResponse{status: status, body: `{"error":"request failed"}`})
+
+		m := createElasticsearchManager(t, createElasticsearchConfig(), b.backend(t))
+
+		sendNumber(t, m, "a", 1, day1)
+
+		assert.Error(t, m.SendData(), "expected the request error with status %d", status)
+		assert.Equal(t, uint64(0), m.GetTransportStats().RejectedPoints, "expected no rejected documents with status %d", status)
+		assert.NotNil(t, b.waitRequest(time.Second), "expected one request")
+		assert.Nil(t, b.waitRequest(200*time.Millisecond), "expected no retries")
+
+		m.Shutdown()
+		b.server.Close()
+	}
+}
+
+// TestElasticsearchConfig - tests the configuration validation
+func TestElasticsearchConfig(t *testing.T) {
+
+	s := createSerializer(t)
+
+	conf := createElasticsearchConfig()
+	conf.IndexPattern = ""
+	_, err := timeline.NewElasticsearchTransport(conf, s)
+	assert.Error(t, err, "expected error without index pattern")
+
+	conf = createElasticsearchConfig()
+	conf.IndexPattern = "timeline-{"
+	_, err = timeline.NewElasticsearchTransport(conf, s)
+	assert.Error(t, err, "expected error with an unclosed date layout")
+
+	conf = createElasticsearchConfig()
+	conf.Action = "update"
+	_, err = timeline.NewElasticsearchTransport(conf, s)
+	assert.Error(t, err, "expected error with an invalid action")
+
+	conf = createElasticsearchConfig()
+	conf.IndexPattern = "static"
+	_, err = timeline.NewElasticsearchTransport(conf, s)
+	assert.NoError(t, err, "expected no error with a static index name")
+}
@@ -0,0 +1,138 @@
+package timeline_file_test
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/hashing"
+	jsonserializer "github.com/uol/serializer/json"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+const (
+	defaultTransportSize int    = 100
+	numberPoint          string = "numberJSON"
+)
+
+// createFileConfig - creates the file transport configuration
+func createFileConfig(path string) *timeline.FileTransportConfig {
+
+	return &timeline.FileTransportConfig{
+		DefaultTransportConfig: timeline.DefaultTransportConfig{
+			BatchSendInterval:    funks.Duration{Duration: time.Second},
+			RequestTimeout:       funks.Duration{Duration: time.Second},
+			TransportBufferSize:  defaultTransportSize,
+			SerializerBufferSize: 256,
+		},
+		Path: path,
+		CustomSerializerConfig: timeline.CustomSerializerConfig{
+			TimestampProperty: "timestamp",
+			ValueProperty:     "value",
+		},
+	}
+}
+
+// createJSONSerializer - creates the json serializer
+func createJSONSerializer(t *testing.T) *jsonserializer.Serializer {
+
+	s := jsonserializer.New(256)
+
+	err := s.Add(numberPoint, jsonserializer.NumberPoint{}, "metric", "value", "timestamp", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// createManager - creates a manual mode manager using the transport
+func createManager(t *testing.T, transport timeline.Transport) *timeline.Manager {
+
+	dtc := &timeline.DataTransformerConfig{
+		CycleDuration:    funks.Duration{Duration: time.Hour},
+		HashingAlgorithm: hashing.SHA256,
+	}
+
+	manager, err := timeline.NewManager(transport, timeline.NewFlattener(dtc), timeline.NewAccumulator(dtc), &timeline.Backend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = manager.Start(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager
+}
+
+// createTempDir - creates a temporary directory
+func createTempDir(t *testing.T) string {
+
+	dir, err := ioutil.TempDir("", "timeline-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+// listFiles - lists the file names sorted
+func listFiles(t *testing.T, dir string) []string {
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// readFile - reads the file content (decompressing gzip files)
+func readFile(t *testing.T, path string) string {
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	if filepath.Ext(path) != ".gz" {
+		content, err := ioutil.ReadAll(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(content)
+}
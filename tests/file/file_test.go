@@ -0,0 +1,194 @@
+package timeline_file_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// TestWriterOpenTSDB - tests the opentsdb lines written to a writer
+func TestWriterOpenTSDB(t *testing.T) {
+
+	buffer := &bytes.Buffer{}
+
+	transport, err := timeline.NewWriterTransport(createFileConfig(""), buffer, nil)
+	if !assert.NoError(t, err, "expected no error creating the transport") {
+		return
+	}
+
+	m := createManager(t, transport)
+	defer m.Shutdown()
+
+	assert.NoError(t, m.SendOpenTSDB(1, 10, "cpu", "host", "h1"))
+	assert.NoError(t, m.SendOpenTSDB(2.5, 20, "mem", "host", "h2"))
+	assert.NoError(t, m.SendData(), "expected no error sending data")
+
+	assert.Equal(t, "put cpu 10 1 host=h1\nput mem 20 2.5 host=h2\n", buffer.String(), "expected the opentsdb lines")
+}
+
+// TestWriterJSONGzip - tests the json batches written to a gzip compressed writer
+func TestWriterJSONGzip(t *testing.T) {
+
+	buffer := &bytes.Buffer{}
+
+	conf := createFileConfig("")
+	conf.Gzip = true
+
+	transport, err := timeline.NewWriterTransport(conf, buffer, createJSONSerializer(t))
+	if !assert.NoError(t, err, "expected no error creating the transport") {
+		return
+	}
+
+	m := createManager(t, transport)
+
+	for _, metric := range []string{"a", "b"} {
+		err := m.SendJSON(numberPoint, "metric", metric, "value", 1.0, "timestamp", int64(10), "tags", map[string]string{"host": "h1"})
+		assert.NoError(t, err, "expected no error sending json")
+		assert.NoError(t, m.SendData(), "expected no error sending data")
+	}
+
+	m.Shutdown()
+	transport.Close()
+
+	reader, err := gzip.NewReader(buffer)
+	if !assert.NoError(t, err, "expected a gzip stream") {
+		return
+	}
+
+	content, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err, "expected no error decompressing")
+
+	expected := `[{"metric":"a","tags":{"host":"h1"},"timestamp":10,"value":1.000000}]` + "\n" +
+		`[{"metric":"b","tags":{"host":"h1"},"timestamp":10,"value":1.000000}]` + "\n"
+
+	assert.Equal(t, expected, string(content), "expected one json array per batch")
+}
+
+// TestFileSizeRotation - tests the rotation by size using gzip
+func TestFileSizeRotation(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	conf := createFileConfig(filepath.Join(dir, "points.log"))
+	conf.MaxFileSize = 10
+	conf.Gzip = true
+
+	transport, err := timeline.NewFileTransport(conf, nil)
+	if !assert.NoError(t, err, "expected no error creating the transport") {
+		return
+	}
+
+	m := createManager(t, transport)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, m.SendOpenTSDB(float64(i), 10, "cpu"))
+		assert.NoError(t, m.SendData(), "expected no error sending data")
+	}
+
+	m.Shutdown()
+	transport.Close()
+
+	files := listFiles(t, dir)
+	if !assert.Len(t, files, 3, "expected two rotated files and the current one") {
+		return
+	}
+
+	assert.Equal(t, "points.log.gz", files[2], "expected the gzip extension in the current file")
+
+	contents := []string{}
+	for _, f := range files {
+		assert.True(t, strings.HasSuffix(f, ".gz"), "expected gzip files")
+		contents = append(contents, readFile(t, filepath.Join(dir, f)))
+	}
+
+	assert.Equal(t, []string{"put cpu 10 0 \n", "put cpu 10 1 \n", "put cpu 10 2 \n"}, contents, "expected one batch per file")
+}
+
+// TestFileSizeRotationCompressed - tests the maximum size is compared with the compressed size
+func TestFileSizeRotationCompressed(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	conf := createFileConfig(filepath.Join(dir, "points.log"))
+	conf.MaxFileSize = 200
+	conf.Gzip = true
+
+	transport, err := timeline.NewFileTransport(conf, nil)
+	if !assert.NoError(t, err, "expected no error creating the transport") {
+		return
+	}
+
+	m := createManager(t, transport)
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, m.SendOpenTSDB(1, 10, "cpu", "host", strings.Repeat("h", 100)))
+		assert.NoError(t, m.SendData(), "expected no error sending data")
+	}
+
+	m.Shutdown()
+	transport.Close()
+
+	files := listFiles(t, dir)
+	if !assert.True(t, len(files) > 1, "expected some rotated files") {
+		return
+	}
+
+	for _, f := range files[:len(files)-1] {
+
+		info, err := os.Stat(filepath.Join(dir, f))
+		if assert.NoError(t, err) {
+			assert.True(t, info.Size() >= conf.MaxFileSize, "expected the rotation after the compressed size reached the maximum: %d", info.Size())
+		}
+	}
+}
+
+// TestFileTimeRotation - tests the rotation by time
+func TestFileTimeRotation(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	conf := createFileConfig(filepath.Join(dir, "points.log"))
+	conf.RotationInterval.Duration = 100 * time.Millisecond
+
+	transport, err := timeline.NewFileTransport(conf, nil)
+	if !assert.NoError(t, err, "expected no error creating the transport") {
+		return
+	}
+
+	m := createManager(t, transport)
+	defer m.Shutdown()
+
+	assert.NoError(t, m.SendOpenTSDB(1, 10, "cpu"))
+	assert.NoError(t, m.SendData(), "expected no error sending data")
+	assert.NoError(t, m.SendOpenTSDB(2, 10, "cpu"))
+	assert.NoError(t, m.SendData(), "expected no error sending data")
+
+	assert.Len(t, listFiles(t, dir), 1, "expected no rotation before the interval")
+
+	<-time.After(150 * time.Millisecond)
+
+	assert.NoError(t, m.SendOpenTSDB(3, 10, "cpu"))
+	assert.NoError(t, m.SendData(), "expected no error sending data")
+
+	files := listFiles(t, dir)
+	if assert.Len(t, files, 2, "expected the rotated file") {
+		assert.Equal(t, "put cpu 10 1 \nput cpu 10 2 \n", readFile(t, filepath.Join(dir, files[1])), "expected the first batches in the rotated file")
+		assert.Equal(t, "put cpu 10 3 \n", readFile(t, filepath.Join(dir, files[0])), "expected the last batch in the current file")
+	}
+}
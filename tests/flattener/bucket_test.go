@@ -0,0 +1,149 @@
+package timeline_flattener_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// createBucketConfig - creates the data transformer configuration using aligned buckets
+func createBucketConfig(bucketSize time.Duration) *timeline.DataTransformerConfig {
+
+	dtc := createDataTransformerConfig()
+	dtc.BucketSize = funks.Duration{Duration: bucketSize}
+
+	return dtc
+}
+
+// TestAlignedBuckets - tests the values grouped by aligned bucket
+func TestAlignedBuckets(t *testing.T) {
+
+	m, output := createManager(t, createBucketConfig(2*time.Second))
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+	start := now - now%2 + 2
+
+	flattenTimed(t, m, timeline.Sum, "requests", []int64{start + 1, start + 2, start, start + 3, start - 1}, []float64{1, 10, 2, 20, 100})
+	flattenTimed(t, m, timeline.Rate, "rate", []int64{start + 2, start + 3}, []float64{30, 20})
+	flattenTimed(t, m, timeline.Last, "gauge", []int64{start + 1, start}, []float64{7, 8})
+
+	<-time.After(time.Until(time.Unix(start+4, 0)) + 10*time.Millisecond)
+
+	values := processCycle(t, m, output)
+
+	assert.Equal(t, map[string]float64{
+		fmt.Sprintf("requests %d [host a]", start-2): 100,
+		fmt.Sprintf("requests %d [host a]", start):   3,
+		fmt.Sprintf("requests %d [host a]", start+2): 30,
+		fmt.Sprintf("rate %d [host a]", start+2):     25,
+		fmt.Sprintf("gauge %d [host a]", start):      8,
+	}, values)
+}
+
+// TestOpenBucket - tests the bucket is only emitted after it is closed
+func TestOpenBucket(t *testing.T) {
+
+	m, output := createManager(t, createBucketConfig(time.Second))
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	flattenTimed(t, m, timeline.Max, "max", []int64{now, now}, []float64{1, 2})
+
+	values := processCycle(t, m, output)
+	assert.Len(t, values, 0, "the bucket is still open")
+
+	<-time.After(time.Until(time.Unix(now+1, 0)) + 10*time.Millisecond)
+
+	values = processCycle(t, m, output)
+	assert.Equal(t, map[string]float64{fmt.Sprintf("max %d [host a]", now): 2}, values)
+}
+
+// TestEmittedBucket - tests the points of an emitted bucket are dropped without a late policy
+func TestEmittedBucket(t *testing.T) {
+
+	f := timeline.NewFlattener(createBucketConfig(time.Second))
+	m, output := createFlattenerManager(t, f)
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	flattenTimed(t, m, timeline.Sum, "sum", []int64{now, now}, []float64{1, 2})
+
+	<-time.After(time.Until(time.Unix(now+1, 0)) + 10*time.Millisecond)
+
+	values := processCycle(t, m, output)
+	assert.Equal(t, map[string]float64{fmt.Sprintf("sum %d [host a]", now): 3}, values)
+
+	flattenTimed(t, m, timeline.Sum, "sum", []int64{now}, []float64{10})
+
+	values = processCycle(t, m, output)
+	assert.Len(t, values, 0, "expected no second point for the emitted bucket")
+	assert.Equal(t, timeline.FlattenerStats{LateDropped: 1}, f.GetStats())
+}
+
+// TestBucketConcurrentCycle - tests no point is lost when the cycle emits the bucket while the points are added
+func TestBucketConcurrentCycle(t *testing.T) {
+
+	f := timeline.NewFlattener(createBucketConfig(time.Second))
+	m, output := createFlattenerManager(t, f)
+	defer m.Shutdown()
+
+	done := make(chan struct{})
+	added := 0
+
+	go func() {
+		defer close(done)
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if err := m.FlattenOpenTSDB(timeline.Count, 1, time.Now().Unix(), "count", "host", "a"); err != nil {
+				t.Error(err)
+				return
+			}
+			added++
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+		default:
+			m.ProcessCycle()
+			continue
+		}
+		break
+	}
+
+	<-time.After(time.Until(time.Unix(time.Now().Unix()+1, 0)) + 10*time.Millisecond)
+
+	m.ProcessCycle()
+
+	if !assert.NoError(t, m.SendData()) {
+		return
+	}
+
+	emitted := 0.0
+
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+
+		item, err := timeline.ParseOpenTSDBLine(line)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		emitted += item.Value
+	}
+
+	assert.Equal(t, added, int(emitted)+int(f.GetStats().LateDropped), "expected all points emitted or counted")
+}
@@ -0,0 +1,139 @@
+package timeline_flattener_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/hashing"
+	jsonserializer "github.com/uol/serializer/json"
+	"github.com/uol/serializer/serializer"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+const (
+	defaultTransportSize int = 1000
+)
+
+// createDataTransformerConfig - creates the default data transformer configuration
+func createDataTransformerConfig() *timeline.DataTransformerConfig {
+
+	return &timeline.DataTransformerConfig{
+		CycleDuration:    funks.Duration{Duration: time.Hour},
+		HashingAlgorithm: hashing.SHA256,
+	}
+}
+
+// createManager - creates a manual mode manager writing the opentsdb lines to the returned buffer
+func createManager(t *testing.T, dtc *timeline.DataTransformerConfig) (*timeline.Manager, *bytes.Buffer) {
+
+	return createFlattenerManager(t, timeline.NewFlattener(dtc))
+}
+
+// createFlattenerManager - creates a manual mode manager using the flattener and writing the opentsdb lines to the returned buffer
+func createFlattenerManager(t *testing.T, flattener *timeline.Flattener) (*timeline.Manager, *bytes.Buffer) {
+
+	return createWriterManager(t, flattener, nil)
+}
+
+// createJSONManager - creates a manual mode manager writing the json points to the returned buffer
+func createJSONManager(t *testing.T, dtc *timeline.DataTransformerConfig) (*timeline.Manager, *bytes.Buffer) {
+
+	s := jsonserializer.New(256)
+
+	err := s.Add("point", jsonserializer.NumberPoint{}, "metric", "value", "timestamp", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return createWriterManager(t, timeline.NewFlattener(dtc), s)
+}
+
+// createWriterManager - creates a manual mode manager using the flattener and writing the points to the returned buffer (opentsdb lines without a custom serializer)
+func createWriterManager(t *testing.T, flattener *timeline.Flattener, customSerializer serializer.Serializer) (*timeline.Manager, *bytes.Buffer) {
+
+	output := &bytes.Buffer{}
+
+	conf := &timeline.FileTransportConfig{
+		DefaultTransportConfig: timeline.DefaultTransportConfig{
+			BatchSendInterval:    funks.Duration{Duration: time.Second},
+			RequestTimeout:       funks.Duration{Duration: time.Second},
+			TransportBufferSize:  defaultTransportSize,
+			SerializerBufferSize: 256,
+		},
+	}
+
+	if customSerializer != nil {
+		conf.CustomSerializerConfig = timeline.CustomSerializerConfig{
+			TimestampProperty: "timestamp",
+			ValueProperty:     "value",
+		}
+	}
+
+	transport, err := timeline.NewWriterTransport(conf, output, customSerializer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := timeline.NewManager(transport, flattener, nil, &timeline.Backend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = manager.Start(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager, output
+}
+
+// flatten - flattens all values using the operation
+func flatten(t *testing.T, m *timeline.Manager, operation timeline.FlatOperation, metric string, timestamp int64, values ...float64) {
+
+	for _, v := range values {
+		err := m.FlattenOpenTSDB(operation, v, timestamp, metric, "host", "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// processCycle - processes the cycle and returns the sent values by series ("metric timestamp tags")
+func processCycle(t *testing.T, m *timeline.Manager, output *bytes.Buffer) map[string]float64 {
+
+	m.ProcessCycle()
+
+	err := m.SendData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]float64{}
+
+	for _, line := range strings.Split(output.String(), "\n") {
+
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		item, err := timeline.ParseOpenTSDBLine(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		values[fmt.Sprintf("%s %d %v", item.Metric, item.Timestamp, item.Tags)] = item.Value
+	}
+
+	output.Reset()
+
+	return values
+}
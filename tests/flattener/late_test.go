@@ -0,0 +1,205 @@
+package timeline_flattener_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// createLateConfig - creates the data transformer configuration using aligned buckets and the late policy
+func createLateConfig(bucketSize, allowedLateness time.Duration, latePolicy string) *timeline.DataTransformerConfig {
+
+	dtc := createBucketConfig(bucketSize)
+	dtc.AllowedLateness = funks.Duration{Duration: allowedLateness}
+	dtc.LatePolicy = latePolicy
+
+	return dtc
+}
+
+// TestAllowedLateness - tests the buckets are kept open by the allowed lateness
+func TestAllowedLateness(t *testing.T) {
+
+	m, output := createManager(t, createLateConfig(10*time.Second, time.Hour, timeline.LateDrop))
+	defer m.Shutdown()
+
+	old := time.Now().Unix() - 100
+
+	flattenTimed(t, m, timeline.Sum, "sum", []int64{old, old}, []float64{1, 2})
+
+	values := processCycle(t, m, output)
+	assert.Len(t, values, 0, "the bucket is still open")
+}
+
+// TestLateDrop - tests the late points are discarded
+func TestLateDrop(t *testing.T) {
+
+	f := timeline.NewFlattener(createLateConfig(10*time.Second, 0, timeline.LateDrop))
+	m, output := createFlattenerManager(t, f)
+	defer m.Shutdown()
+
+	old := time.Now().Unix() - 100
+
+	flattenTimed(t, m, timeline.Sum, "sum", []int64{old, old}, []float64{1, 2})
+
+	values := processCycle(t, m, output)
+	assert.Len(t, values, 0, "the late points must be dropped")
+	assert.Equal(t, timeline.FlattenerStats{LateDropped: 2}, f.GetStats())
+}
+
+// TestLateCorrection - tests the emitted buckets are emitted again with the late points
+func TestLateCorrection(t *testing.T) {
+
+	dtc := createLateConfig(time.Second, 0, timeline.LateCorrection)
+	dtc.CorrectionWindow = funks.Duration{Duration: time.Hour}
+
+	f := timeline.NewFlattener(dtc)
+	m, output := createFlattenerManager(t, f)
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	flattenTimed(t, m, timeline.Sum, "sum", []int64{now, now}, []float64{1, 2})
+
+	<-time.After(time.Until(time.Unix(now+1, 0)) + 10*time.Millisecond)
+
+	values := processCycle(t, m, output)
+	assert.Equal(t, map[string]float64{fmt.Sprintf("sum %d [host a]", now): 3}, values)
+
+	values = processCycle(t, m, output)
+	assert.Len(t, values, 0, "the emitted bucket must not be emitted again without late points")
+
+	flattenTimed(t, m, timeline.Sum, "sum", []int64{now}, []float64{10})
+	flattenTimed(t, m, timeline.Sum, "sum", []int64{now - 100}, []float64{100})
+
+	values = processCycle(t, m, output)
+	assert.Equal(t, map[string]float64{fmt.Sprintf("sum %d [host a]", now): 13}, values, "the correction has all values")
+	assert.Equal(t, timeline.FlattenerStats{LateCorrected: 1, LateExpired: 1}, f.GetStats(), "the point of a bucket not retained must be counted as expired")
+}
+
+// TestLateMerge - tests the late points are added to the current bucket
+func TestLateMerge(t *testing.T) {
+
+	f := timeline.NewFlattener(createLateConfig(time.Second, 0, timeline.LateMerge))
+	m, output := createFlattenerManager(t, f)
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	flattenTimed(t, m, timeline.Sum, "sum", []int64{now - 100, now - 50}, []float64{1, 2})
+
+	<-time.After(time.Until(time.Unix(time.Now().Unix()+1, 0)) + 10*time.Millisecond)
+
+	values := processCycle(t, m, output)
+	assert.Len(t, values, 1)
+	for key, value := range values {
+		assert.NotEqual(t, fmt.Sprintf("sum %d [host a]", now-100), key, "the late point must be merged into the current bucket")
+		assert.Equal(t, float64(3), value)
+	}
+	assert.Equal(t, timeline.FlattenerStats{LateMerged: 2}, f.GetStats())
+}
+
+// TestLateCorrectionBuffered - tests the correction does not change the emitted point still buffered by the transport
+func TestLateCorrectionBuffered(t *testing.T) {
+
+	dtc := createLateConfig(time.Second, 0, timeline.LateCorrection)
+	dtc.CorrectionWindow = funks.Duration{Duration: time.Hour}
+
+	m, output := createManager(t, dtc)
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	flattenTimed(t, m, timeline.Sum, "sum", []int64{now, now}, []float64{1, 2})
+
+	<-time.After(time.Until(time.Unix(now+1, 0)) + 10*time.Millisecond)
+
+	m.ProcessCycle()
+
+	flattenTimed(t, m, timeline.Sum, "sum", []int64{now}, []float64{10})
+
+	m.ProcessCycle()
+
+	if !assert.NoError(t, m.SendData()) {
+		return
+	}
+
+	values := []float64{}
+
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+
+		item, err := timeline.ParseOpenTSDBLine(line)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, now, item.Timestamp, "expected the bucket timestamp")
+		values = append(values, item.Value)
+	}
+
+	assert.Equal(t, []float64{3, 13}, values, "expected the emission and the correction")
+}
+
+// TestLateCorrectionJSON - tests the correction using json points
+func TestLateCorrectionJSON(t *testing.T) {
+
+	dtc := createLateConfig(time.Second, 0, timeline.LateCorrection)
+	dtc.CorrectionWindow = funks.Duration{Duration: time.Hour}
+
+	m, output := createJSONManager(t, dtc)
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	flattenJSON := func(v float64) {
+		err := m.FlattenJSON(timeline.Sum, "point", "metric", "sum", "value", v, "timestamp", now, "tags", map[string]string{"host": "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	flattenJSON(1)
+	flattenJSON(2)
+
+	<-time.After(time.Until(time.Unix(now+1, 0)) + 10*time.Millisecond)
+
+	m.ProcessCycle()
+
+	flattenJSON(10)
+
+	m.ProcessCycle()
+
+	if !assert.NoError(t, m.SendData()) {
+		return
+	}
+
+	expected := fmt.Sprintf(`[{"metric":"sum","tags":{"host":"a"},"timestamp":%d,"value":3.000000},`+
+		`{"metric":"sum","tags":{"host":"a"},"timestamp":%d,"value":13.000000}]`+"\n", now, now)
+
+	assert.Equal(t, expected, output.String(), "expected the emission and the correction")
+}
+
+// TestLatePolicyConfig - tests the invalid late policy and operation label are rejected by the manager
+func TestLatePolicyConfig(t *testing.T) {
+
+	m, _ := createManager(t, createDataTransformerConfig())
+	defer m.Shutdown()
+
+	dtc := createLateConfig(time.Second, 0, "corection")
+	_, err := timeline.NewManager(m.GetTransport(), timeline.NewFlattener(dtc), nil, &timeline.Backend{})
+	assert.Error(t, err, "expected error with an invalid late policy")
+
+	dtc = createDataTransformerConfig()
+	dtc.OperationLabel = "prefix"
+	_, err = timeline.NewManager(m.GetTransport(), timeline.NewFlattener(dtc), nil, &timeline.Backend{})
+	assert.Error(t, err, "expected error with an invalid operation label")
+}
@@ -0,0 +1,126 @@
+package timeline_flattener_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// flattenMultiple - flattens all values using the operations
+func flattenMultiple(t *testing.T, m *timeline.Manager, operations []timeline.FlatOperation, metric string, timestamp int64, values ...float64) {
+
+	for _, v := range values {
+		err := m.FlattenOpenTSDBMultiple(operations, v, timestamp, metric, "host", "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestMultipleOperationsSuffix - tests multiple operations labeled by metric suffix
+func TestMultipleOperationsSuffix(t *testing.T) {
+
+	m, output := createManager(t, createDataTransformerConfig())
+	defer m.Shutdown()
+
+	p999, err := timeline.QuantileOperation(0.999)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	flattenMultiple(t, m, []timeline.FlatOperation{timeline.Avg, timeline.Max, timeline.P50, p999}, "latency", 10, 10, 20, 30, 40)
+	flatten(t, m, timeline.Avg, "latency", 10, 100)
+
+	values := processCycle(t, m, output)
+
+	assert.Len(t, values, 5)
+	assert.Equal(t, 25.0, values["latency.avg 10 [host a]"])
+	assert.Equal(t, 40.0, values["latency.max 10 [host a]"])
+	assert.Equal(t, 25.0, values["latency.p50 10 [host a]"])
+	assert.InDelta(t, 39.97, values["latency.p99.9 10 [host a]"], 1e-9)
+	assert.Equal(t, 100.0, values["latency 10 [host a]"], "the single operation must not share the values")
+}
+
+// TestMultipleOperationsTag - tests multiple operations labeled by tag
+func TestMultipleOperationsTag(t *testing.T) {
+
+	dtc := createDataTransformerConfig()
+	dtc.OperationLabel = timeline.OperationLabelTag
+	dtc.OperationTagKey = "agg"
+
+	m, output := createManager(t, dtc)
+	defer m.Shutdown()
+
+	flattenMultiple(t, m, []timeline.FlatOperation{timeline.Min, timeline.Count}, "latency", 10, 10, 20, 30)
+
+	values := processCycle(t, m, output)
+
+	assert.Len(t, values, 2)
+	assert.Equal(t, 10.0, values["latency 10 [host a agg min]"])
+	assert.Equal(t, 3.0, values["latency 10 [host a agg count]"])
+}
+
+// TestMultipleOperationsErrors - tests the invalid operation sets
+func TestMultipleOperationsErrors(t *testing.T) {
+
+	m, _ := createManager(t, createDataTransformerConfig())
+	defer m.Shutdown()
+
+	err := m.FlattenOpenTSDBMultiple(nil, 1, 10, "latency")
+	assert.Error(t, err, "expected an error with no operations")
+
+	err = m.FlattenOpenTSDBMultiple([]timeline.FlatOperation{timeline.Avg, timeline.Avg}, 1, 10, "latency")
+	assert.Error(t, err, "expected an error with duplicated operations")
+}
+
+// TestMultipleOperationsJSON - tests multiple operations using json points
+func TestMultipleOperationsJSON(t *testing.T) {
+
+	for _, label := range []string{timeline.OperationLabelSuffix, timeline.OperationLabelTag} {
+
+		dtc := createDataTransformerConfig()
+		dtc.OperationLabel = label
+
+		m, output := createJSONManager(t, dtc)
+
+		for _, v := range []float64{1, 2, 6} {
+			err := m.FlattenJSONMultiple([]timeline.FlatOperation{timeline.Sum, timeline.Max}, "point", "metric", "requests", "value", v, "timestamp", int64(10), "tags", map[string]string{"host": "a"})
+			if !assert.NoError(t, err) {
+				return
+			}
+		}
+
+		m.ProcessCycle()
+
+		if !assert.NoError(t, m.SendData()) {
+			return
+		}
+
+		m.Shutdown()
+
+		points := []map[string]interface{}{}
+		if !assert.NoError(t, json.Unmarshal(output.Bytes(), &points)) {
+			return
+		}
+
+		values := map[string]float64{}
+		for _, p := range points {
+			tags := p["tags"].(map[string]interface{})
+			values[fmt.Sprintf("%s %v", p["metric"], tags["operation"])] = p["value"].(float64)
+		}
+
+		if label == timeline.OperationLabelSuffix {
+			assert.Equal(t, map[string]float64{"requests.sum <nil>": 9, "requests.max <nil>": 6}, values)
+		} else {
+			assert.Equal(t, map[string]float64{"requests sum": 9, "requests max": 6}, values)
+		}
+	}
+}
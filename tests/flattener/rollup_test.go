@@ -0,0 +1,113 @@
+package timeline_flattener_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// flattenTagged - flattens the value using the operation and the tags
+func flattenTagged(t *testing.T, m *timeline.Manager, operation timeline.FlatOperation, metric string, value float64, tags ...interface{}) {
+
+	err := m.FlattenOpenTSDB(operation, value, 10, metric, tags...)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRollupDropTags - tests the series aggregated across the dropped tag
+func TestRollupDropTags(t *testing.T) {
+
+	dtc := createDataTransformerConfig()
+	dtc.Rollups = []timeline.FlattenRollup{
+		{Metric: "http.latency", DropTags: []string{"host"}},
+	}
+
+	m, output := createManager(t, dtc)
+	defer m.Shutdown()
+
+	flattenTagged(t, m, timeline.Sum, "http.latency", 1, "host", "a", "endpoint", "/x")
+	flattenTagged(t, m, timeline.Sum, "http.latency", 2, "host", "b", "endpoint", "/x")
+	flattenTagged(t, m, timeline.Sum, "http.latency", 4, "host", "a", "endpoint", "/y")
+	flattenTagged(t, m, timeline.Sum, "http.latency", 8, "endpoint", "/z")
+	flattenTagged(t, m, timeline.Sum, "cpu", 16, "host", "a")
+
+	values := processCycle(t, m, output)
+
+	assert.Equal(t, map[string]float64{
+		"http.latency 10 [endpoint /x]": 3,
+		"http.latency 10 [endpoint /y]": 4,
+		"http.latency 10 [endpoint /z]": 8,
+		"cpu 10 [host a]":               16,
+	}, values)
+}
+
+// TestRollupKeepTagsAndOriginal - tests the series aggregated keeping only some tags and sent with the original ones
+func TestRollupKeepTagsAndOriginal(t *testing.T) {
+
+	dtc := createDataTransformerConfig()
+	dtc.Rollups = []timeline.FlattenRollup{
+		{Metric: "http.latency", KeepTags: []string{"endpoint"}, KeepOriginal: true},
+		{KeepTags: []string{"dc"}},
+	}
+
+	m, output := createManager(t, dtc)
+	defer m.Shutdown()
+
+	flattenTagged(t, m, timeline.Max, "http.latency", 1, "host", "a", "endpoint", "/x", "dc", "1")
+	flattenTagged(t, m, timeline.Max, "http.latency", 2, "host", "b", "endpoint", "/x", "dc", "1")
+
+	values := processCycle(t, m, output)
+
+	assert.Equal(t, map[string]float64{
+		"http.latency 10 [endpoint /x]":             2,
+		"http.latency 10 [dc 1]":                    2,
+		"http.latency 10 [host a endpoint /x dc 1]": 1,
+		"http.latency 10 [host b endpoint /x dc 1]": 2,
+	}, values)
+}
+
+// TestRollupJSON - tests the tags removed from the tags property of the json points
+func TestRollupJSON(t *testing.T) {
+
+	dtc := createDataTransformerConfig()
+	dtc.Rollups = []timeline.FlattenRollup{
+		{Metric: "http.latency", DropTags: []string{"host"}},
+	}
+
+	m, output := createJSONManager(t, dtc)
+	defer m.Shutdown()
+
+	for i, host := range []string{"a", "b", "c"} {
+		err := m.FlattenJSON(timeline.Avg, "point", "metric", "http.latency", "value", float64(i+1), "timestamp", int64(10), "tags", map[string]string{"host": host, "endpoint": "/x"})
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	m.ProcessCycle()
+
+	if !assert.NoError(t, m.SendData()) {
+		return
+	}
+
+	points := []map[string]interface{}{}
+	if !assert.NoError(t, json.Unmarshal(output.Bytes(), &points)) {
+		return
+	}
+
+	if assert.Len(t, points, 1) {
+		assert.Equal(t, "http.latency", points[0]["metric"])
+		assert.Equal(t, map[string]interface{}{"endpoint": "/x"}, points[0]["tags"])
+		assert.Equal(t, float64(2), points[0]["value"])
+		assert.Equal(t, "10", fmt.Sprint(points[0]["timestamp"]))
+	}
+}
@@ -0,0 +1,140 @@
+package timeline_flattener_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+	jsonserializer "github.com/uol/serializer/json"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// flattenTimed - flattens the values using a timestamp for each one
+func flattenTimed(t *testing.T, m *timeline.Manager, operation timeline.FlatOperation, metric string, timestamps []int64, values []float64) {
+
+	for i := range values {
+		err := m.FlattenOpenTSDB(operation, values[i], timestamps[i], metric, "host", "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestFirstLastArrivalOrder - tests the first and last operations using the arrival order
+func TestFirstLastArrivalOrder(t *testing.T) {
+
+	m, output := createManager(t, createDataTransformerConfig())
+	defer m.Shutdown()
+
+	flattenTimed(t, m, timeline.First, "first", []int64{20, 10, 30}, []float64{2, 1, 3})
+	flattenTimed(t, m, timeline.Last, "last", []int64{20, 30, 10}, []float64{2, 3, 1})
+
+	values := processCycle(t, m, output)
+
+	assert.Len(t, values, 2)
+	assert.Equal(t, 2.0, values["first 20 [host a]"])
+	assert.Equal(t, 1.0, values["last 10 [host a]"])
+}
+
+// TestFirstLastByTimestamp - tests the first and last operations using the timestamp order
+func TestFirstLastByTimestamp(t *testing.T) {
+
+	dtc := createDataTransformerConfig()
+	dtc.FirstLastByTimestamp = true
+
+	m, output := createManager(t, dtc)
+	defer m.Shutdown()
+
+	flattenTimed(t, m, timeline.First, "first", []int64{20, 10, 30}, []float64{2, 1, 3})
+	flattenTimed(t, m, timeline.Last, "last", []int64{20, 30, 10}, []float64{2, 3, 1})
+
+	values := processCycle(t, m, output)
+
+	assert.Len(t, values, 2)
+	assert.Equal(t, 1.0, values["first 10 [host a]"])
+	assert.Equal(t, 3.0, values["last 30 [host a]"])
+}
+
+// TestRangeAndRate - tests the range and rate operations
+func TestRangeAndRate(t *testing.T) {
+
+	dtc := createDataTransformerConfig()
+	dtc.CycleDuration = funks.Duration{Duration: 10 * time.Second}
+
+	m, output := createManager(t, dtc)
+	defer m.Shutdown()
+
+	flatten(t, m, timeline.Range, "range", 10, 7, -3, 12, 5)
+	flatten(t, m, timeline.Rate, "rate", 10, 10, 20, 30, 40)
+
+	values := processCycle(t, m, output)
+
+	assert.Equal(t, 15.0, values["range 10 [host a]"])
+	assert.Equal(t, 10.0, values["rate 10 [host a]"])
+}
+
+// TestLastJSON - tests the last operation using json points
+func TestLastJSON(t *testing.T) {
+
+	s := jsonserializer.New(256)
+
+	err := s.Add("point", jsonserializer.NumberPoint{}, "metric", "value", "timestamp", "tags")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	output := &bytes.Buffer{}
+
+	transport, err := timeline.NewWriterTransport(
+		&timeline.FileTransportConfig{
+			DefaultTransportConfig: timeline.DefaultTransportConfig{
+				BatchSendInterval:    funks.Duration{Duration: time.Second},
+				RequestTimeout:       funks.Duration{Duration: time.Second},
+				TransportBufferSize:  defaultTransportSize,
+				SerializerBufferSize: 256,
+			},
+			CustomSerializerConfig: timeline.CustomSerializerConfig{
+				TimestampProperty: "timestamp",
+				ValueProperty:     "value",
+			},
+		},
+		output,
+		s,
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	m, err := timeline.NewManager(transport, timeline.NewFlattener(createDataTransformerConfig()), nil, &timeline.Backend{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if !assert.NoError(t, m.Start(true)) {
+		return
+	}
+
+	defer m.Shutdown()
+
+	for i, v := range []float64{5, 8, 2} {
+		err = m.FlattenJSON(timeline.Last, "point", "metric", "gauge", "value", v, "timestamp", int64(100+i), "tags", map[string]string{"host": "a"})
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	m.ProcessCycle()
+
+	if !assert.NoError(t, m.SendData()) {
+		return
+	}
+
+	assert.Equal(t, `[{"metric":"gauge","tags":{"host":"a"},"timestamp":102,"value":2.000000}]`+"\n", output.String())
+}
@@ -0,0 +1,123 @@
+package timeline_flattener_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+var latencies = []float64{15, 20, 35, 40, 50, 10, 5, 45, 30, 25}
+
+// TestQuantiles - tests the median and the percentile operations
+func TestQuantiles(t *testing.T) {
+
+	m, output := createManager(t, createDataTransformerConfig())
+	defer m.Shutdown()
+
+	p75, err := timeline.QuantileOperation(0.75)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	again, err := timeline.QuantileOperation(0.75)
+	if !assert.NoError(t, err) || !assert.Equal(t, p75, again, "expected the same registered operation") {
+		return
+	}
+
+	flatten(t, m, timeline.Median, "median", 10, latencies...)
+	flatten(t, m, timeline.P50, "p50", 10, latencies...)
+	flatten(t, m, timeline.P90, "p90", 10, latencies...)
+	flatten(t, m, timeline.P99, "p99", 10, latencies...)
+	flatten(t, m, p75, "p75", 10, latencies...)
+
+	values := processCycle(t, m, output)
+
+	assert.Equal(t, 27.5, values["median 10 [host a]"])
+	assert.Equal(t, 27.5, values["p50 10 [host a]"])
+	assert.InDelta(t, 45.5, values["p90 10 [host a]"], 1e-9)
+	assert.InDelta(t, 49.55, values["p99 10 [host a]"], 1e-9)
+	assert.InDelta(t, 38.75, values["p75 10 [host a]"], 1e-9)
+
+	_, err = timeline.QuantileOperation(1.5)
+	assert.Error(t, err, "expected an invalid quantile")
+}
+
+// TestStdDevAndVariance - tests the population standard deviation and variance operations
+func TestStdDevAndVariance(t *testing.T) {
+
+	m, output := createManager(t, createDataTransformerConfig())
+	defer m.Shutdown()
+
+	flatten(t, m, timeline.Variance, "variance", 10, 2, 4, 4, 4, 5, 5, 7, 9)
+	flatten(t, m, timeline.StdDev, "stddev", 10, 2, 4, 4, 4, 5, 5, 7, 9)
+
+	values := processCycle(t, m, output)
+
+	assert.Equal(t, 4.0, values["variance 10 [host a]"])
+	assert.Equal(t, 2.0, values["stddev 10 [host a]"])
+}
+
+// TestQuantileSketch - tests the quantiles using the streaming sketch
+func TestQuantileSketch(t *testing.T) {
+
+	dtc := createDataTransformerConfig()
+	dtc.QuantileSketch = true
+	dtc.SketchRelativeAccuracy = 0.01
+
+	m, output := createManager(t, dtc)
+	defer m.Shutdown()
+
+	values := make([]float64, 10000)
+	for i := range values {
+		values[i] = float64(i + 1)
+	}
+
+	flatten(t, m, timeline.Median, "median", 10, values...)
+	flatten(t, m, timeline.P99, "p99", 10, values...)
+	flatten(t, m, timeline.P90, "negative", 10, -1, -2, -3, -4, -5, -6, -7, -8, -9, -10)
+
+	results := processCycle(t, m, output)
+
+	assert.True(t, math.Abs(results["median 10 [host a]"]-5000) <= 5000*0.01, "median out of the relative accuracy: %f", results["median 10 [host a]"])
+	assert.True(t, math.Abs(results["p99 10 [host a]"]-9900) <= 9900*0.01, "p99 out of the relative accuracy: %f", results["p99 10 [host a]"])
+	assert.True(t, math.Abs(results["negative 10 [host a]"]+2) <= 2*0.01, "p90 out of the relative accuracy: %f", results["negative 10 [host a]"])
+}
+
+// TestQuantileOperationExhausted - tests the custom quantile ids are allocated in order until they are exhausted (registers all ids, keep it as the last quantile test)
+func TestQuantileOperationExhausted(t *testing.T) {
+
+	p75, err := timeline.QuantileOperation(0.75)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	last := p75
+	registered := 0
+
+	for i := 1; i <= 256; i++ {
+
+		operation, err := timeline.QuantileOperation(float64(i) / 1000)
+		if err != nil {
+			assert.Equal(t, timeline.ErrNoCustomQuantiles, err, "expected the exhausted ids error")
+			break
+		}
+
+		assert.True(t, operation > last, "expected the ids allocated in order: %d after %d", operation, last)
+		last = operation
+		registered++
+	}
+
+	assert.True(t, registered > 0 && registered < 128, "expected the ids to be exhausted: %d", registered)
+	assert.Equal(t, timeline.FlatOperation(255), last, "expected the last id to be used")
+
+	again, err := timeline.QuantileOperation(0.75)
+	assert.NoError(t, err, "expected the registered quantile after the exhaustion")
+	assert.Equal(t, p75, again, "expected the same registered operation")
+}
@@ -0,0 +1,78 @@
+package timeline_flattener_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// TestConcurrentRunningAggregates - tests the running aggregates updated by concurrent goroutines
+func TestConcurrentRunningAggregates(t *testing.T) {
+
+	m, output := createManager(t, createDataTransformerConfig())
+	defer m.Shutdown()
+
+	operations := []timeline.FlatOperation{timeline.Sum, timeline.Count, timeline.Min, timeline.Max, timeline.Avg, timeline.Range}
+
+	numGoroutines := 8
+	numValues := 1000
+
+	wg := sync.WaitGroup{}
+	wg.Add(numGoroutines)
+
+	for g := 0; g < numGoroutines; g++ {
+		go func(g int) {
+			defer wg.Done()
+			for i := 1; i <= numValues; i++ {
+				err := m.FlattenOpenTSDBMultiple(operations, float64(i), 10, "requests", "host", "a")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(g)
+	}
+
+	wg.Wait()
+
+	values := processCycle(t, m, output)
+
+	total := float64(numGoroutines * numValues)
+	sum := float64(numGoroutines) * float64(numValues*(numValues+1)/2)
+
+	assert.Equal(t, sum, values["requests.sum 10 [host a]"])
+	assert.Equal(t, total, values["requests.count 10 [host a]"])
+	assert.Equal(t, 1.0, values["requests.min 10 [host a]"])
+	assert.Equal(t, float64(numValues), values["requests.max 10 [host a]"])
+	assert.Equal(t, sum/total, values["requests.avg 10 [host a]"])
+	assert.Equal(t, float64(numValues-1), values["requests.range 10 [host a]"])
+}
+
+// TestStreamingAfterCycle - tests the aggregates are restarted after each cycle
+func TestStreamingAfterCycle(t *testing.T) {
+
+	m, output := createManager(t, createDataTransformerConfig())
+	defer m.Shutdown()
+
+	flatten(t, m, timeline.Max, "max", 10, 5, 50, 7)
+	flatten(t, m, timeline.StdDev, "stddev", 10, 1, 1, 1)
+
+	values := processCycle(t, m, output)
+
+	assert.Equal(t, 50.0, values["max 10 [host a]"])
+	assert.Equal(t, 0.0, values["stddev 10 [host a]"])
+
+	flatten(t, m, timeline.Max, "max", 20, 3, 1)
+
+	values = processCycle(t, m, output)
+
+	assert.Len(t, values, 1)
+	assert.Equal(t, 3.0, values["max 20 [host a]"])
+}
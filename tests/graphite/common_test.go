@@ -0,0 +1,82 @@
+package timeline_graphite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/gotest/tcpudp"
+	"github.com/uol/hashing"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+var (
+	defaultConf tcpudp.TCPConfiguration = tcpudp.TCPConfiguration{
+		ServerConfiguration: tcpudp.ServerConfiguration{
+			Host:               "localhost",
+			MessageChannelSize: 10,
+			ReadBufferSize:     65536,
+		},
+		ReadTimeout: time.Second,
+	}
+)
+
+const (
+	defaultTransportSize int = 100
+)
+
+// createGraphiteTransportConfig - creates the graphite transport configuration
+func createGraphiteTransportConfig(protocol string, tagged bool) *timeline.GraphiteTransportConfig {
+
+	return &timeline.GraphiteTransportConfig{
+		DefaultTransportConfig: timeline.DefaultTransportConfig{
+			BatchSendInterval:    funks.Duration{Duration: time.Second},
+			RequestTimeout:       funks.Duration{Duration: time.Second},
+			TransportBufferSize:  defaultTransportSize,
+			SerializerBufferSize: 1024,
+			TimeBetweenBatches:   funks.Duration{Duration: 10 * time.Millisecond},
+		},
+		TCPUDPTransportConfig: timeline.TCPUDPTransportConfig{
+			ReconnectionTimeout:    funks.Duration{Duration: time.Second},
+			MaxReconnectionRetries: 3,
+		},
+		Protocol: protocol,
+		Tagged:   tagged,
+	}
+}
+
+// createGraphiteManager - creates a manual mode manager using the graphite transport
+func createGraphiteManager(t *testing.T, conf *timeline.GraphiteTransportConfig, port int) *timeline.Manager {
+
+	transport, err := timeline.NewGraphiteTransport(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dtc := &timeline.DataTransformerConfig{
+		CycleDuration:    funks.Duration{Duration: time.Hour},
+		HashingAlgorithm: hashing.SHA256,
+	}
+
+	manager, err := timeline.NewManager(
+		transport,
+		timeline.NewFlattener(dtc),
+		timeline.NewAccumulator(dtc),
+		&timeline.Backend{Host: defaultConf.Host, Port: port},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = manager.Start(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager
+}
@@ -0,0 +1,178 @@
+package timeline_graphite_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gotest/tcpudp"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// splitLines - splits the payload lines
+func splitLines(payload string) []string {
+
+	return strings.Split(strings.TrimSpace(payload), "\n")
+}
+
+// receiveTCPLines - receives the next tcp message and splits its lines
+func receiveTCPLines(t *testing.T, s *tcpudp.TCPServer) []string {
+
+	select {
+	case message := <-s.MessageChannel():
+		return splitLines(message.Message)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "expected a message")
+		return nil
+	}
+}
+
+// TestGraphitePlaintext - tests the plaintext protocol without tags
+func TestGraphitePlaintext(t *testing.T) {
+
+	s, port := tcpudp.NewTCPServer(&defaultConf, true)
+	defer s.Stop()
+
+	m := createGraphiteManager(t, createGraphiteTransportConfig(timeline.GraphiteTCP, false), port)
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	assert.NoError(t, m.SendGraphite(1.5, now, "servers.h1.cpu", "host", "h1"))
+	assert.NoError(t, m.SendGraphite(-3, now, "servers.h1.disk usage"))
+
+	m.SendData()
+
+	expected := []string{
+		fmt.Sprintf("servers.h1.cpu 1.5 %d", now),
+		fmt.Sprintf("servers.h1.disk_usage -3 %d", now),
+	}
+
+	assert.Equal(t, expected, receiveTCPLines(t, s), "expected the plaintext lines")
+}
+
+// TestGraphiteTagged - tests the graphite 1.1 tagged metrics
+func TestGraphiteTagged(t *testing.T) {
+
+	s, port := tcpudp.NewTCPServer(&defaultConf, true)
+	defer s.Stop()
+
+	m := createGraphiteManager(t, createGraphiteTransportConfig(timeline.GraphiteTCP, true), port)
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	assert.NoError(t, m.SendGraphite(10, now, "cpu", "host", "h1", "dc", "us;west", "empty", ""))
+	assert.NoError(t, m.SendGraphite(20, now, "mem", "a=b", "~c"))
+
+	m.SendData()
+
+	expected := []string{
+		fmt.Sprintf("cpu;dc=us_west;host=h1 10 %d", now),
+		fmt.Sprintf("mem;a_b=c 20 %d", now),
+	}
+
+	assert.Equal(t, expected, receiveTCPLines(t, s), "expected the tagged lines")
+}
+
+// TestGraphiteFlattenAndAccumulate - tests the data processors using the graphite transport
+func TestGraphiteFlattenAndAccumulate(t *testing.T) {
+
+	s, port := tcpudp.NewTCPServer(&defaultConf, true)
+	defer s.Stop()
+
+	m := createGraphiteManager(t, createGraphiteTransportConfig(timeline.GraphiteTCP, true), port)
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	for _, v := range []float64{2, 4, 6} {
+		assert.NoError(t, m.FlattenGraphite(timeline.Avg, v, now, "latency", "host", "h1"))
+	}
+
+	hash, err := m.StoreDataToAccumulateOpenTSDB(time.Minute, 0, now, "hits", "host", "h1")
+	if !assert.NoError(t, err, "expected no error storing data") {
+		return
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, m.IncrementAccumulatedData(hash))
+	}
+
+	m.ProcessCycle()
+	m.SendData()
+
+	lines := receiveTCPLines(t, s)
+	if !assert.Len(t, lines, 2, "expected the flattened and accumulated points") {
+		return
+	}
+
+	sort.Strings(lines)
+
+	assert.True(t, strings.HasPrefix(lines[0], "hits;host=h1 3 "), "expected the accumulated value: %s", lines[0])
+	assert.Equal(t, fmt.Sprintf("latency;host=h1 4 %d", now), lines[1], "expected the average value")
+}
+
+// TestGraphiteUDP - tests the points packed in datagrams
+func TestGraphiteUDP(t *testing.T) {
+
+	s, port := tcpudp.NewUDPServer(&defaultConf.ServerConfiguration, true)
+	defer s.Stop()
+
+	conf := createGraphiteTransportConfig(timeline.GraphiteUDP, false)
+	conf.MaxDatagramSize = 64
+
+	m := createGraphiteManager(t, conf, port)
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+	expected := []string{}
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, m.SendGraphite(float64(i), now, fmt.Sprintf("servers.h%d.requests", i)))
+		expected = append(expected, fmt.Sprintf("servers.h%d.requests %d %d", i, i, now))
+	}
+
+	m.SendData()
+
+	received := []string{}
+
+	for len(received) < len(expected) {
+
+		select {
+		case message := <-s.MessageChannel():
+			assert.LessOrEqual(t, len(message.Message), conf.MaxDatagramSize, "expected packets inside the maximum size")
+			received = append(received, splitLines(message.Message)...)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "expected all points")
+			return
+		}
+	}
+
+	sort.Strings(received)
+
+	assert.Equal(t, expected, received, "expected all points")
+}
+
+// TestGraphiteConfig - tests the configuration errors
+func TestGraphiteConfig(t *testing.T) {
+
+	_, err := timeline.NewGraphiteTransport(nil)
+	assert.Error(t, err, "expected error on null configuration")
+
+	_, err = timeline.NewGraphiteTransport(createGraphiteTransportConfig("http", false))
+	assert.Error(t, err, "expected error on invalid protocol")
+
+	conf := createGraphiteTransportConfig(timeline.GraphiteUDP, false)
+	conf.MaxDatagramSize = -1
+	_, err = timeline.NewGraphiteTransport(conf)
+	assert.Error(t, err, "expected error on invalid datagram size")
+}
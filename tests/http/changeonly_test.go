@@ -0,0 +1,135 @@
+package timeline_http_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+	gotesthttp "github.com/uol/gotest/http"
+	jsonserializer "github.com/uol/serializer/json"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// sendAndReceiveValues - sends the number points and returns the received values
+func sendAndReceiveValues(t *testing.T, s *gotesthttp.Server, m *timeline.Manager, values ...float64) []float64 {
+
+	number := newNumberPoint(0)
+
+	for i, v := range values {
+		number.Value = v
+		number.Timestamp = time.Now().Unix() + int64(i)
+		err := m.SendJSON(numberPoint, toGenericParameters(number)...)
+		if !assert.NoError(t, err, "expected no error sending json") {
+			return nil
+		}
+	}
+
+	m.SendData()
+
+	requestData := gotesthttp.WaitForServerRequest(s, time.Millisecond, 5*time.Second)
+	if !assert.NotNil(t, requestData, "expected a request") {
+		return nil
+	}
+
+	var actual []jsonserializer.NumberPoint
+	err := json.Unmarshal([]byte(requestData.Body), &actual)
+	if !assert.NoError(t, err, "expected no error unmarshalling json") {
+		return nil
+	}
+
+	received := make([]float64, len(actual))
+	for i, p := range actual {
+		received[i] = p.Value
+	}
+
+	return received
+}
+
+// TestChangeOnly - tests the suppression of unchanged values and the heartbeat
+func TestChangeOnly(t *testing.T) {
+
+	s := createTimeseriesBackend()
+	defer s.Close()
+
+	m := createTimelineManager(false, true, defaultTransportSize, time.Second, applicationJSON, nil)
+
+	filter, err := timeline.NewChangeOnlyFilter(&timeline.ChangeOnlyConfig{
+		HeartbeatInterval: funks.Duration{Duration: 500 * time.Millisecond},
+		TTL:               funks.Duration{Duration: time.Minute},
+	})
+	if !assert.NoError(t, err, "expected no error creating the filter") {
+		return
+	}
+	defer filter.Stop()
+
+	assert.NoError(t, m.AddPointFilter(filter), "expected the filter added")
+
+	err = m.Start(true)
+	if !assert.NoError(t, err, "expected no error starting the manager") {
+		return
+	}
+	defer m.Shutdown()
+
+	received := sendAndReceiveValues(t, s, m, 1, 1, 2, 2, 2, 1)
+	assert.Equal(t, []float64{1, 2, 1}, received, "expected only the changed values")
+
+	<-time.After(600 * time.Millisecond)
+
+	received = sendAndReceiveValues(t, s, m, 1, 1, 3)
+	assert.Equal(t, []float64{1, 3}, received, "expected the heartbeat and the changed value")
+
+	stats := filter.GetStats()
+	assert.Equal(t, uint64(4), stats.SuppressedPoints, "expected suppressed points")
+	assert.Equal(t, uint64(1), stats.Heartbeats, "expected one heartbeat")
+}
+
+// TestChangeOnlyTTL - tests if the series state expires
+func TestChangeOnlyTTL(t *testing.T) {
+
+	filter, err := timeline.NewChangeOnlyFilter(&timeline.ChangeOnlyConfig{
+		HeartbeatInterval: funks.Duration{Duration: time.Hour},
+		TTL:               funks.Duration{Duration: 200 * time.Millisecond},
+		Metrics:           []string{"gauge"},
+	})
+	if !assert.NoError(t, err, "expected no error creating the filter") {
+		return
+	}
+	defer filter.Stop()
+
+	point := func(metric string) *timeline.SeriesPoint {
+		return &timeline.SeriesPoint{
+			Metric: metric,
+			Tags:   []interface{}{"host", "h1"},
+			Value:  1,
+		}
+	}
+
+	assert.True(t, filter.Filter(point("gauge")), "expected the first point")
+	assert.False(t, filter.Filter(point("gauge")), "expected the repeated point to be suppressed")
+	assert.True(t, filter.Filter(point("other")), "expected metrics not configured to be kept")
+	assert.True(t, filter.Filter(point("other")), "expected metrics not configured to be kept")
+
+	<-time.After(700 * time.Millisecond)
+
+	assert.True(t, filter.Filter(point("gauge")), "expected the point to be sent after the state expiration")
+}
+
+// TestChangeOnlyConfig - tests the configuration errors
+func TestChangeOnlyConfig(t *testing.T) {
+
+	_, err := timeline.NewChangeOnlyFilter(nil)
+	assert.Error(t, err, "expected error on null configuration")
+
+	_, err = timeline.NewChangeOnlyFilter(&timeline.ChangeOnlyConfig{TTL: funks.Duration{Duration: time.Second}})
+	assert.Error(t, err, "expected error on no heartbeat interval")
+
+	_, err = timeline.NewChangeOnlyFilter(&timeline.ChangeOnlyConfig{HeartbeatInterval: funks.Duration{Duration: time.Second}})
+	assert.Error(t, err, "expected error on no ttl")
+}
@@ -0,0 +1,148 @@
+package timeline_influx_test
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/gotest/tcpudp"
+	"github.com/uol/hashing"
+	serializer "github.com/uol/serializer/opentsdb"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+var (
+	defaultConf tcpudp.ServerConfiguration = tcpudp.ServerConfiguration{
+		Host:               "localhost",
+		MessageChannelSize: 100,
+		ReadBufferSize:     65536,
+	}
+)
+
+const (
+	defaultTransportSize int    = 100
+	testDatabase         string = "test_db"
+)
+
+// receivedRequest - a request received by the test backend
+type receivedRequest struct {
+	uri  string
+	body string
+}
+
+// createInfluxBackend - creates a http backend returning the received requests
+func createInfluxBackend(t *testing.T) (*httptest.Server, chan receivedRequest) {
+
+	requests := make(chan receivedRequest, 10)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		requests <- receivedRequest{
+			uri:  r.RequestURI,
+			body: string(body),
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	return s, requests
+}
+
+// backendFromURL - extracts the host and port from the test server
+func backendFromURL(t *testing.T, s *httptest.Server) *timeline.Backend {
+
+	host, port, err := net.SplitHostPort(s.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &timeline.Backend{
+		Host: host,
+		Port: portNum,
+	}
+}
+
+// createInfluxTransportConfig - creates the influx transport configuration
+func createInfluxTransportConfig(protocol string) *timeline.InfluxTransportConfig {
+
+	return &timeline.InfluxTransportConfig{
+		DefaultTransportConfig: timeline.DefaultTransportConfig{
+			BatchSendInterval:    funks.Duration{Duration: time.Second},
+			RequestTimeout:       funks.Duration{Duration: time.Second},
+			TransportBufferSize:  defaultTransportSize,
+			SerializerBufferSize: 1024,
+			TimeBetweenBatches:   funks.Duration{Duration: 10 * time.Millisecond},
+		},
+		TCPUDPTransportConfig: timeline.TCPUDPTransportConfig{
+			ReconnectionTimeout:    funks.Duration{Duration: time.Second},
+			MaxReconnectionRetries: 3,
+		},
+		Protocol: protocol,
+		Database: testDatabase,
+	}
+}
+
+// createInfluxManager - creates a manual mode manager using the influx transport
+func createInfluxManager(t *testing.T, conf *timeline.InfluxTransportConfig, backend *timeline.Backend, withProcessors bool) *timeline.Manager {
+
+	transport, err := timeline.NewInfluxTransport(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var flattener, accumulator timeline.DataProcessor
+
+	if withProcessors {
+
+		dtc := &timeline.DataTransformerConfig{
+			CycleDuration:    funks.Duration{Duration: time.Hour},
+			HashingAlgorithm: hashing.SHA256,
+		}
+
+		flattener = timeline.NewFlattener(dtc)
+		accumulator = timeline.NewAccumulator(dtc)
+	}
+
+	manager, err := timeline.NewManager(transport, flattener, accumulator, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = manager.Start(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager
+}
+
+// newItem - creates a new item with the specified value
+func newItem(value float64) *serializer.ArrayItem {
+
+	return &serializer.ArrayItem{
+		Metric:    "metric",
+		Timestamp: time.Now().Unix(),
+		Value:     value,
+		Tags:      []interface{}{"host", "h1"},
+	}
+}
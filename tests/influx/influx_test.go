@@ -0,0 +1,197 @@
+package timeline_influx_test
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gotest/tcpudp"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// waitRequest - waits for the next request received by the backend
+func waitRequest(t *testing.T, requests chan receivedRequest) *receivedRequest {
+
+	select {
+	case r := <-requests:
+		return &r
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "expected a request")
+		return nil
+	}
+}
+
+// splitLines - splits the payload lines
+func splitLines(payload string) []string {
+
+	return strings.Split(strings.TrimSpace(payload), "\n")
+}
+
+// TestInfluxHTTP - tests the points sent using the http write endpoint
+func TestInfluxHTTP(t *testing.T) {
+
+	s, requests := createInfluxBackend(t)
+	defer s.Close()
+
+	conf := createInfluxTransportConfig(timeline.InfluxHTTP)
+	conf.Precision = "ms"
+	conf.RetentionPolicy = "one_week"
+
+	m := createInfluxManager(t, conf, backendFromURL(t, s), false)
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	assert.NoError(t, m.SendInflux(1.5, now, "cpu", "host", "h1", "region", "us west"))
+	assert.NoError(t, m.SendInflux(-2, now, "disk,usage", "path", "/a=b", "empty", ""))
+	assert.NoError(t, m.SendInflux(10, now, "mem", "z", "1", "a", "2"))
+
+	m.SendData()
+
+	r := waitRequest(t, requests)
+	if r == nil {
+		return
+	}
+
+	assert.Equal(t, "/write?db=test_db&precision=ms&rp=one_week", r.uri, "expected the write endpoint")
+
+	expected := []string{
+		fmt.Sprintf(`cpu,host=h1,region=us\ west value=1.5 %d`, now*1000),
+		fmt.Sprintf(`disk\,usage,path=/a\=b value=-2 %d`, now*1000),
+		fmt.Sprintf(`mem,a=2,z=1 value=10 %d`, now*1000),
+	}
+
+	assert.Equal(t, expected, splitLines(r.body), "expected the line protocol points")
+}
+
+// TestInfluxUDP - tests the points sent one per datagram
+func TestInfluxUDP(t *testing.T) {
+
+	s, port := tcpudp.NewUDPServer(&defaultConf, true)
+	defer s.Stop()
+
+	conf := createInfluxTransportConfig(timeline.InfluxUDP)
+	conf.FieldKey = "count"
+
+	m := createInfluxManager(t, conf, &timeline.Backend{Host: defaultConf.Host, Port: port}, false)
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+	expected := []string{}
+
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, m.SendInflux(float64(i), now, "requests", "host", fmt.Sprintf("h%d", i)))
+		expected = append(expected, fmt.Sprintf("requests,host=h%d count=%d %d", i, i, now))
+	}
+
+	m.SendData()
+
+	received := []string{}
+	numPackets := 0
+
+	for len(received) < len(expected) {
+
+		select {
+		case message := <-s.MessageChannel():
+			numPackets++
+			received = append(received, splitLines(message.Message)...)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "expected all points")
+			return
+		}
+	}
+
+	sort.Strings(received)
+
+	assert.Equal(t, expected, received, "expected all points")
+	assert.Equal(t, len(expected), numPackets, "expected one packet per point")
+}
+
+// TestInfluxFlattenAndAccumulate - tests the data processors using the influx transport
+func TestInfluxFlattenAndAccumulate(t *testing.T) {
+
+	s, requests := createInfluxBackend(t)
+	defer s.Close()
+
+	m := createInfluxManager(t, createInfluxTransportConfig(timeline.InfluxHTTP), backendFromURL(t, s), true)
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	for _, v := range []float64{1, 5, 3} {
+		assert.NoError(t, m.FlattenInflux(timeline.Max, v, now, "latency", "host", "h1"))
+	}
+
+	hash, err := m.StoreDataToAccumulateOpenTSDB(time.Minute, 0, now, "hits", "host", "h1")
+	if !assert.NoError(t, err, "expected no error storing data") {
+		return
+	}
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, m.IncrementAccumulatedData(hash))
+	}
+
+	m.ProcessCycle()
+	m.SendData()
+
+	r := waitRequest(t, requests)
+	if r == nil {
+		return
+	}
+
+	lines := splitLines(r.body)
+	if !assert.Len(t, lines, 2, "expected the flattened and accumulated points") {
+		return
+	}
+
+	sort.Strings(lines)
+
+	assert.Equal(t, "hits,host=h1 value=4", lines[0][:strings.LastIndex(lines[0], " ")], "expected the accumulated value")
+	assert.Equal(t, fmt.Sprintf("latency,host=h1 value=5 %d", now), lines[1], "expected the maximum value")
+}
+
+// TestInfluxInvalidValues - tests the values not supported by the line protocol
+func TestInfluxInvalidValues(t *testing.T) {
+
+	transport, err := timeline.NewInfluxTransport(createInfluxTransportConfig(timeline.InfluxHTTP))
+	if !assert.NoError(t, err, "expected no error creating the transport") {
+		return
+	}
+
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, err := transport.SerializePayload([]interface{}{newItem(v)})
+		assert.Errorf(t, err, "expected error serializing %f", v)
+	}
+}
+
+// TestInfluxConfig - tests the configuration errors
+func TestInfluxConfig(t *testing.T) {
+
+	conf := createInfluxTransportConfig(timeline.InfluxHTTP)
+	conf.Database = ""
+	_, err := timeline.NewInfluxTransport(conf)
+	assert.Error(t, err, "expected error on no database")
+
+	conf = createInfluxTransportConfig(timeline.InfluxHTTP)
+	conf.Precision = "h"
+	_, err = timeline.NewInfluxTransport(conf)
+	assert.Error(t, err, "expected error on invalid precision")
+
+	conf = createInfluxTransportConfig("tcp")
+	_, err = timeline.NewInfluxTransport(conf)
+	assert.Error(t, err, "expected error on invalid protocol")
+
+	conf = createInfluxTransportConfig(timeline.InfluxUDP)
+	conf.Database = ""
+	_, err = timeline.NewInfluxTransport(conf)
+	assert.NoError(t, err, "expected no database required by the udp protocol")
+}
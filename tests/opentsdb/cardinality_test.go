@@ -0,0 +1,187 @@
+package timeline_opentsdb_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gotest/tcpudp"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// createLimitedManager - creates a manual mode manager using a cardinality limiter
+func createLimitedManager(t *testing.T, port int, conf *timeline.CardinalityLimiterConfig) (*timeline.Manager, *timeline.CardinalityLimiter) {
+
+	m := createTimelineManager(false, true, port, defaultTransportSize, time.Second)
+
+	limiter, err := timeline.NewCardinalityLimiter(conf)
+	if !assert.NoError(t, err, "expected no error creating the limiter") {
+		return nil, nil
+	}
+
+	assert.NoError(t, m.AddPointFilter(limiter), "expected the limiter added")
+
+	err = m.Start(true)
+	if !assert.NoError(t, err, "expected no error starting the manager") {
+		return nil, nil
+	}
+
+	return m, limiter
+}
+
+// receiveLines - receives the next message and splits its lines
+func receiveLines(s *tcpudp.TCPServer) []string {
+
+	message := <-s.MessageChannel()
+
+	return strings.Split(strings.TrimSpace(message.Message), "\n")
+}
+
+// sendRequestIDs - sends one point for each request id
+func sendRequestIDs(t *testing.T, m *timeline.Manager, metric string, timestamp int64, numIDs int) {
+
+	for i := 0; i < numIDs; i++ {
+		err := m.SendOpenTSDB(1, timestamp, metric, "host", "h1", "request_id", fmt.Sprintf("r%d", i))
+		assert.NoError(t, err, "expected no error sending point")
+	}
+}
+
+// TestCardinalityDrop - tests if the points from new series are dropped after the limit
+func TestCardinalityDrop(t *testing.T) {
+
+	s, port := tcpudp.NewTCPServer(&defaultConf, true)
+	defer s.Stop()
+
+	m, limiter := createLimitedManager(t, port, &timeline.CardinalityLimiterConfig{
+		MaxSeriesPerMetric: 2,
+		MetricLimits: map[string]int{
+			"unlimited": 0,
+		},
+	})
+	if m == nil {
+		return
+	}
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	sendRequestIDs(t, m, "limited", now, 5)
+	sendRequestIDs(t, m, "limited", now, 1)
+	sendRequestIDs(t, m, "unlimited", now, 3)
+
+	m.SendData()
+
+	lines := receiveLines(s)
+	assert.Len(t, lines, 6, "expected 3 limited and 3 unlimited points")
+
+	stats := limiter.GetStats()
+	assert.Equal(t, uint64(3), stats.DroppedPoints, "expected dropped points")
+	assert.Equal(t, uint64(0), stats.CollapsedPoints, "expected no collapsed points")
+
+	if !assert.Len(t, stats.TopOffenders, 1, "expected only the limited metric") {
+		return
+	}
+
+	assert.Equal(t, "limited", stats.TopOffenders[0].Metric, "expected the limited metric as top offender")
+	assert.Equal(t, uint64(2), stats.TopOffenders[0].Series, "expected two admitted series")
+	assert.Equal(t, uint64(3), stats.TopOffenders[0].DroppedPoints, "expected three dropped points")
+}
+
+// TestCardinalityCollapse - tests if the offending tag is replaced after the limit
+func TestCardinalityCollapse(t *testing.T) {
+
+	s, port := tcpudp.NewTCPServer(&defaultConf, true)
+	defer s.Stop()
+
+	m, limiter := createLimitedManager(t, port, &timeline.CardinalityLimiterConfig{
+		MaxSeriesPerMetric: 2,
+		Action:             timeline.CardinalityCollapse,
+	})
+	if m == nil {
+		return
+	}
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	sendRequestIDs(t, m, "collapsed", now, 4)
+
+	m.SendData()
+
+	lines := receiveLines(s)
+	if !assert.Len(t, lines, 4, "expected all points") {
+		return
+	}
+
+	expected := []string{
+		fmt.Sprintf("put collapsed %d 1 host=h1 request_id=r0", now),
+		fmt.Sprintf("put collapsed %d 1 host=h1 request_id=r1", now),
+		fmt.Sprintf("put collapsed %d 1 host=h1 request_id=__overflow__", now),
+		fmt.Sprintf("put collapsed %d 1 host=h1 request_id=__overflow__", now),
+	}
+
+	assert.Equal(t, expected, lines, "expected the request_id tag to be collapsed")
+
+	stats := limiter.GetStats()
+	assert.Equal(t, uint64(2), stats.CollapsedPoints, "expected collapsed points")
+	assert.Equal(t, "request_id", stats.TopOffenders[0].OverflowTag, "expected the request_id as overflow tag")
+}
+
+// TestCardinalityHyperLogLog - tests the sketch estimation
+func TestCardinalityHyperLogLog(t *testing.T) {
+
+	limiter, err := timeline.NewCardinalityLimiter(&timeline.CardinalityLimiterConfig{
+		MaxSeriesPerMetric: 1000,
+		Estimator:          timeline.CardinalityHyperLogLog,
+	})
+	if !assert.NoError(t, err, "expected no error creating the limiter") {
+		return
+	}
+
+	numSeries := 5000
+	for i := 0; i < numSeries; i++ {
+		limiter.Filter(&timeline.SeriesPoint{
+			Metric: "hll",
+			Tags:   []interface{}{"request_id", fmt.Sprintf("r%d", i)},
+		})
+	}
+
+	stats := limiter.GetStats()
+	if !assert.Len(t, stats.TopOffenders, 1, "expected one metric") {
+		return
+	}
+
+	estimated := float64(stats.TopOffenders[0].Series)
+	assert.InDeltaf(t, float64(numSeries), estimated, float64(numSeries)*0.05, "expected an estimation near %d", numSeries)
+	assert.InDelta(t, float64(numSeries-1000), float64(stats.DroppedPoints), float64(numSeries)*0.05, "expected the points above the limit to be dropped")
+
+	admitted := limiter.Filter(&timeline.SeriesPoint{
+		Metric: "hll",
+		Tags:   []interface{}{"request_id", "r0"},
+	})
+
+	assert.True(t, admitted, "expected an admitted series to be kept after the limit is exceeded")
+}
+
+// TestCardinalityConfig - tests the configuration errors
+func TestCardinalityConfig(t *testing.T) {
+
+	_, err := timeline.NewCardinalityLimiter(nil)
+	assert.Error(t, err, "expected error on null configuration")
+
+	_, err = timeline.NewCardinalityLimiter(&timeline.CardinalityLimiterConfig{Action: "unknown"})
+	assert.Error(t, err, "expected error on unknown action")
+
+	_, err = timeline.NewCardinalityLimiter(&timeline.CardinalityLimiterConfig{Estimator: "unknown"})
+	assert.Error(t, err, "expected error on unknown estimator")
+
+	_, err = timeline.NewCardinalityLimiter(&timeline.CardinalityLimiterConfig{MetricLimits: map[string]int{"m": -1}})
+	assert.Error(t, err, "expected error on negative limit")
+}
@@ -31,10 +31,10 @@ const (
 	defaultTransportSize int = 50
 )
 
-// createOpenTSDBTransport - creates the opentsdb transport
-func createOpenTSDBTransport(transportBufferSize int, batchSendInterval time.Duration) *timeline.OpenTSDBTransport {
+// createOpenTSDBTransportConfig - creates the opentsdb transport configuration
+func createOpenTSDBTransportConfig(transportBufferSize int, batchSendInterval time.Duration) *timeline.OpenTSDBTransportConfig {
 
-	transportConf := timeline.OpenTSDBTransportConfig{
+	return &timeline.OpenTSDBTransportConfig{
 		DefaultTransportConfig: timeline.DefaultTransportConfig{
 			BatchSendInterval: funks.Duration{
 				Duration: batchSendInterval,
@@ -59,8 +59,12 @@ func createOpenTSDBTransport(transportBufferSize int, batchSendInterval time.Dur
 			Duration: time.Second,
 		},
 	}
+}
+
+// createOpenTSDBTransport - creates the opentsdb transport
+func createOpenTSDBTransport(transportBufferSize int, batchSendInterval time.Duration) *timeline.OpenTSDBTransport {
 
-	transport, err := timeline.NewOpenTSDBTransport(&transportConf)
+	transport, err := timeline.NewOpenTSDBTransport(createOpenTSDBTransportConfig(transportBufferSize, batchSendInterval))
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,114 @@
+package timeline_opentsdb_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+	"github.com/uol/gotest/tcpudp"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// createDedupeManager - creates a manual mode manager with deduplication
+func createDedupeManager(t *testing.T, port int, window time.Duration) *timeline.Manager {
+
+	conf := createOpenTSDBTransportConfig(defaultTransportSize, time.Second)
+	conf.DeduplicateBatch = true
+	conf.DeduplicationWindow = funks.Duration{Duration: window}
+	conf.DisconnectAfterWrites = true // the test server reads only one message per connection
+
+	transport, err := timeline.NewOpenTSDBTransport(conf)
+	if !assert.NoError(t, err, "expected no error creating the transport") {
+		return nil
+	}
+
+	m, err := timeline.NewManager(transport, nil, nil, &timeline.Backend{Host: defaultConf.Host, Port: port})
+	if !assert.NoError(t, err, "expected no error creating the manager") {
+		return nil
+	}
+
+	err = m.Start(true)
+	if !assert.NoError(t, err, "expected no error starting the manager") {
+		return nil
+	}
+
+	return m
+}
+
+// TestDeduplicateBatch - tests the removal of identical points in the same batch
+func TestDeduplicateBatch(t *testing.T) {
+
+	s, port := tcpudp.NewTCPServer(&defaultConf, true)
+	defer s.Stop()
+
+	m := createDedupeManager(t, port, 0)
+	if m == nil {
+		return
+	}
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, m.SendOpenTSDB(1, now, "dup", "host", "h1"))
+		assert.NoError(t, m.SendOpenTSDB(2, now, "dup", "host", "h1"))
+		assert.NoError(t, m.SendOpenTSDB(1, now+1, "dup", "host", "h1"))
+	}
+
+	m.SendData()
+
+	expected := []string{
+		fmt.Sprintf("put dup %d 1 host=h1", now),
+		fmt.Sprintf("put dup %d 2 host=h1", now),
+		fmt.Sprintf("put dup %d 1 host=h1", now+1),
+	}
+
+	assert.Equal(t, expected, receiveLines(s), "expected only distinct points")
+	assert.Equal(t, uint64(6), m.GetTransportStats().DuplicatedPoints, "expected duplicated points in stats")
+
+	// without a window, the same points are sent again in a new batch
+	assert.NoError(t, m.SendOpenTSDB(1, now, "dup", "host", "h1"))
+	m.SendData()
+
+	assert.Equal(t, expected[:1], receiveLines(s), "expected the point to be sent again")
+}
+
+// TestDeduplicateWindow - tests the removal of identical points across batches
+func TestDeduplicateWindow(t *testing.T) {
+
+	s, port := tcpudp.NewTCPServer(&defaultConf, true)
+	defer s.Stop()
+
+	m := createDedupeManager(t, port, time.Second)
+	if m == nil {
+		return
+	}
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	assert.NoError(t, m.SendOpenTSDB(1, now, "dup", "host", "h1"))
+	m.SendData()
+
+	assert.NoError(t, m.SendOpenTSDB(1, now, "dup", "host", "h1"))
+	assert.NoError(t, m.SendOpenTSDB(2, now, "dup", "host", "h1"))
+	m.SendData()
+
+	assert.Equal(t, uint64(1), m.GetTransportStats().DuplicatedPoints, "expected one duplicated point")
+
+	<-time.After(2100 * time.Millisecond)
+
+	assert.NoError(t, m.SendOpenTSDB(1, now, "dup", "host", "h1"))
+	m.SendData()
+
+	assert.Equal(t, []string{fmt.Sprintf("put dup %d 1 host=h1", now)}, receiveLines(s), "expected the first point")
+	assert.Equal(t, []string{fmt.Sprintf("put dup %d 2 host=h1", now)}, receiveLines(s), "expected only the new point")
+	assert.Equal(t, []string{fmt.Sprintf("put dup %d 1 host=h1", now)}, receiveLines(s), "expected the point after the window")
+}
@@ -0,0 +1,176 @@
+package timeline_opentsdb_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+	"github.com/uol/gotest/tcpudp"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// TestSamplingFirstN - tests if only the first points of each series are kept
+func TestSamplingFirstN(t *testing.T) {
+
+	s, port := tcpudp.NewTCPServer(&defaultConf, true)
+	defer s.Stop()
+
+	m := createTimelineManagerF(true, true, port, defaultTransportSize)
+	defer m.Shutdown()
+
+	sampler, err := timeline.NewSampler(&timeline.SamplerConfig{
+		Rules: []timeline.SamplingRule{
+			{
+				Metric:    "debug",
+				Strategy:  timeline.SamplingFirstN,
+				MaxPoints: 2,
+				Interval:  funks.Duration{Duration: time.Hour},
+			},
+		},
+	})
+	if !assert.NoError(t, err, "expected no error creating the sampler") {
+		return
+	}
+
+	m.SetSampler(sampler)
+
+	now := time.Now().Unix()
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, m.SendOpenTSDB(float64(i), now, "debug", "host", "h1"))
+		assert.NoError(t, m.SendOpenTSDB(float64(i), now, "debug", "host", "h2"))
+		assert.NoError(t, m.SendOpenTSDB(float64(i), now, "other", "host", "h1"))
+		assert.NoError(t, m.FlattenOpenTSDB(timeline.Count, float64(i), now, "debug", "host", "h3"))
+	}
+
+	m.ProcessCycle()
+	m.SendData()
+
+	expected := []string{
+		fmt.Sprintf("put debug %d 0 host=h1", now),
+		fmt.Sprintf("put debug %d 0 host=h2", now),
+		fmt.Sprintf("put other %d 0 host=h1", now),
+		fmt.Sprintf("put debug %d 1 host=h1", now),
+		fmt.Sprintf("put debug %d 1 host=h2", now),
+		fmt.Sprintf("put other %d 1 host=h1", now),
+		fmt.Sprintf("put other %d 2 host=h1", now),
+		fmt.Sprintf("put other %d 3 host=h1", now),
+		fmt.Sprintf("put other %d 4 host=h1", now),
+		fmt.Sprintf("put debug %d 2 host=h3", now),
+	}
+
+	assert.ElementsMatch(t, expected, receiveLines(s), "expected only two points per debug series")
+}
+
+// TestSamplingHash - tests if the hash sampling always keeps the same series
+func TestSamplingHash(t *testing.T) {
+
+	sampler, err := timeline.NewSampler(&timeline.SamplerConfig{
+		Rules: []timeline.SamplingRule{
+			{
+				Strategy: timeline.SamplingHash,
+				Rate:     0.25,
+			},
+		},
+	})
+	if !assert.NoError(t, err, "expected no error creating the sampler") {
+		return
+	}
+
+	numSeries := 4000
+	kept := map[string]bool{}
+
+	for i := 0; i < numSeries; i++ {
+		tagValue := fmt.Sprintf("h%d", i)
+		kept[tagValue] = sampler.Filter(&timeline.SeriesPoint{
+			Metric:    "any",
+			Tags:      []interface{}{"host", tagValue},
+			Value:     float64(i),
+			Timestamp: time.Now().Unix(),
+		})
+	}
+
+	numKept := 0
+
+	for tagValue, wasKept := range kept {
+
+		if wasKept {
+			numKept++
+		}
+
+		for j := 0; j < 3; j++ {
+			keep := sampler.Filter(&timeline.SeriesPoint{
+				Metric:    "any",
+				Tags:      []interface{}{"host", tagValue},
+				Value:     float64(j),
+				Timestamp: time.Now().Unix() + int64(j),
+			})
+
+			if !assert.Equal(t, wasKept, keep, "expected the same decision for the same series") {
+				return
+			}
+		}
+	}
+
+	assert.InDelta(t, float64(numSeries)*0.25, float64(numKept), float64(numSeries)*0.05, "expected a quarter of the series")
+}
+
+// TestSamplingRandom - tests the random sampling rate
+func TestSamplingRandom(t *testing.T) {
+
+	sampler, err := timeline.NewSampler(&timeline.SamplerConfig{
+		Rules: []timeline.SamplingRule{
+			{
+				Metric:   "chatty",
+				Strategy: timeline.SamplingRandom,
+				Rate:     0.1,
+			},
+		},
+	})
+	if !assert.NoError(t, err, "expected no error creating the sampler") {
+		return
+	}
+
+	numPoints := 10000
+	numKept := 0
+	numOthers := 0
+
+	for i := 0; i < numPoints; i++ {
+
+		if sampler.Filter(&timeline.SeriesPoint{Metric: "chatty"}) {
+			numKept++
+		}
+
+		if sampler.Filter(&timeline.SeriesPoint{Metric: "other"}) {
+			numOthers++
+		}
+	}
+
+	assert.InDelta(t, float64(numPoints)*0.1, float64(numKept), float64(numPoints)*0.02, "expected ten percent of the points")
+	assert.Equal(t, numPoints, numOthers, "expected all points from metrics without rules")
+}
+
+// TestSamplingConfig - tests the configuration errors
+func TestSamplingConfig(t *testing.T) {
+
+	invalidRules := [][]timeline.SamplingRule{
+		{{Strategy: "unknown"}},
+		{{Strategy: timeline.SamplingRandom, Rate: 1.5}},
+		{{Strategy: timeline.SamplingFirstN, MaxPoints: 0, Interval: funks.Duration{Duration: time.Second}}},
+		{{Strategy: timeline.SamplingFirstN, MaxPoints: 1}},
+		{{Metric: "m", Strategy: timeline.SamplingHash}, {Metric: "m", Strategy: timeline.SamplingHash}},
+		{{Strategy: timeline.SamplingHash}, {Strategy: timeline.SamplingHash}},
+	}
+
+	for _, rules := range invalidRules {
+		_, err := timeline.NewSampler(&timeline.SamplerConfig{Rules: rules})
+		assert.Errorf(t, err, "expected error on rules: %+v", rules)
+	}
+}
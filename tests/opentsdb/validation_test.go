@@ -0,0 +1,155 @@
+package timeline_opentsdb_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gotest/tcpudp"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// createValidatedManager - creates a manual mode manager using a point validator
+func createValidatedManager(t *testing.T, port int, mode timeline.ValidationMode) *timeline.Manager {
+
+	m := createTimelineManager(true, true, port, defaultTransportSize, time.Second)
+
+	validator, err := timeline.NewPointValidator(&timeline.ValidationConfig{
+		Mode:    mode,
+		MaxTags: 2,
+	})
+
+	if !assert.NoError(t, err, "expected no error creating the validator") {
+		return nil
+	}
+
+	m.SetPointValidator(validator)
+
+	return m
+}
+
+// TestValidationReject - tests the typed errors returned in reject mode
+func TestValidationReject(t *testing.T) {
+
+	m := createValidatedManager(t, 0, timeline.ValidationReject)
+	if m == nil {
+		return
+	}
+	defer m.Shutdown()
+
+	type testCase struct {
+		metric   string
+		value    float64
+		tags     []interface{}
+		expected error
+	}
+
+	testCases := []testCase{
+		{"", 1, []interface{}{"k", "v"}, timeline.ErrEmptyMetric},
+		{"metric with space", 1, []interface{}{"k", "v"}, timeline.ErrInvalidCharacter},
+		{"metric", math.NaN(), []interface{}{"k", "v"}, timeline.ErrInvalidValue},
+		{"metric", math.Inf(1), []interface{}{"k", "v"}, timeline.ErrInvalidValue},
+		{"metric", 1, []interface{}{"k", "v", "k2"}, timeline.ErrOddNumberOfTags},
+		{"metric", 1, []interface{}{}, timeline.ErrNoTags},
+		{"metric", 1, []interface{}{"k1", "v", "k2", "v", "k3", "v"}, timeline.ErrTooManyTags},
+		{"metric", 1, []interface{}{"k", ""}, timeline.ErrEmptyTag},
+		{"metric", 1, []interface{}{1, "v"}, timeline.ErrInvalidTagType},
+		{"metric", 1, []interface{}{"k", "v=1"}, timeline.ErrInvalidCharacter},
+	}
+
+	for _, tc := range testCases {
+
+		err := m.SendOpenTSDB(tc.value, 0, tc.metric, tc.tags...)
+		if !assert.Error(t, err, "expected an error on metric \"%s\"", tc.metric) {
+			continue
+		}
+
+		assert.Truef(t, errors.Is(err, tc.expected), "expected error \"%s\", got: %s", tc.expected, err)
+
+		var validationErr *timeline.ValidationError
+		assert.True(t, errors.As(err, &validationErr), "expected a validation error type")
+
+		_, err = m.StoreDataToAccumulateOpenTSDB(time.Second, tc.value, 0, tc.metric, tc.tags...)
+		assert.Truef(t, errors.Is(err, tc.expected), "expected error \"%s\" storing data, got: %s", tc.expected, err)
+	}
+
+	assert.NoError(t, m.SendOpenTSDB(1, 0, "valid/metric-name_1.0", "host", "ação"), "expected no error on valid point")
+}
+
+// TestValidationSanitize - tests if the points are fixed before being sent
+func TestValidationSanitize(t *testing.T) {
+
+	s, port := tcpudp.NewTCPServer(&defaultConf, true)
+	defer s.Stop()
+
+	m := createValidatedManager(t, port, timeline.ValidationSanitize)
+	if m == nil {
+		return
+	}
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	tags := []interface{}{"host name", "a:b", "empty", "", "dangling"}
+
+	err := m.SendOpenTSDB(1, now, "my metric!", tags...)
+	if !assert.NoError(t, err, "expected the point to be sanitized") {
+		return
+	}
+
+	assert.Equal(t, "host name", tags[0], "expected the caller's tags to be unchanged")
+
+	err = m.SendOpenTSDB(math.NaN(), now, "metric", "k", "v")
+	assert.True(t, errors.Is(err, timeline.ErrInvalidValue), "expected NaN to be rejected")
+
+	m.SendData()
+
+	message := <-s.MessageChannel()
+	assert.Equal(t, fmt.Sprintf("put my_metric_ %d 1 host_name=a_b\n", now), message.Message, "expected a sanitized line")
+}
+
+// TestValidationLogAndPass - tests if invalid points are sent anyway
+func TestValidationLogAndPass(t *testing.T) {
+
+	s, port := tcpudp.NewTCPServer(&defaultConf, true)
+	defer s.Stop()
+
+	m := createValidatedManager(t, port, timeline.ValidationLogAndPass)
+	if m == nil {
+		return
+	}
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	err := m.SendOpenTSDB(1, now, "my metric", "k", "v")
+	if !assert.NoError(t, err, "expected no error in log mode") {
+		return
+	}
+
+	m.SendData()
+
+	message := <-s.MessageChannel()
+	assert.Equal(t, fmt.Sprintf("put my metric %d 1 k=v\n", now), message.Message, "expected the original line")
+}
+
+// TestValidationConfig - tests the configuration errors
+func TestValidationConfig(t *testing.T) {
+
+	_, err := timeline.NewPointValidator(nil)
+	assert.Error(t, err, "expected error on null configuration")
+
+	_, err = timeline.NewPointValidator(&timeline.ValidationConfig{Mode: "unknown"})
+	assert.Error(t, err, "expected error on unknown mode")
+
+	_, err = timeline.NewPointValidator(&timeline.ValidationConfig{MaxTags: -1})
+	assert.Error(t, err, "expected error on negative number of tags")
+}
@@ -0,0 +1,298 @@
+package timeline_otlp_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/hashing"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+const (
+	defaultTransportSize int = 100
+)
+
+// receivedRequest - a request received by the test receiver
+type receivedRequest struct {
+	uri     string
+	headers http.Header
+	body    []byte
+}
+
+// testReceiver - a otlp/http receiver
+type testReceiver struct {
+	server   *httptest.Server
+	requests chan receivedRequest
+}
+
+// createReceiver - creates a otlp/http receiver responding the given status
+func createReceiver(t *testing.T, status int) *testReceiver {
+
+	r := &testReceiver{
+		requests: make(chan receivedRequest, 20),
+	}
+
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		r.requests <- receivedRequest{
+			uri:     req.RequestURI,
+			headers: req.Header,
+			body:    body,
+		}
+
+		w.WriteHeader(status)
+	}))
+
+	return r
+}
+
+// backend - returns the timeline backend
+func (r *testReceiver) backend(t *testing.T) *timeline.Backend {
+
+	host, port, err := net.SplitHostPort(r.server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &timeline.Backend{Host: host, Port: portNum}
+}
+
+// waitRequest - waits for the next request
+func (r *testReceiver) waitRequest(timeout time.Duration) *receivedRequest {
+
+	select {
+	case req := <-r.requests:
+		return &req
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+// createOTLPConfig - creates the otlp transport configuration
+func createOTLPConfig(encoding string) *timeline.OTLPTransportConfig {
+
+	return &timeline.OTLPTransportConfig{
+		DefaultTransportConfig: timeline.DefaultTransportConfig{
+			BatchSendInterval:    funks.Duration{Duration: time.Second},
+			RequestTimeout:       funks.Duration{Duration: time.Second},
+			TransportBufferSize:  defaultTransportSize,
+			SerializerBufferSize: 1024,
+			TimeBetweenBatches:   funks.Duration{Duration: 10 * time.Millisecond},
+		},
+		Encoding: encoding,
+		GlobalTags: map[string]string{
+			"service.name": "test",
+			"host.name":    "h1",
+		},
+	}
+}
+
+// createOTLPManager - creates a manual mode manager using the otlp transport
+func createOTLPManager(t *testing.T, conf *timeline.OTLPTransportConfig, backend *timeline.Backend) *timeline.Manager {
+
+	transport, err := timeline.NewOTLPTransport(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dtc := &timeline.DataTransformerConfig{
+		CycleDuration:    funks.Duration{Duration: time.Hour},
+		HashingAlgorithm: hashing.SHA256,
+	}
+
+	manager, err := timeline.NewManager(transport, timeline.NewFlattener(dtc), timeline.NewAccumulator(dtc), backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = manager.Start(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager
+}
+
+// decodedDataPoint - a decoded number data point
+type decodedDataPoint struct {
+	attributes map[string]string
+	startTime  uint64
+	time       uint64
+	value      float64
+}
+
+// decodedMetric - a decoded metric
+type decodedMetric struct {
+	name        string
+	sum         bool
+	temporality uint64
+	monotonic   bool
+	dataPoints  []decodedDataPoint
+}
+
+// decodedRequest - a decoded export metrics service request
+type decodedRequest struct {
+	resource map[string]string
+	scope    string
+	metrics  []decodedMetric
+}
+
+// protoFields - iterates over the protobuf fields
+func protoFields(data []byte, fn func(field int, value []byte, number uint64)) error {
+
+	for len(data) > 0 {
+
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("invalid key")
+		}
+		data = data[n:]
+
+		field, wireType := int(key>>3), key&7
+
+		switch wireType {
+		case 0:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("invalid varint")
+			}
+			data = data[n:]
+			fn(field, nil, v)
+		case 1:
+			fn(field, nil, binary.LittleEndian.Uint64(data[:8]))
+			data = data[8:]
+		case 2:
+			l, n := binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("invalid length")
+			}
+			data = data[n:]
+			fn(field, data[:l], 0)
+			data = data[l:]
+		default:
+			return fmt.Errorf("unexpected wire type: %d", wireType)
+		}
+	}
+
+	return nil
+}
+
+// decodeKeyValue - decodes a key value with a string value
+func decodeKeyValue(data []byte, attributes map[string]string) {
+
+	var key, value string
+
+	protoFields(data, func(field int, v []byte, _ uint64) {
+		if field == 1 {
+			key = string(v)
+			return
+		}
+		protoFields(v, func(_ int, s []byte, _ uint64) {
+			value = string(s)
+		})
+	})
+
+	attributes[key] = value
+}
+
+// decodeDataPoints - decodes the data points from a gauge or sum
+func decodeDataPoints(data []byte, metric *decodedMetric) {
+
+	protoFields(data, func(field int, v []byte, n uint64) {
+
+		switch field {
+		case 1:
+			dp := decodedDataPoint{attributes: map[string]string{}}
+			protoFields(v, func(f int, kv []byte, number uint64) {
+				switch f {
+				case 2:
+					dp.startTime = number
+				case 3:
+					dp.time = number
+				case 4:
+					dp.value = math.Float64frombits(number)
+				case 7:
+					decodeKeyValue(kv, dp.attributes)
+				}
+			})
+			metric.dataPoints = append(metric.dataPoints, dp)
+		case 2:
+			metric.temporality = n
+		case 3:
+			metric.monotonic = n == 1
+		}
+	})
+}
+
+// decodeRequest - decodes the protobuf export metrics service request
+func decodeRequest(t *testing.T, body []byte) *decodedRequest {
+
+	result := &decodedRequest{resource: map[string]string{}}
+
+	err := protoFields(body, func(_ int, rm []byte, _ uint64) {
+
+		protoFields(rm, func(field int, v []byte, _ uint64) {
+
+			if field == 1 {
+				protoFields(v, func(_ int, kv []byte, _ uint64) {
+					decodeKeyValue(kv, result.resource)
+				})
+				return
+			}
+
+			protoFields(v, func(f int, sm []byte, _ uint64) {
+
+				if f == 1 {
+					protoFields(sm, func(_ int, name []byte, _ uint64) {
+						result.scope = string(name)
+					})
+					return
+				}
+
+				metric := decodedMetric{}
+				protoFields(sm, func(mf int, mv []byte, _ uint64) {
+					switch mf {
+					case 1:
+						metric.name = string(mv)
+					case 5:
+						decodeDataPoints(mv, &metric)
+					case 7:
+						metric.sum = true
+						decodeDataPoints(mv, &metric)
+					}
+				})
+				result.metrics = append(result.metrics, metric)
+			})
+		})
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
@@ -0,0 +1,252 @@
+package timeline_otlp_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// findMetric - returns the metric with the given name
+func findMetric(metrics []decodedMetric, name string) *decodedMetric {
+
+	for i := range metrics {
+		if metrics[i].name == name {
+			return &metrics[i]
+		}
+	}
+
+	return nil
+}
+
+// TestOTLPProtobuf - tests the protobuf encoding with gauges, delta sums and the resource attributes
+func TestOTLPProtobuf(t *testing.T) {
+
+	r := createReceiver(t, http.StatusOK)
+	defer r.server.Close()
+
+	m := createOTLPManager(t, createOTLPConfig(timeline.OTLPProtobuf), r.backend(t))
+	defer m.Shutdown()
+
+	start := time.Now().UnixNano()
+	now := time.Now().Unix()
+
+	hash, err := m.StoreDataToAccumulateOpenTSDB(time.Minute, 0, now, "http.requests", "path", "/a")
+	if !assert.NoError(t, err, "expected no error storing data") {
+		return
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, m.IncrementAccumulatedData(hash))
+	}
+
+	for _, v := range []float64{10, 30} {
+		assert.NoError(t, m.FlattenOpenTSDB(timeline.Max, v, now, "queue.size", "host", "h1"))
+	}
+
+	assert.NoError(t, m.FlattenOpenTSDB(timeline.Max, 0, now, "queue.size", "host", "h2"))
+
+	m.ProcessCycle()
+	assert.NoError(t, m.SendData(), "expected no error sending data")
+
+	req := r.waitRequest(5 * time.Second)
+	if !assert.NotNil(t, req, "expected a request") {
+		return
+	}
+
+	assert.Equal(t, "/v1/metrics", req.uri, "expected the default endpoint")
+	assert.Equal(t, "application/x-protobuf", req.headers.Get("Content-Type"), "expected the content type")
+
+	decoded := decodeRequest(t, req.body)
+	assert.Equal(t, map[string]string{"service.name": "test", "host.name": "h1"}, decoded.resource, "expected the global tags as resource attributes")
+	assert.Equal(t, "github.com/uol/timeline", decoded.scope, "expected the default scope name")
+
+	if !assert.Len(t, decoded.metrics, 2, "expected the points grouped by metric") {
+		return
+	}
+
+	gauge := findMetric(decoded.metrics, "queue.size")
+	if assert.NotNil(t, gauge, "expected the gauge") {
+		assert.False(t, gauge.sum, "expected a gauge")
+		if assert.Len(t, gauge.dataPoints, 2, "expected two gauge data points") {
+			values := map[string]float64{}
+			for _, dp := range gauge.dataPoints {
+				values[dp.attributes["host"]] = dp.value
+				assert.Equal(t, uint64(now)*uint64(time.Second), dp.time, "expected the timestamp in nanoseconds")
+			}
+			assert.Equal(t, map[string]float64{"h1": 30, "h2": 0}, values, "expected the flattened values (zero included)")
+		}
+	}
+
+	sum := findMetric(decoded.metrics, "http.requests")
+	if assert.NotNil(t, sum, "expected the sum") {
+		assert.True(t, sum.sum, "expected a sum")
+		assert.Equal(t, uint64(1), sum.temporality, "expected the delta temporality")
+		assert.True(t, sum.monotonic, "expected a monotonic sum")
+		if assert.Len(t, sum.dataPoints, 1, "expected one sum data point") {
+			assert.Equal(t, float64(3), sum.dataPoints[0].value, "expected the accumulated value")
+			assert.Equal(t, "/a", sum.dataPoints[0].attributes["path"], "expected the tags as attributes")
+			assert.True(t, sum.dataPoints[0].startTime > 0 && sum.dataPoints[0].startTime <= uint64(start), "expected the start time")
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, m.IncrementAccumulatedData(hash))
+	}
+
+	m.ProcessCycle()
+	assert.NoError(t, m.SendData(), "expected no error sending data")
+
+	req = r.waitRequest(5 * time.Second)
+	if !assert.NotNil(t, req, "expected the second request") {
+		return
+	}
+
+	decoded = decodeRequest(t, req.body)
+	sum = findMetric(decoded.metrics, "http.requests")
+	if assert.NotNil(t, sum, "expected the sum") && assert.Len(t, sum.dataPoints, 1, "expected one sum data point") {
+		assert.Equal(t, float64(2), sum.dataPoints[0].value, "expected the delta value")
+		assert.True(t, sum.dataPoints[0].startTime >= uint64(start), "expected the start time at the previous emission")
+	}
+}
+
+// TestOTLPDeltaWindows - tests the delta windows start before their time and meet exactly
+func TestOTLPDeltaWindows(t *testing.T) {
+
+	r := createReceiver(t, http.StatusOK)
+	defer r.server.Close()
+
+	m := createOTLPManager(t, createOTLPConfig(timeline.OTLPProtobuf), r.backend(t))
+	defer m.Shutdown()
+
+	hash, err := m.StoreDataToAccumulateOpenTSDB(time.Minute, 0, time.Now().Unix(), "http.requests", "path", "/a")
+	if !assert.NoError(t, err, "expected no error storing data") {
+		return
+	}
+
+	var previous uint64
+
+	for i := 0; i < 3; i++ {
+
+		<-time.After(300 * time.Millisecond)
+
+		assert.NoError(t, m.IncrementAccumulatedData(hash))
+
+		m.ProcessCycle()
+		assert.NoError(t, m.SendData(), "expected no error sending data")
+
+		req := r.waitRequest(5 * time.Second)
+		if !assert.NotNil(t, req, "expected a request") {
+			return
+		}
+
+		sum := findMetric(decodeRequest(t, req.body).metrics, "http.requests")
+		if !assert.NotNil(t, sum, "expected the sum") || !assert.Len(t, sum.dataPoints, 1, "expected one sum data point") {
+			return
+		}
+
+		dp := sum.dataPoints[0]
+		assert.True(t, dp.startTime < dp.time, "expected the start time before the time: %d >= %d", dp.startTime, dp.time)
+
+		if i > 0 {
+			assert.Equal(t, previous, dp.startTime, "expected the window to start at the previous time")
+		}
+
+		previous = dp.time
+	}
+}
+
+// TestOTLPExpiredSeries - tests the last emission is removed when the accumulated data expires
+func TestOTLPExpiredSeries(t *testing.T) {
+
+	r := createReceiver(t, http.StatusOK)
+	defer r.server.Close()
+
+	m := createOTLPManager(t, createOTLPConfig(timeline.OTLPProtobuf), r.backend(t))
+	defer m.Shutdown()
+
+	start := time.Now().UnixNano()
+	now := time.Now().Unix()
+
+	for i := 0; i < 2; i++ {
+
+		hash, err := m.StoreDataToAccumulateOpenTSDB(200*time.Millisecond, 0, now, "http.requests", "path", "/a")
+		if !assert.NoError(t, err, "expected no error storing data") {
+			return
+		}
+
+		assert.NoError(t, m.IncrementAccumulatedData(hash))
+
+		m.ProcessCycle()
+		assert.NoError(t, m.SendData(), "expected no error sending data")
+
+		req := r.waitRequest(5 * time.Second)
+		if !assert.NotNil(t, req, "expected a request") {
+			return
+		}
+
+		sum := findMetric(decodeRequest(t, req.body).metrics, "http.requests")
+		if assert.NotNil(t, sum, "expected the sum") && assert.Len(t, sum.dataPoints, 1, "expected one sum data point") {
+			assert.True(t, sum.dataPoints[0].startTime <= uint64(start), "expected the start time of a new series")
+		}
+
+		<-time.After(time.Second)
+	}
+}
+
+// TestOTLPJSON - tests the json encoding
+func TestOTLPJSON(t *testing.T) {
+
+	r := createReceiver(t, http.StatusOK)
+	defer r.server.Close()
+
+	conf := createOTLPConfig(timeline.OTLPJSON)
+	conf.ServiceEndpoint = "/otlp/v1/metrics"
+	conf.ScopeName = "custom"
+
+	m := createOTLPManager(t, conf, r.backend(t))
+	defer m.Shutdown()
+
+	assert.NoError(t, m.SendOpenTSDB(1.5, 10, "mem", "host", "h2"))
+	assert.NoError(t, m.SendData(), "expected no error sending data")
+
+	req := r.waitRequest(5 * time.Second)
+	if !assert.NotNil(t, req, "expected a request") {
+		return
+	}
+
+	assert.Equal(t, "/otlp/v1/metrics", req.uri, "expected the configured endpoint")
+	assert.Equal(t, "application/json", req.headers.Get("Content-Type"), "expected the content type")
+
+	expected := `{"resourceMetrics":[{"resource":{"attributes":[` +
+		`{"key":"host.name","value":{"stringValue":"h1"}},` +
+		`{"key":"service.name","value":{"stringValue":"test"}}]},` +
+		`"scopeMetrics":[{"metrics":[{"name":"mem","gauge":{"dataPoints":[` +
+		`{"attributes":[{"key":"host","value":{"stringValue":"h2"}}],"timeUnixNano":"10000000000","asDouble":1.5}]}}],` +
+		`"scope":{"name":"custom"}}]}]}`
+
+	assert.JSONEq(t, expected, string(req.body), "expected the json mapping")
+}
+
+// TestOTLPErrors - tests the configuration and the receiver errors
+func TestOTLPErrors(t *testing.T) {
+
+	_, err := timeline.NewOTLPTransport(createOTLPConfig("xml"))
+	assert.Error(t, err, "expected error with an invalid encoding")
+
+	r := createReceiver(t, http.StatusBadRequest)
+	defer r.server.Close()
+
+	m := createOTLPManager(t, createOTLPConfig(timeline.OTLPProtobuf), r.backend(t))
+	defer m.Shutdown()
+
+	assert.NoError(t, m.SendOpenTSDB(1, 0, "metric"))
+	assert.Error(t, m.SendData(), "expected error from the receiver")
+}
@@ -0,0 +1,74 @@
+package timeline_parser_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	serializer "github.com/uol/serializer/opentsdb"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// TestParseOpenTSDBLine - tests the telnet line parser
+func TestParseOpenTSDBLine(t *testing.T) {
+
+	item, err := timeline.ParseOpenTSDBLine("put sys.cpu 1600000000 1.5 host=h1 dc=us")
+	if assert.NoError(t, err, "expected no error") {
+		assert.Equal(t, &serializer.ArrayItem{
+			Metric:    "sys.cpu",
+			Timestamp: 1600000000,
+			Value:     1.5,
+			Tags:      []interface{}{"host", "h1", "dc", "us"},
+		}, item, "expected the parsed item")
+	}
+
+	item, err = timeline.ParseOpenTSDBLine("  mem 1600000000 -2  ")
+	if assert.NoError(t, err, "expected no error without the put command and tags") {
+		assert.Equal(t, "mem", item.Metric, "expected the metric")
+		assert.Equal(t, float64(-2), item.Value, "expected the value")
+		assert.Empty(t, item.Tags, "expected no tags")
+	}
+
+	for _, line := range []string{
+		"put",
+		"put cpu 1600000000",
+		"put cpu now 1",
+		"put cpu 1600000000 one",
+		"put cpu 1600000000 1 host",
+		"put cpu 1600000000 1 =h1",
+		"put cpu 1600000000 1 host=",
+	} {
+		_, err = timeline.ParseOpenTSDBLine(line)
+		assert.Errorf(t, err, "expected error parsing: %s", line)
+	}
+}
+
+// TestParseOpenTSDBJSON - tests the /api/put json parser
+func TestParseOpenTSDBJSON(t *testing.T) {
+
+	items, err := timeline.ParseOpenTSDBJSON([]byte(`{"metric":"cpu","timestamp":1600000000,"value":1,"tags":{"z":"1","a":"2"}}`))
+	if assert.NoError(t, err, "expected no error parsing a single point") && assert.Len(t, items, 1, "expected one item") {
+		assert.Equal(t, []interface{}{"a", "2", "z", "1"}, items[0].Tags, "expected the tags sorted by key")
+	}
+
+	items, err = timeline.ParseOpenTSDBJSON([]byte(` [{"metric":"a","timestamp":1,"value":"2.5"},{"metric":"b","timestamp":2,"value":3}] `))
+	if assert.NoError(t, err, "expected no error parsing an array") && assert.Len(t, items, 2, "expected two items") {
+		assert.Equal(t, 2.5, items[0].Value, "expected the string value parsed")
+		assert.Equal(t, int64(2), items[1].Timestamp, "expected the timestamp")
+	}
+
+	for _, data := range []string{
+		``,
+		`{"metric":"a"`,
+		`{"timestamp":1,"value":1}`,
+		`{"metric":"a","timestamp":1.5,"value":1}`,
+		`{"metric":"a","timestamp":1,"value":"x"}`,
+	} {
+		_, err = timeline.ParseOpenTSDBJSON([]byte(data))
+		assert.Errorf(t, err, "expected error parsing: %s", data)
+	}
+}
@@ -0,0 +1,254 @@
+package timeline_prometheus_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/uol/funks"
+	"github.com/uol/hashing"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+const (
+	defaultTransportSize int = 100
+)
+
+// receivedRequest - a request received by the test backend
+type receivedRequest struct {
+	uri     string
+	headers http.Header
+	body    []byte
+}
+
+// testBackend - a http backend responding with the configured status codes
+type testBackend struct {
+	server   *httptest.Server
+	requests chan receivedRequest
+	statuses []int
+	calls    uint32
+}
+
+// createBackend - creates a http backend responding the status codes in sequence (the last one is repeated)
+func createBackend(t *testing.T, statuses ...int) *testBackend {
+
+	return createBackendWithHeaders(t, nil, statuses...)
+}
+
+// createBackendWithHeaders - creates a http backend responding the headers and the status codes in sequence (the last one is repeated)
+func createBackendWithHeaders(t *testing.T, headers http.Header, statuses ...int) *testBackend {
+
+	b := &testBackend{
+		requests: make(chan receivedRequest, 20),
+		statuses: statuses,
+	}
+
+	b.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		b.requests <- receivedRequest{
+			uri:     r.RequestURI,
+			headers: r.Header,
+			body:    body,
+		}
+
+		call := int(atomic.AddUint32(&b.calls, 1)) - 1
+		if call >= len(b.statuses) {
+			call = len(b.statuses) - 1
+		}
+
+		for k, v := range headers {
+			w.Header()[k] = v
+		}
+
+		w.WriteHeader(b.statuses[call])
+	}))
+
+	return b
+}
+
+// backend - returns the timeline backend
+func (b *testBackend) backend(t *testing.T) *timeline.Backend {
+
+	host, port, err := net.SplitHostPort(b.server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &timeline.Backend{Host: host, Port: portNum}
+}
+
+// waitRequest - waits for the next request
+func (b *testBackend) waitRequest(timeout time.Duration) *receivedRequest {
+
+	select {
+	case r := <-b.requests:
+		return &r
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+// createRemoteWriteConfig - creates the remote write transport configuration
+func createRemoteWriteConfig() *timeline.PrometheusRemoteWriteConfig {
+
+	return &timeline.PrometheusRemoteWriteConfig{
+		DefaultTransportConfig: timeline.DefaultTransportConfig{
+			BatchSendInterval:    funks.Duration{Duration: time.Second},
+			RequestTimeout:       funks.Duration{Duration: time.Second},
+			TransportBufferSize:  defaultTransportSize,
+			SerializerBufferSize: 1024,
+			TimeBetweenBatches:   funks.Duration{Duration: 10 * time.Millisecond},
+		},
+		MinBackoff: funks.Duration{Duration: 10 * time.Millisecond},
+		MaxBackoff: funks.Duration{Duration: 50 * time.Millisecond},
+	}
+}
+
+// createRemoteWriteManager - creates a manual mode manager using the remote write transport
+func createRemoteWriteManager(t *testing.T, conf *timeline.PrometheusRemoteWriteConfig, backend *timeline.Backend) *timeline.Manager {
+
+	transport, err := timeline.NewPrometheusRemoteWriteTransport(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dtc := &timeline.DataTransformerConfig{
+		CycleDuration:    funks.Duration{Duration: time.Hour},
+		HashingAlgorithm: hashing.SHA256,
+	}
+
+	manager, err := timeline.NewManager(transport, timeline.NewFlattener(dtc), timeline.NewAccumulator(dtc), backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = manager.Start(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager
+}
+
+// decodedSeries - a decoded remote write series
+type decodedSeries struct {
+	labels     map[string]string
+	values     []float64
+	timestamps []int64
+}
+
+// protoFields - iterates over the protobuf fields
+func protoFields(data []byte, fn func(field int, wireType uint64, value []byte, number uint64)) error {
+
+	for len(data) > 0 {
+
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("invalid key")
+		}
+		data = data[n:]
+
+		field, wireType := int(key>>3), key&7
+
+		switch wireType {
+		case 0:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("invalid varint")
+			}
+			data = data[n:]
+			fn(field, wireType, nil, v)
+		case 1:
+			fn(field, wireType, nil, binary.LittleEndian.Uint64(data[:8]))
+			data = data[8:]
+		case 2:
+			l, n := binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("invalid length")
+			}
+			data = data[n:]
+			fn(field, wireType, data[:l], 0)
+			data = data[l:]
+		default:
+			return fmt.Errorf("unexpected wire type: %d", wireType)
+		}
+	}
+
+	return nil
+}
+
+// decodeWriteRequest - decodes the snappy compressed write request
+func decodeWriteRequest(t *testing.T, body []byte) []decodedSeries {
+
+	data, err := snappy.Decode(nil, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := []decodedSeries{}
+
+	err = protoFields(data, func(_ int, _ uint64, ts []byte, _ uint64) {
+
+		series := decodedSeries{labels: map[string]string{}}
+
+		protoFields(ts, func(field int, _ uint64, inner []byte, _ uint64) {
+
+			if field == 1 {
+				var name, value string
+				protoFields(inner, func(f int, _ uint64, v []byte, _ uint64) {
+					if f == 1 {
+						name = string(v)
+					} else {
+						value = string(v)
+					}
+				})
+				series.labels[name] = value
+				return
+			}
+
+			var value float64
+			var timestamp int64
+			protoFields(inner, func(f int, _ uint64, _ []byte, n uint64) {
+				if f == 1 {
+					value = math.Float64frombits(n)
+				} else {
+					timestamp = int64(n)
+				}
+			})
+			series.values = append(series.values, value)
+			series.timestamps = append(series.timestamps, timestamp)
+		})
+
+		result = append(result, series)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
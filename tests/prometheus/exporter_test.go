@@ -0,0 +1,164 @@
+package timeline_prometheus_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/funks"
+	"github.com/uol/hashing"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// createExporterManager - creates a manual mode manager using the exporter
+func createExporterManager(t *testing.T, exporter *timeline.PrometheusExporter, backend *timeline.Backend) *timeline.Manager {
+
+	dtc := &timeline.DataTransformerConfig{
+		CycleDuration:    funks.Duration{Duration: time.Hour},
+		HashingAlgorithm: hashing.SHA256,
+	}
+
+	manager, err := timeline.NewManager(exporter, timeline.NewFlattener(dtc), timeline.NewAccumulator(dtc), backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = manager.Start(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager
+}
+
+// scrape - does a request to the exporter handler
+func scrape(t *testing.T, exporter *timeline.PrometheusExporter) string {
+
+	recorder := httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code, "expected status ok")
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"), "expected the exposition format content type")
+
+	return recorder.Body.String()
+}
+
+// TestExporterStandalone - tests the exporter without a push transport
+func TestExporterStandalone(t *testing.T) {
+
+	exporter, err := timeline.NewPrometheusExporter(&timeline.PrometheusExporterConfig{}, nil)
+	if !assert.NoError(t, err, "expected no error creating the exporter") {
+		return
+	}
+
+	m := createExporterManager(t, exporter, &timeline.Backend{})
+	defer m.Shutdown()
+
+	hash, err := m.StoreDataToAccumulateOpenTSDB(time.Minute, 0, 0, "http.requests", "path", `/a"b`)
+	if !assert.NoError(t, err, "expected no error storing data") {
+		return
+	}
+
+	for _, v := range []float64{10, 30} {
+		assert.NoError(t, m.FlattenOpenTSDB(timeline.Max, v, 0, "queue.size", "host", "h1"))
+		assert.NoError(t, m.FlattenOpenTSDB(timeline.Min, v, 0, "queue.size", "host", "h2"))
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, m.IncrementAccumulatedData(hash))
+	}
+
+	m.ProcessCycle()
+
+	expected := "# TYPE http_requests_total counter\n" +
+		"http_requests_total{path=\"/a\\\"b\"} 3\n" +
+		"# TYPE queue_size gauge\n" +
+		"queue_size{host=\"h1\"} 30\n" +
+		"queue_size{host=\"h2\"} 10\n"
+
+	assert.Equal(t, expected, scrape(t, exporter), "expected the exported series")
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, m.IncrementAccumulatedData(hash))
+	}
+
+	assert.NoError(t, m.FlattenOpenTSDB(timeline.Max, 5, 0, "queue.size", "host", "h1"))
+
+	m.ProcessCycle()
+
+	expected = "# TYPE http_requests_total counter\n" +
+		"http_requests_total{path=\"/a\\\"b\"} 5\n" +
+		"# TYPE queue_size gauge\n" +
+		"queue_size{host=\"h1\"} 5\n" +
+		"queue_size{host=\"h2\"} 10\n"
+
+	assert.Equal(t, expected, scrape(t, exporter), "expected the cumulative counter and the last gauge values")
+}
+
+// TestExporterWithTransport - tests the exporter wrapping a push transport
+func TestExporterWithTransport(t *testing.T) {
+
+	b := createBackend(t, http.StatusOK)
+	defer b.server.Close()
+
+	transport, err := timeline.NewPrometheusRemoteWriteTransport(createRemoteWriteConfig())
+	if !assert.NoError(t, err, "expected no error creating the transport") {
+		return
+	}
+
+	exporter, err := timeline.NewPrometheusExporter(&timeline.PrometheusExporterConfig{}, transport)
+	if !assert.NoError(t, err, "expected no error creating the exporter") {
+		return
+	}
+
+	m := createExporterManager(t, exporter, b.backend(t))
+	defer m.Shutdown()
+
+	assert.NoError(t, m.FlattenOpenTSDB(timeline.Sum, 2, 0, "bytes", "host", "h1"))
+	assert.NoError(t, m.FlattenOpenTSDB(timeline.Sum, 3, 0, "bytes", "host", "h1"))
+
+	m.ProcessCycle()
+	m.SendData()
+
+	assert.Equal(t, "# TYPE bytes gauge\nbytes{host=\"h1\"} 5\n", scrape(t, exporter), "expected the exported gauge")
+
+	r := b.waitRequest(5 * time.Second)
+	if !assert.NotNil(t, r, "expected the pushed points") {
+		return
+	}
+
+	series := decodeWriteRequest(t, r.body)
+	if assert.Len(t, series, 1, "expected one pushed series") {
+		assert.Equal(t, []float64{5}, series[0].values, "expected the pushed sum")
+	}
+}
+
+// TestExporterSeriesTTL - tests the expiration of the series not updated
+func TestExporterSeriesTTL(t *testing.T) {
+
+	exporter, err := timeline.NewPrometheusExporter(&timeline.PrometheusExporterConfig{
+		SeriesTTL: funks.Duration{Duration: 200 * time.Millisecond},
+	}, nil)
+	if !assert.NoError(t, err, "expected no error creating the exporter") {
+		return
+	}
+
+	m := createExporterManager(t, exporter, &timeline.Backend{})
+	defer m.Shutdown()
+
+	assert.NoError(t, m.FlattenOpenTSDB(timeline.Avg, 1, 0, "temp", "room", "a"))
+	m.ProcessCycle()
+
+	assert.Equal(t, "# TYPE temp gauge\ntemp{room=\"a\"} 1\n", scrape(t, exporter), "expected the gauge")
+
+	<-time.After(300 * time.Millisecond)
+
+	assert.Empty(t, scrape(t, exporter), "expected the series to be expired")
+}
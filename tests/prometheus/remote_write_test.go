@@ -0,0 +1,220 @@
+package timeline_prometheus_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// TestRemoteWrite - tests the write request encoding and the required headers
+func TestRemoteWrite(t *testing.T) {
+
+	b := createBackend(t, http.StatusNoContent)
+	defer b.server.Close()
+
+	m := createRemoteWriteManager(t, createRemoteWriteConfig(), b.backend(t))
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	assert.NoError(t, m.SendOpenTSDB(1, now+1, "sys.cpu-usage", "host", "h1", "1dc", "us", "empty", ""))
+	assert.NoError(t, m.SendOpenTSDB(2, now, "sys.cpu-usage", "host", "h1", "1dc", "us"))
+	assert.NoError(t, m.SendOpenTSDB(3, now, "mem", "host", "h2"))
+
+	m.SendData()
+
+	r := b.waitRequest(5 * time.Second)
+	if !assert.NotNil(t, r, "expected a request") {
+		return
+	}
+
+	assert.Equal(t, "/api/v1/write", r.uri, "expected the default endpoint")
+	assert.Equal(t, "snappy", r.headers.Get("Content-Encoding"), "expected the content encoding")
+	assert.Equal(t, "application/x-protobuf", r.headers.Get("Content-Type"), "expected the content type")
+	assert.Equal(t, "0.1.0", r.headers.Get("X-Prometheus-Remote-Write-Version"), "expected the remote write version")
+
+	series := decodeWriteRequest(t, r.body)
+	if !assert.Len(t, series, 2, "expected the samples grouped by series") {
+		return
+	}
+
+	assert.Equal(t, map[string]string{"__name__": "sys_cpu_usage", "host": "h1", "_1dc": "us"}, series[0].labels, "expected sanitized labels")
+	assert.Equal(t, []float64{2, 1}, series[0].values, "expected the samples sorted by timestamp")
+	assert.Equal(t, []int64{now * 1000, (now + 1) * 1000}, series[0].timestamps, "expected the timestamps in milliseconds")
+
+	assert.Equal(t, map[string]string{"__name__": "mem", "host": "h2"}, series[1].labels, "expected the second series")
+	assert.Equal(t, []float64{3}, series[1].values, "expected the second series value")
+}
+
+// TestRemoteWriteReservedLabels - tests if the tags using the reserved prefix does not replace the metric name
+func TestRemoteWriteReservedLabels(t *testing.T) {
+
+	b := createBackend(t, http.StatusNoContent)
+	defer b.server.Close()
+
+	m := createRemoteWriteManager(t, createRemoteWriteConfig(), b.backend(t))
+	defer m.Shutdown()
+
+	assert.NoError(t, m.SendOpenTSDB(1, time.Now().Unix(), "cpu", "__name__", "other", "__meta", "x", "host", "h1"))
+
+	m.SendData()
+
+	r := b.waitRequest(5 * time.Second)
+	if !assert.NotNil(t, r, "expected a request") {
+		return
+	}
+
+	series := decodeWriteRequest(t, r.body)
+	if assert.Len(t, series, 1, "expected one series") {
+		assert.Equal(t, map[string]string{"__name__": "cpu", "tag__name__": "other", "tag__meta": "x", "host": "h1"}, series[0].labels, "expected the reserved labels prefixed")
+	}
+}
+
+// TestRemoteWriteRetry - tests if the server errors are retried
+func TestRemoteWriteRetry(t *testing.T) {
+
+	b := createBackend(t, http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK)
+	defer b.server.Close()
+
+	m := createRemoteWriteManager(t, createRemoteWriteConfig(), b.backend(t))
+	defer m.Shutdown()
+
+	assert.NoError(t, m.SendOpenTSDB(1, 0, "metric", "host", "h1"))
+	assert.NoError(t, m.SendData(), "expected success after the retries")
+
+	for i := 0; i < 3; i++ {
+		assert.NotNilf(t, b.waitRequest(time.Second), "expected the request %d", i)
+	}
+}
+
+// TestRemoteWriteNoRetry - tests if the client errors and the rate limit (disabled) are not retried
+func TestRemoteWriteNoRetry(t *testing.T) {
+
+	for _, status := range []int{http.StatusBadRequest, http.StatusTooManyRequests} {
+
+		b := createBackend(t, status, http.StatusOK)
+
+		m := createRemoteWriteManager(t, createRemoteWriteConfig(), b.backend(t))
+
+		assert.NoError(t, m.SendOpenTSDB(1, 0, "metric", "host", "h1"))
+		assert.Errorf(t, m.SendData(), "expected error on status %d", status)
+		assert.NotNil(t, b.waitRequest(time.Second), "expected one request")
+		assert.Nilf(t, b.waitRequest(200*time.Millisecond), "expected no retries on status %d", status)
+
+		m.Shutdown()
+		b.server.Close()
+	}
+}
+
+// TestRemoteWriteRateLimit - tests the rate limit retry
+func TestRemoteWriteRateLimit(t *testing.T) {
+
+	b := createBackend(t, http.StatusTooManyRequests, http.StatusOK)
+	defer b.server.Close()
+
+	conf := createRemoteWriteConfig()
+	conf.RetryOnRateLimit = true
+
+	m := createRemoteWriteManager(t, conf, b.backend(t))
+	defer m.Shutdown()
+
+	assert.NoError(t, m.SendOpenTSDB(1, 0, "metric", "host", "h1"))
+	assert.NoError(t, m.SendData(), "expected success after the retry")
+}
+
+// TestRemoteWriteRetryAfter - tests the retry after header is limited by the maximum backoff
+func TestRemoteWriteRetryAfter(t *testing.T) {
+
+	b := createBackendWithHeaders(t, http.Header{"Retry-After": []string{"3600"}}, http.StatusTooManyRequests, http.StatusOK)
+	defer b.server.Close()
+
+	conf := createRemoteWriteConfig()
+	conf.RetryOnRateLimit = true
+
+	m := createRemoteWriteManager(t, conf, b.backend(t))
+	defer m.Shutdown()
+
+	assert.NoError(t, m.SendOpenTSDB(1, 0, "metric", "host", "h1"))
+
+	start := time.Now()
+	assert.NoError(t, m.SendData(), "expected success after the retry")
+	assert.True(t, time.Since(start) < time.Second, "expected the wait limited by the maximum backoff: %s", time.Since(start))
+}
+
+// TestRemoteWriteRetriesExhausted - tests the maximum number of retries
+func TestRemoteWriteRetriesExhausted(t *testing.T) {
+
+	b := createBackend(t, http.StatusInternalServerError)
+	defer b.server.Close()
+
+	conf := createRemoteWriteConfig()
+	conf.MaxRetries = 2
+
+	m := createRemoteWriteManager(t, conf, b.backend(t))
+	defer m.Shutdown()
+
+	assert.NoError(t, m.SendOpenTSDB(1, 0, "metric", "host", "h1"))
+	assert.Error(t, m.SendData(), "expected error after the retries")
+
+	for i := 0; i < 3; i++ {
+		assert.NotNilf(t, b.waitRequest(time.Second), "expected the request %d", i)
+	}
+
+	assert.Nil(t, b.waitRequest(200*time.Millisecond), "expected no more requests")
+}
+
+// TestRemoteWriteFlatten - tests the flattener using the remote write transport
+func TestRemoteWriteFlatten(t *testing.T) {
+
+	b := createBackend(t, http.StatusOK)
+	defer b.server.Close()
+
+	m := createRemoteWriteManager(t, createRemoteWriteConfig(), b.backend(t))
+	defer m.Shutdown()
+
+	now := time.Now().Unix()
+
+	for _, v := range []float64{1, 2, 3} {
+		assert.NoError(t, m.FlattenOpenTSDB(timeline.Sum, v, now, "requests", "host", "h1"))
+	}
+
+	m.ProcessCycle()
+	m.SendData()
+
+	r := b.waitRequest(5 * time.Second)
+	if !assert.NotNil(t, r, "expected a request") {
+		return
+	}
+
+	series := decodeWriteRequest(t, r.body)
+	if !assert.Len(t, series, 1, "expected one series") {
+		return
+	}
+
+	assert.Equal(t, []float64{6}, series[0].values, "expected the sum")
+}
+
+// TestRemoteWriteConfig - tests the configuration errors
+func TestRemoteWriteConfig(t *testing.T) {
+
+	_, err := timeline.NewPrometheusRemoteWriteTransport(nil)
+	assert.Error(t, err, "expected error on null configuration")
+
+	conf := createRemoteWriteConfig()
+	conf.MaxRetries = -1
+	_, err = timeline.NewPrometheusRemoteWriteTransport(conf)
+	assert.Error(t, err, "expected error on negative retries")
+
+	conf = createRemoteWriteConfig()
+	conf.MaxBackoff.Duration = time.Millisecond
+	_, err = timeline.NewPrometheusRemoteWriteTransport(conf)
+	assert.Error(t, err, "expected error on invalid backoff")
+}
@@ -0,0 +1,90 @@
+package timeline_statsd_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/gotest/tcpudp"
+	"github.com/uol/hashing"
+	serializer "github.com/uol/serializer/opentsdb"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+var (
+	defaultConf tcpudp.ServerConfiguration = tcpudp.ServerConfiguration{
+		Host:               "localhost",
+		MessageChannelSize: 100,
+		ReadBufferSize:     65536,
+	}
+)
+
+const (
+	defaultTransportSize int = 100
+)
+
+// createStatsDTransportConfig - creates the statsd transport configuration
+func createStatsDTransportConfig(dogStatsD bool) *timeline.StatsDTransportConfig {
+
+	return &timeline.StatsDTransportConfig{
+		DefaultTransportConfig: timeline.DefaultTransportConfig{
+			BatchSendInterval:    funks.Duration{Duration: time.Second},
+			RequestTimeout:       funks.Duration{Duration: time.Second},
+			TransportBufferSize:  defaultTransportSize,
+			SerializerBufferSize: 1024,
+			TimeBetweenBatches:   funks.Duration{Duration: 10 * time.Millisecond},
+		},
+		TCPUDPTransportConfig: timeline.TCPUDPTransportConfig{
+			ReconnectionTimeout:    funks.Duration{Duration: time.Second},
+			MaxReconnectionRetries: 3,
+		},
+		DogStatsD: dogStatsD,
+	}
+}
+
+// createStatsDManager - creates a manual mode manager using the statsd transport
+func createStatsDManager(t *testing.T, conf *timeline.StatsDTransportConfig, port int) *timeline.Manager {
+
+	transport, err := timeline.NewStatsDTransport(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dtc := &timeline.DataTransformerConfig{
+		CycleDuration:    funks.Duration{Duration: time.Hour},
+		HashingAlgorithm: hashing.SHA256,
+	}
+
+	manager, err := timeline.NewManager(
+		transport,
+		timeline.NewFlattener(dtc),
+		timeline.NewAccumulator(dtc),
+		&timeline.Backend{Host: defaultConf.Host, Port: port},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = manager.Start(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager
+}
+
+// newItem - creates a new opentsdb item
+func newItem(metric string, value float64) *serializer.ArrayItem {
+
+	return &serializer.ArrayItem{
+		Metric:    metric,
+		Timestamp: time.Now().Unix(),
+		Value:     value,
+		Tags:      []interface{}{"host", "h1"},
+	}
+}
@@ -0,0 +1,185 @@
+package timeline_statsd_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gotest/tcpudp"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// receiveLines - receives the datagrams until the expected number of lines
+func receiveLines(t *testing.T, s *tcpudp.UDPServer, numLines, maxSize int) (lines []string, numPackets int) {
+
+	for len(lines) < numLines {
+
+		select {
+		case message := <-s.MessageChannel():
+			numPackets++
+			assert.LessOrEqual(t, len(message.Message), maxSize, "expected packets inside the maximum size")
+			lines = append(lines, strings.Split(message.Message, "\n")...)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "expected all lines")
+			return
+		}
+	}
+
+	sort.Strings(lines)
+
+	return
+}
+
+// TestStatsDTypes - tests all statsd types
+func TestStatsDTypes(t *testing.T) {
+
+	s, port := tcpudp.NewUDPServer(&defaultConf, true)
+	defer s.Stop()
+
+	conf := createStatsDTransportConfig(false)
+
+	m := createStatsDManager(t, conf, port)
+	defer m.Shutdown()
+
+	assert.NoError(t, m.SendStatsD(timeline.StatsDCounter, 1, "requests", "host", "h1"))
+	assert.NoError(t, m.SendStatsD(timeline.StatsDGauge, 0.5, "cpu"))
+	assert.NoError(t, m.SendStatsD(timeline.StatsDTimer, 320, "latency"))
+	assert.NoError(t, m.SendStatsD(timeline.StatsDSet, 42, "users"))
+	assert.NoError(t, m.SendStatsD(timeline.StatsDHistogram, 7, "size"))
+	assert.NoError(t, m.SendStatsD(timeline.StatsDDistribution, 3, "dist:name"))
+
+	m.Send(&timeline.StatsDItem{ArrayItem: *newItem("sampled", 1), Type: timeline.StatsDCounter, SampleRate: 0.1})
+
+	m.SendData()
+
+	lines, numPackets := receiveLines(t, s, 7, conf.MaxDatagramSize)
+
+	expected := []string{
+		"cpu:0.5|g",
+		"dist_name:3|d",
+		"latency:320|ms",
+		"requests:1|c",
+		"sampled:1|c|@0.1",
+		"size:7|h",
+		"users:42|s",
+	}
+
+	assert.Equal(t, expected, lines, "expected all types without tags")
+	assert.Equal(t, 1, numPackets, "expected all lines in one packet")
+}
+
+// TestDogStatsDTags - tests the dogstatsd tags
+func TestDogStatsDTags(t *testing.T) {
+
+	s, port := tcpudp.NewUDPServer(&defaultConf, true)
+	defer s.Stop()
+
+	conf := createStatsDTransportConfig(true)
+
+	m := createStatsDManager(t, conf, port)
+	defer m.Shutdown()
+
+	assert.NoError(t, m.SendStatsD(timeline.StatsDCounter, 2, "requests", "host", "h1", "env", "", "path", "/a,b"))
+	assert.NoError(t, m.SendStatsD(timeline.StatsDGauge, 1, "up"))
+
+	m.SendData()
+
+	lines, _ := receiveLines(t, s, 2, conf.MaxDatagramSize)
+
+	expected := []string{
+		"requests:2|c|#host:h1,env,path:/a_b",
+		"up:1|g",
+	}
+
+	assert.Equal(t, expected, lines, "expected the dogstatsd tags")
+}
+
+// TestStatsDPacking - tests if the lines are packed respecting the maximum datagram size
+func TestStatsDPacking(t *testing.T) {
+
+	s, port := tcpudp.NewUDPServer(&defaultConf, true)
+	defer s.Stop()
+
+	conf := createStatsDTransportConfig(false)
+	conf.MaxDatagramSize = 40
+
+	m := createStatsDManager(t, conf, port)
+	defer m.Shutdown()
+
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, m.SendStatsD(timeline.StatsDCounter, float64(i), "metric.number"))
+	}
+
+	assert.NoError(t, m.SendStatsD(timeline.StatsDCounter, 1, strings.Repeat("x", 50)))
+
+	m.SendData()
+
+	lines, numPackets := receiveLines(t, s, 6, conf.MaxDatagramSize)
+
+	assert.Len(t, lines, 6, "expected the oversized line to be dropped")
+	assert.Equal(t, 3, numPackets, "expected two lines per packet")
+}
+
+// TestStatsDFlattenAndAccumulate - tests the data processors mapping to statsd types
+func TestStatsDFlattenAndAccumulate(t *testing.T) {
+
+	s, port := tcpudp.NewUDPServer(&defaultConf, true)
+	defer s.Stop()
+
+	conf := createStatsDTransportConfig(true)
+
+	m := createStatsDManager(t, conf, port)
+	defer m.Shutdown()
+
+	for _, v := range []float64{1, 2, 3} {
+		assert.NoError(t, m.FlattenStatsD(timeline.Sum, v, "bytes", "host", "h1"))
+		assert.NoError(t, m.FlattenStatsD(timeline.Max, v, "queue", "host", "h1"))
+	}
+
+	hash, err := m.StoreDataToAccumulate(time.Minute, &timeline.StatsDItem{ArrayItem: *newItem("hits", 0)})
+	if !assert.NoError(t, err, "expected no error storing data") {
+		return
+	}
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, m.IncrementAccumulatedData(hash))
+	}
+
+	m.ProcessCycle()
+	m.SendData()
+
+	lines, _ := receiveLines(t, s, 3, conf.MaxDatagramSize)
+
+	expected := []string{
+		"bytes:6|c|#host:h1",
+		"hits:4|c|#host:h1",
+		"queue:3|g|#host:h1",
+	}
+
+	assert.Equal(t, expected, lines, "expected counters and gauges")
+}
+
+// TestStatsDInvalidItems - tests the serialization errors
+func TestStatsDInvalidItems(t *testing.T) {
+
+	transport, err := timeline.NewStatsDTransport(createStatsDTransportConfig(false))
+	if !assert.NoError(t, err, "expected no error creating the transport") {
+		return
+	}
+
+	_, err = transport.Serialize(&timeline.StatsDItem{ArrayItem: *newItem("m", 1), Type: "x"})
+	assert.Error(t, err, "expected error on invalid type")
+
+	_, err = transport.Serialize(&timeline.StatsDItem{ArrayItem: *newItem("m", 1), Type: timeline.StatsDCounter, SampleRate: 2})
+	assert.Error(t, err, "expected error on invalid sample rate")
+
+	_, err = transport.Serialize("text")
+	assert.Error(t, err, "expected error on unknown item")
+}
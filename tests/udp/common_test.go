@@ -32,10 +32,10 @@ const (
 	numberPoint          string = "numberJSON"
 )
 
-// createUDPTransport - creates the udp transport with custom batch send interval
-func createUDPTransport(transportBufferSize int, batchSendInterval time.Duration, s serializer.Serializer) *timeline.UDPTransport {
+// createUDPTransportConfig - creates the udp transport configuration with custom batch send interval
+func createUDPTransportConfig(transportBufferSize int, batchSendInterval time.Duration) *timeline.UDPTransportConfig {
 
-	transportConf := timeline.UDPTransportConfig{
+	return &timeline.UDPTransportConfig{
 		DefaultTransportConfig: timeline.DefaultTransportConfig{
 			RequestTimeout: funks.Duration{
 				Duration: time.Second,
@@ -56,27 +56,37 @@ func createUDPTransport(transportBufferSize int, batchSendInterval time.Duration
 			ValueProperty:     "value",
 		},
 	}
+}
+
+// createNumberSerializer - creates the json serializer with the number point
+func createNumberSerializer() serializer.Serializer {
+
+	jsons := jsonserializer.New(256)
+
+	err := jsons.Add(
+		numberPoint,
+		jsonserializer.NumberPoint{},
+		"metric",
+		"value",
+		"timestamp",
+		"tags",
+	)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return jsons
+}
+
+// createUDPTransport - creates the udp transport with custom batch send interval
+func createUDPTransport(transportBufferSize int, batchSendInterval time.Duration, s serializer.Serializer) *timeline.UDPTransport {
 
 	if s == nil {
-		jsons := jsonserializer.New(256)
-
-		err := jsons.Add(
-			numberPoint,
-			jsonserializer.NumberPoint{},
-			"metric",
-			"value",
-			"timestamp",
-			"tags",
-		)
-
-		if err != nil {
-			panic(err)
-		}
-
-		s = jsons
+		s = createNumberSerializer()
 	}
 
-	transport, err := timeline.NewUDPTransport(&transportConf, s)
+	transport, err := timeline.NewUDPTransport(createUDPTransportConfig(transportBufferSize, batchSendInterval), s)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,185 @@
+package timeline_udp_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/gotest/tcpudp"
+	jsonserializer "github.com/uol/serializer/json"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+var packingConf tcpudp.ServerConfiguration = tcpudp.ServerConfiguration{
+	Host:               "localhost",
+	MessageChannelSize: 100,
+	ReadBufferSize:     65536,
+}
+
+// createPackingManager - creates a manual mode manager packing the points in datagrams
+func createPackingManager(t *testing.T, port, maxDatagramSize int, separator string) (*timeline.Manager, *timeline.UDPTransport) {
+
+	conf := createUDPTransportConfig(defaultTransportSize, time.Second)
+	conf.MaxDatagramSize = maxDatagramSize
+	conf.DatagramSeparator = separator
+
+	transport, err := timeline.NewUDPTransport(conf, createNumberSerializer())
+	if !assert.NoError(t, err, "expected no error creating the transport") {
+		return nil, nil
+	}
+
+	m, err := timeline.NewManager(transport, nil, nil, &timeline.Backend{Host: defaultConf.Host, Port: port})
+	if !assert.NoError(t, err, "expected no error creating the manager") {
+		return nil, nil
+	}
+
+	err = m.Start(true)
+	if !assert.NoError(t, err, "expected no error starting the manager") {
+		return nil, nil
+	}
+
+	return m, transport
+}
+
+// receivePackets - receives the packets until the timeout
+func receivePackets(s *tcpudp.UDPServer, timeout time.Duration) []string {
+
+	packets := []string{}
+
+	for {
+		select {
+		case message := <-s.MessageChannel():
+			packets = append(packets, message.Message)
+		case <-time.After(timeout):
+			return packets
+		}
+	}
+}
+
+// TestDatagramPacking - tests if the points are packed using the separator and the maximum size
+func TestDatagramPacking(t *testing.T) {
+
+	s, port := tcpudp.NewUDPServer(&packingConf, true)
+	defer s.Stop()
+
+	m, transport := createPackingManager(t, port, 400, "|")
+	if m == nil {
+		return
+	}
+	defer m.Shutdown()
+
+	numPoints := 6
+	for i := 0; i < numPoints; i++ {
+		assert.NoError(t, m.SendJSON(numberPoint, toGenericParametersN(newNumberPoint(float64(i)))...))
+	}
+
+	oversized := newNumberPoint(1)
+	oversized.Tags["big"] = strings.Repeat("x", 400)
+	assert.NoError(t, m.SendJSON(numberPoint, toGenericParametersN(oversized)...))
+
+	m.SendData()
+
+	packets := receivePackets(s, time.Second)
+	if !assert.True(t, len(packets) > 1 && len(packets) < numPoints, "expected the points packed in some datagrams: %d", len(packets)) {
+		return
+	}
+
+	received := 0
+
+	for _, packet := range packets {
+
+		assert.LessOrEqual(t, len(packet), 400, "expected packets inside the maximum size")
+
+		for _, serialized := range strings.Split(packet, "|") {
+			var point jsonserializer.NumberPoint
+			if assert.NoError(t, json.Unmarshal([]byte(serialized), &point), "expected a valid json point") {
+				received++
+			}
+		}
+	}
+
+	assert.Equal(t, numPoints, received, "expected all points except the oversized one")
+	assert.Equal(t, uint64(1), transport.GetStats().OversizedPoints, "expected the oversized point to be counted")
+}
+
+// TestDatagramOversizedBatch - tests a batch with oversized points only does not stop the next batches
+func TestDatagramOversizedBatch(t *testing.T) {
+
+	s, port := tcpudp.NewUDPServer(&packingConf, true)
+	defer s.Stop()
+
+	conf := createUDPTransportConfig(2, time.Second)
+	conf.MaxDatagramSize = 400
+
+	transport, err := timeline.NewUDPTransport(conf, createNumberSerializer())
+	if !assert.NoError(t, err, "expected no error creating the transport") {
+		return
+	}
+
+	m, err := timeline.NewManager(transport, nil, nil, &timeline.Backend{Host: defaultConf.Host, Port: port})
+	if !assert.NoError(t, err, "expected no error creating the manager") {
+		return
+	}
+
+	if !assert.NoError(t, m.Start(true), "expected no error starting the manager") {
+		return
+	}
+	defer m.Shutdown()
+
+	for i := 0; i < 2; i++ {
+		oversized := newNumberPoint(float64(i))
+		oversized.Tags["big"] = strings.Repeat("x", 400)
+		assert.NoError(t, m.SendJSON(numberPoint, toGenericParametersN(oversized)...))
+	}
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, m.SendJSON(numberPoint, toGenericParametersN(newNumberPoint(float64(i)))...))
+	}
+
+	assert.NoError(t, m.SendData(), "expected no error when a batch is fully dropped")
+
+	packets := receivePackets(s, time.Second)
+	if assert.Len(t, packets, 1, "expected the valid batch packed in one datagram") {
+		assert.Len(t, strings.Split(packets[0], "\n"), 2, "expected the two valid points")
+	}
+
+	assert.Equal(t, uint64(2), transport.GetStats().OversizedPoints, "expected the oversized points to be counted")
+}
+
+// TestDatagramNoPacking - tests the default behaviour (one datagram per point)
+func TestDatagramNoPacking(t *testing.T) {
+
+	s, port := tcpudp.NewUDPServer(&packingConf, true)
+	defer s.Stop()
+
+	m, _ := createPackingManager(t, port, 0, "")
+	if m == nil {
+		return
+	}
+	defer m.Shutdown()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, m.SendJSON(numberPoint, toGenericParametersN(newNumberPoint(float64(i)))...))
+	}
+
+	m.SendData()
+
+	assert.Len(t, receivePackets(s, time.Second), 3, "expected one datagram per point")
+}
+
+// TestDatagramPackingConfig - tests the configuration errors
+func TestDatagramPackingConfig(t *testing.T) {
+
+	conf := createUDPTransportConfig(defaultTransportSize, time.Second)
+	conf.MaxDatagramSize = -1
+
+	_, err := timeline.NewUDPTransport(conf, createNumberSerializer())
+	assert.Error(t, err, "expected error on negative datagram size")
+}
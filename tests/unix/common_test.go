@@ -0,0 +1,212 @@
+package timeline_unix_test
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/uol/funks"
+	"github.com/uol/hashing"
+	jsonserializer "github.com/uol/serializer/json"
+	"github.com/uol/serializer/serializer"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+const (
+	defaultTransportSize int    = 100
+	numberPoint          string = "numberJSON"
+)
+
+// socketServer - a unix socket server collecting the received lines or datagrams
+type socketServer struct {
+	path        string
+	dir         string
+	listener    *net.UnixListener
+	packetConn  *net.UnixConn
+	messages    chan string
+	connections chan struct{}
+}
+
+// createSocketPath - creates a temporary socket path
+func createSocketPath(t *testing.T) (string, string) {
+
+	dir, err := ioutil.TempDir("", "timeline-unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, filepath.Join(dir, "timeline.sock")
+}
+
+// createStreamServer - creates a unix stream server sending each received line to the channel
+func createStreamServer(t *testing.T) *socketServer {
+
+	dir, path := createSocketPath(t)
+
+	listener, err := net.ListenUnix(timeline.UnixStream, &net.UnixAddr{Name: path, Net: timeline.UnixStream})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &socketServer{
+		path:        path,
+		dir:         dir,
+		listener:    listener,
+		messages:    make(chan string, 100),
+		connections: make(chan struct{}, 10),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			s.connections <- struct{}{}
+
+			go func(c net.Conn) {
+				defer c.Close()
+				scanner := bufio.NewScanner(c)
+				for scanner.Scan() {
+					s.messages <- scanner.Text()
+				}
+			}(conn)
+		}
+	}()
+
+	return s
+}
+
+// createDatagramServer - creates a unix datagram server sending each received datagram to the channel
+func createDatagramServer(t *testing.T) *socketServer {
+
+	dir, path := createSocketPath(t)
+
+	conn, err := net.ListenUnixgram(timeline.UnixDatagram, &net.UnixAddr{Name: path, Net: timeline.UnixDatagram})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &socketServer{
+		path:       path,
+		dir:        dir,
+		packetConn: conn,
+		messages:   make(chan string, 100),
+	}
+
+	go func() {
+		buffer := make([]byte, 65536)
+		for {
+			n, _, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			s.messages <- string(buffer[:n])
+		}
+	}()
+
+	return s
+}
+
+// close - closes the server and removes the socket
+func (s *socketServer) close() {
+
+	if s.listener != nil {
+		s.listener.Close()
+	}
+
+	if s.packetConn != nil {
+		s.packetConn.Close()
+	}
+
+	os.RemoveAll(s.dir)
+}
+
+// waitMessages - waits for the number of messages
+func (s *socketServer) waitMessages(n int, timeout time.Duration) []string {
+
+	result := []string{}
+
+	for len(result) < n {
+		select {
+		case m := <-s.messages:
+			result = append(result, m)
+		case <-time.After(timeout):
+			return result
+		}
+	}
+
+	return result
+}
+
+// createUnixConfig - creates the unix transport configuration
+func createUnixConfig(network, path string) *timeline.UnixTransportConfig {
+
+	return &timeline.UnixTransportConfig{
+		DefaultTransportConfig: timeline.DefaultTransportConfig{
+			BatchSendInterval:    funks.Duration{Duration: time.Second},
+			RequestTimeout:       funks.Duration{Duration: time.Second},
+			TransportBufferSize:  defaultTransportSize,
+			SerializerBufferSize: 256,
+			TimeBetweenBatches:   funks.Duration{Duration: 10 * time.Millisecond},
+		},
+		TCPUDPTransportConfig: timeline.TCPUDPTransportConfig{
+			ReconnectionTimeout: funks.Duration{Duration: 100 * time.Millisecond},
+		},
+		Network:    network,
+		SocketPath: path,
+		CustomSerializerConfig: timeline.CustomSerializerConfig{
+			TimestampProperty: "timestamp",
+			ValueProperty:     "value",
+		},
+	}
+}
+
+// createJSONSerializer - creates the json serializer
+func createJSONSerializer(t *testing.T) *jsonserializer.Serializer {
+
+	s := jsonserializer.New(256)
+
+	err := s.Add(numberPoint, jsonserializer.NumberPoint{}, "metric", "value", "timestamp", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// createUnixManager - creates a manual mode manager using the unix transport
+func createUnixManager(t *testing.T, conf *timeline.UnixTransportConfig, s serializer.Serializer) *timeline.Manager {
+
+	transport, err := timeline.NewUnixTransport(conf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dtc := &timeline.DataTransformerConfig{
+		CycleDuration:    funks.Duration{Duration: time.Hour},
+		HashingAlgorithm: hashing.SHA256,
+	}
+
+	manager, err := timeline.NewManager(transport, timeline.NewFlattener(dtc), timeline.NewAccumulator(dtc), &timeline.Backend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = manager.Start(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manager
+}
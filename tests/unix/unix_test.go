@@ -0,0 +1,95 @@
+package timeline_unix_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uol/timeline"
+)
+
+/**
+* The timeline library tests.
+* @author rnojiri
+**/
+
+// TestStreamOpenTSDB - tests the opentsdb lines over the unix stream socket
+func TestStreamOpenTSDB(t *testing.T) {
+
+	s := createStreamServer(t)
+	defer s.close()
+
+	m := createUnixManager(t, createUnixConfig(timeline.UnixStream, s.path), nil)
+	defer m.Shutdown()
+
+	assert.NoError(t, m.SendOpenTSDB(1, 10, "cpu", "host", "h1"))
+	assert.NoError(t, m.SendOpenTSDB(2.5, 20, "mem", "host", "h2"))
+	assert.NoError(t, m.SendData(), "expected no error sending data")
+
+	assert.Equal(t, []string{"put cpu 10 1 host=h1", "put mem 20 2.5 host=h2"}, s.waitMessages(2, 2*time.Second), "expected the opentsdb lines")
+
+	assert.Error(t, m.SendJSON(numberPoint, "metric", "x"), "expected the json items to be refused")
+}
+
+// TestStreamDisconnectAfterWrites - tests the reconnection after each write
+func TestStreamDisconnectAfterWrites(t *testing.T) {
+
+	s := createStreamServer(t)
+	defer s.close()
+
+	conf := createUnixConfig(timeline.UnixStream, s.path)
+	conf.DisconnectAfterWrites = true
+
+	m := createUnixManager(t, conf, nil)
+	defer m.Shutdown()
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, m.SendOpenTSDB(float64(i), 10, "cpu"))
+		assert.NoError(t, m.SendData(), "expected no error sending data")
+		assert.Len(t, s.waitMessages(1, 2*time.Second), 1, "expected the line")
+	}
+
+	assert.Len(t, s.connections, 2, "expected one connection per write")
+}
+
+// TestDatagramJSON - tests the json items over the unix datagram socket
+func TestDatagramJSON(t *testing.T) {
+
+	s := createDatagramServer(t)
+	defer s.close()
+
+	conf := createUnixConfig(timeline.UnixDatagram, s.path)
+	conf.MaxDatagramSize = 1024
+
+	m := createUnixManager(t, conf, createJSONSerializer(t))
+	defer m.Shutdown()
+
+	for _, metric := range []string{"a", "b"} {
+		err := m.SendJSON(numberPoint, "metric", metric, "value", 1.0, "timestamp", int64(10), "tags", map[string]string{"host": "h1"})
+		assert.NoError(t, err, "expected no error sending json")
+	}
+
+	assert.NoError(t, m.SendData(), "expected no error sending data")
+
+	expected := `{"metric":"a","tags":{"host":"h1"},"timestamp":10,"value":1.000000}` + "\n" +
+		`{"metric":"b","tags":{"host":"h1"},"timestamp":10,"value":1.000000}`
+
+	assert.Equal(t, []string{expected}, s.waitMessages(1, 2*time.Second), "expected both points packed in one datagram")
+}
+
+// TestUnixConfig - tests the configuration validation
+func TestUnixConfig(t *testing.T) {
+
+	_, err := timeline.NewUnixTransport(createUnixConfig("tcp", "/tmp/x.sock"), nil)
+	assert.Error(t, err, "expected error with an invalid network")
+
+	conf := createUnixConfig(timeline.UnixStream, "/tmp/x.sock")
+	conf.MaxReadTimeout.Duration = time.Second
+	_, err = timeline.NewUnixTransport(conf, nil)
+	assert.Error(t, err, "expected error without the read buffer size")
+
+	conf = createUnixConfig(timeline.UnixStream, "/tmp/x.sock")
+	conf.TimestampProperty = ""
+	_, err = timeline.NewUnixTransport(conf, createJSONSerializer(t))
+	assert.Error(t, err, "expected error without the timestamp property")
+}
@@ -22,14 +22,20 @@ const (
 	typeHTTP     transportType = 1
 	typeOpenTSDB transportType = 2
 	typeUDP      transportType = 3
+	typeInflux   transportType = 4
+	typeGraphite transportType = 5
+	typeStatsD   transportType = 6
 )
 
 var (
 	// ErrInvalidPayloadSize - raised when the transport receives an invalid payload size
 	ErrInvalidPayloadSize error = errors.New("invalid payload size")
+
+	// ErrUnsupportedOperation - raised when the transport does not implement an optional operation
+	ErrUnsupportedOperation error = errors.New("operation not supported by the transport")
 )
 
-// Transport - the implementation type to send a event
+// Transport - the implementation type to send a event (only implemented by this package's transports, the optional operations are checked by type assertion)
 type Transport interface {
 
 	// Send - send a new point
@@ -75,6 +81,41 @@ type Transport interface {
 	BuildContextualLogger(path ...string)
 }
 
+// seriesPointConverter - implemented by the transports converting the items to series points (used by the point filters, the sampler and the deduplication)
+type seriesPointConverter interface {
+
+	// DataChannelItemToSeriesPoint - converts the data channel item to the series point
+	DataChannelItemToSeriesPoint(item interface{}) (*SeriesPoint, error)
+}
+
+// rollupConverter - implemented by the transports supporting the flattener rollups
+type rollupConverter interface {
+
+	// DataChannelItemToRollup - returns the data channel item without the tags removed by the rollup (nil if the rollup does not match)
+	DataChannelItemToRollup(item interface{}, rollup *FlattenRollup) (interface{}, error)
+}
+
+// pointFilterer - implemented by the transports supporting the point filters
+type pointFilterer interface {
+
+	// AddPointFilter - adds a filter to be applied before buffering the points (call it before Start())
+	AddPointFilter(filter PointFilter)
+}
+
+// statsProvider - implemented by the transports keeping statistics
+type statsProvider interface {
+
+	// GetStats - returns the transport statistics
+	GetStats() TransportStats
+}
+
+// TransportStats - the transport statistics
+type TransportStats struct {
+	DuplicatedPoints uint64
+	OversizedPoints  uint64
+	RejectedPoints   uint64
+}
+
 // Hashable - a struct with hash function
 type Hashable interface {
 
@@ -91,6 +132,11 @@ type transportCore struct {
 	started              uint32
 	defaultConfiguration *DefaultTransportConfig
 	manualMode           uint32
+	filters              []PointFilter
+	dedupeWindow         *hashWindow
+	duplicatedPoints     uint64
+	oversizedPoints      uint64
+	rejectedPoints       uint64
 }
 
 // Validate - validates the default itens from the configuration
@@ -112,6 +158,10 @@ func (c *DefaultTransportConfig) Validate() error {
 		return fmt.Errorf("invalid request timeout interval: %s", c.RequestTimeout)
 	}
 
+	if c.DeduplicationWindow.Duration < 0 {
+		return fmt.Errorf("invalid deduplication window: %s", c.DeduplicationWindow)
+	}
+
 	return nil
 }
 
@@ -127,6 +177,11 @@ func (t *transportCore) Start(manualMode bool) error {
 	}
 
 	t.pointBuffer = buffer.New()
+
+	if t.defaultConfiguration.DeduplicationWindow.Duration > 0 {
+		t.dedupeWindow = newHashWindow(t.defaultConfiguration.DeduplicationWindow.Duration)
+	}
+
 	atomic.StoreUint32(&t.started, 1)
 
 	if !manualMode {
@@ -201,6 +256,21 @@ func (t *transportCore) SendData() error {
 			return err
 		}
 
+		if size == 0 {
+			if logh.DebugEnabled {
+				t.loggers.Debug().Msg("no points left after serialization, nothing will be sent")
+			}
+			continue
+		}
+
+		// all points were dropped by the datagram packing (they were already counted)
+		if len(payload) == 0 {
+			if logh.DebugEnabled {
+				t.loggers.Debug().Msgf("all %d points were larger than the maximum datagram size, nothing will be sent", size)
+			}
+			continue
+		}
+
 		err = t.transport.TransferData(payload)
 		if err != nil {
 			if logh.ErrorEnabled {
@@ -260,6 +330,8 @@ func (t *transportCore) serialize(dataList []interface{}) (payload []string, siz
 		filtered = append(filtered, dataList[i])
 	}
 
+	filtered = t.deduplicate(filtered)
+
 	size = len(filtered)
 
 	t.debugInput(dataList)
@@ -318,9 +390,79 @@ func (t *transportCore) dataChannel(item interface{}) {
 	if k == reflect.Array || k == reflect.Slice {
 		v := reflect.ValueOf(item)
 		for i := 0; i < v.Len(); i++ {
-			t.pointBuffer.Add(v.Index(i).Interface())
+			t.addToBuffer(v.Index(i).Interface())
 		}
 	} else {
+		t.addToBuffer(item)
+	}
+}
+
+// addToBuffer - applies the filters and adds the item to the buffer
+func (t *transportCore) addToBuffer(item interface{}) {
+
+	if len(t.filters) == 0 {
 		t.pointBuffer.Add(item)
+		return
+	}
+
+	converter, ok := t.transport.(seriesPointConverter)
+	if !ok {
+		t.pointBuffer.Add(item)
+		return
+	}
+
+	point, err := converter.DataChannelItemToSeriesPoint(item)
+	if err != nil {
+		if logh.ErrorEnabled {
+			ev := t.loggers.Error()
+			if t.defaultConfiguration.PrintStackOnError {
+				ev = ev.Caller()
+			}
+			ev.Err(err).Msg("error converting item to series point, no filters were applied")
+		}
+
+		t.pointBuffer.Add(item)
+		return
+	}
+
+	for _, filter := range t.filters {
+		if !filter.Filter(point) {
+			return
+		}
+	}
+
+	t.pointBuffer.Add(item)
+}
+
+// addPointFilter - adds a new point filter
+func (t *transportCore) addPointFilter(filter PointFilter) {
+
+	t.filters = append(t.filters, filter)
+}
+
+// packDatagrams - packs the serialized points into datagrams, counting the dropped ones
+func (t *transportCore) packDatagrams(serialized []string, separator string, maxSize int) []string {
+
+	packets, dropped := packPayload(serialized, separator, maxSize)
+
+	if dropped > 0 {
+
+		atomic.AddUint64(&t.oversizedPoints, uint64(dropped))
+
+		if logh.WarnEnabled {
+			t.loggers.Warn().Msgf("%d points larger than the maximum datagram size (%d bytes) were dropped", dropped, maxSize)
+		}
+	}
+
+	return packets
+}
+
+// getStats - returns the transport statistics
+func (t *transportCore) getStats() TransportStats {
+
+	return TransportStats{
+		DuplicatedPoints: atomic.LoadUint64(&t.duplicatedPoints),
+		OversizedPoints:  atomic.LoadUint64(&t.oversizedPoints),
+		RejectedPoints:   atomic.LoadUint64(&t.rejectedPoints),
 	}
 }
@@ -34,6 +34,14 @@ func NewUDPTransport(configuration *UDPTransportConfig, customSerializer seriali
 		return nil, err
 	}
 
+	if configuration.MaxDatagramSize < 0 {
+		return nil, fmt.Errorf("invalid maximum datagram size: %d", configuration.MaxDatagramSize)
+	}
+
+	if configuration.MaxDatagramSize > 0 && len(configuration.DatagramSeparator) == 0 {
+		configuration.DatagramSeparator = defaultDatagramSeparator
+	}
+
 	t := &UDPTransport{
 		core: transportCore{
 			batchSendInterval:    configuration.BatchSendInterval.Duration,
@@ -101,6 +109,10 @@ func (t *UDPTransport) SerializePayload(dataList []interface{}) (payload []strin
 		payload[i] = serialized
 	}
 
+	if t.configuration.MaxDatagramSize > 0 {
+		payload = t.core.packDatagrams(payload, t.configuration.DatagramSeparator, t.configuration.MaxDatagramSize)
+	}
+
 	return
 }
 
@@ -144,6 +156,18 @@ func (t *UDPTransport) DataChannel(item interface{}) {
 	t.core.dataChannel(item)
 }
 
+// AddPointFilter - adds a filter to be applied before buffering the points
+func (t *UDPTransport) AddPointFilter(filter PointFilter) {
+
+	t.core.addPointFilter(filter)
+}
+
+// GetStats - returns the transport statistics
+func (t *UDPTransport) GetStats() TransportStats {
+
+	return t.core.getStats()
+}
+
 // MatchType - checks if this transport implementation matches the given type
 func (t *UDPTransport) MatchType(tt transportType) bool {
 
@@ -24,6 +24,18 @@ func (t *UDPTransport) AccumulatedDataToDataChannelItem(point *accumulatedData)
 	return t.serializerTransport.accumulatedDataToDataChannelItem(point)
 }
 
+// DataChannelItemToSeriesPoint - converts the data channel item to the series point
+func (t *UDPTransport) DataChannelItemToSeriesPoint(instance interface{}) (*SeriesPoint, error) {
+
+	return t.serializerTransport.dataChannelItemToSeriesPoint(instance)
+}
+
+// DataChannelItemToRollup - returns the data channel item without the tags removed by the rollup (nil if the rollup does not match)
+func (t *UDPTransport) DataChannelItemToRollup(instance interface{}, rollup *FlattenRollup) (interface{}, error) {
+
+	return t.serializerTransport.dataChannelItemToRollup(instance, rollup)
+}
+
 // Serialize - renders the text using the configured serializer
 func (t *UDPTransport) Serialize(item interface{}) (string, error) {
 
@@ -0,0 +1,301 @@
+package timeline
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/uol/logh"
+	openTSDBSerializer "github.com/uol/serializer/opentsdb"
+	"github.com/uol/serializer/serializer"
+)
+
+/**
+* The unix domain socket transport implementation (stream and datagram).
+* Sends the opentsdb lines when no custom serializer is given, otherwise each item is rendered by the custom serializer (ex: json).
+* @author rnojiri
+**/
+
+const (
+	// UnixStream - the unix stream socket
+	UnixStream string = "unix"
+
+	// UnixDatagram - the unix datagram socket
+	UnixDatagram string = "unixgram"
+)
+
+// UnixTransport - implements the unix domain socket transport
+type UnixTransport struct {
+	core                transportCore
+	configuration       *UnixTransportConfig
+	serializer          serializer.Serializer
+	address             *net.UnixAddr
+	unixNetworkConn     *rawNetworkConnection
+	itemTransport       *openTSDBItemTransport
+	serializerTransport *customSerializerTransport
+	separator           string
+}
+
+// NewUnixTransport - creates a new unix domain socket event manager (uses the opentsdb serializer if the custom one is nil)
+func NewUnixTransport(configuration *UnixTransportConfig, customSerializer serializer.Serializer) (*UnixTransport, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch configuration.Network {
+	case empty:
+		configuration.Network = UnixStream
+	case UnixStream, UnixDatagram:
+	default:
+		return nil, fmt.Errorf("invalid unix network: %s", configuration.Network)
+	}
+
+	if configuration.ReconnectionTimeout.Seconds() <= 0 {
+		return nil, fmt.Errorf("invalid connection reconnection timeout: %s", configuration.ReconnectionTimeout)
+	}
+
+	if configuration.MaxReconnectionRetries == 0 {
+		configuration.MaxReconnectionRetries = defaultConnRetries
+	}
+
+	if configuration.MaxReadTimeout.Duration < 0 {
+		return nil, fmt.Errorf("invalid connection maximum read timeout: %s", configuration.MaxReadTimeout)
+	}
+
+	if configuration.MaxReadTimeout.Duration > 0 && configuration.ReadBufferSize <= 0 {
+		return nil, fmt.Errorf("invalid read buffer size: %d", configuration.ReadBufferSize)
+	}
+
+	if configuration.MaxDatagramSize < 0 {
+		return nil, fmt.Errorf("invalid maximum datagram size: %d", configuration.MaxDatagramSize)
+	}
+
+	t := &UnixTransport{
+		core: transportCore{
+			batchSendInterval:    configuration.BatchSendInterval.Duration,
+			defaultConfiguration: &configuration.DefaultTransportConfig,
+		},
+		unixNetworkConn: &rawNetworkConnection{
+			transportConfiguration: &configuration.DefaultTransportConfig,
+			configuration:          &configuration.TCPUDPTransportConfig,
+		},
+		configuration: configuration,
+	}
+
+	if customSerializer == nil {
+		// the opentsdb lines already ends with a line break
+		t.serializer = openTSDBSerializer.New(configuration.SerializerBufferSize)
+		t.itemTransport = &openTSDBItemTransport{}
+	} else {
+
+		if len(configuration.TimestampProperty) == 0 {
+			return nil, fmt.Errorf("timestamp property is not configured")
+		}
+
+		if len(configuration.ValueProperty) == 0 {
+			return nil, fmt.Errorf("value property is not configured")
+		}
+
+		t.serializer = customSerializer
+		t.serializerTransport = &customSerializerTransport{
+			configuration: &configuration.CustomSerializerConfig,
+		}
+		t.separator = defaultDatagramSeparator
+	}
+
+	t.core.transport = t
+	t.unixNetworkConn.custom = t
+
+	return t, nil
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (t *UnixTransport) BuildContextualLogger(path ...string) {
+
+	if t.core.loggers != nil {
+		return
+	}
+
+	logContext := []string{"pkg", "timeline/unix"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	t.core.loggers = logh.CreateContextualLogger(logContext...)
+	t.unixNetworkConn.loggers = t.core.loggers
+}
+
+// ConfigureBackend - configures the backend (the backend host is used as socket path if it is not configured)
+func (t *UnixTransport) ConfigureBackend(backend *Backend) error {
+
+	if backend == nil {
+		return fmt.Errorf("no backend was configured")
+	}
+
+	socketPath := t.configuration.SocketPath
+	if len(socketPath) == 0 {
+		socketPath = backend.Host
+	}
+
+	if len(socketPath) == 0 {
+		return fmt.Errorf("no socket path was configured")
+	}
+
+	var err error
+	t.address, err = net.ResolveUnixAddr(t.configuration.Network, socketPath)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SerializePayload - serializes a list of generic data
+func (t *UnixTransport) SerializePayload(dataList []interface{}) (payload []string, err error) {
+
+	size := len(dataList)
+	serialized := make([]string, size)
+
+	for i := 0; i < size; i++ {
+
+		serialized[i], err = t.serializer.SerializeGeneric(dataList[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if t.configuration.Network == UnixDatagram {
+
+		if t.configuration.MaxDatagramSize > 0 {
+			return t.core.packDatagrams(serialized, t.separator, t.configuration.MaxDatagramSize), nil
+		}
+
+		return serialized, nil
+	}
+
+	return []string{strings.Join(serialized, t.separator) + t.separator}, nil
+}
+
+// Serialize - renders the text using the configured serializer
+func (t *UnixTransport) Serialize(item interface{}) (string, error) {
+
+	return t.serializer.SerializeGeneric(item)
+}
+
+func (t *UnixTransport) getAddress() net.Addr {
+
+	return t.address
+}
+
+func (t *UnixTransport) read(conn net.Conn, logConnError func(error, rwOp)) bool {
+
+	if t.configuration.Network == UnixDatagram || t.configuration.MaxReadTimeout.Duration == 0 {
+		return true
+	}
+
+	err := conn.SetReadDeadline(time.Now().Add(t.configuration.MaxReadTimeout.Duration))
+	if err != nil {
+		if logh.ErrorEnabled {
+			ev := t.core.loggers.Error()
+			if t.core.defaultConfiguration.PrintStackOnError {
+				ev = ev.Caller()
+			}
+			ev.Err(err).Msg("error setting read deadline")
+		}
+		return false
+	}
+
+	readBuffer := make([]byte, t.configuration.ReadBufferSize)
+	_, err = conn.Read(readBuffer)
+	if err != nil {
+		if err == io.EOF {
+			logConnError(err, readConnClosed)
+			return false
+		}
+
+		if castedErr, ok := err.(net.Error); ok && !castedErr.Timeout() {
+			logConnError(err, read)
+			return false
+		}
+	}
+
+	return true
+}
+
+func (t *UnixTransport) dial() (net.Conn, error) {
+
+	return net.DialUnix(t.configuration.Network, nil, t.address)
+}
+
+// TransferData - transfers the data to the backend throught this transport
+func (t *UnixTransport) TransferData(payload []string) error {
+
+	size := len(payload)
+	if size == 0 {
+		return ErrInvalidPayloadSize
+	}
+
+	for _, p := range payload {
+
+		err := t.unixNetworkConn.transferData(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DataChannel - send a new point
+func (t *UnixTransport) DataChannel(item interface{}) {
+
+	t.core.dataChannel(item)
+}
+
+// AddPointFilter - adds a filter to be applied before buffering the points
+func (t *UnixTransport) AddPointFilter(filter PointFilter) {
+
+	t.core.addPointFilter(filter)
+}
+
+// GetStats - returns the transport statistics
+func (t *UnixTransport) GetStats() TransportStats {
+
+	return t.core.getStats()
+}
+
+// MatchType - checks if this transport implementation matches the given type (opentsdb or json items)
+func (t *UnixTransport) MatchType(tt transportType) bool {
+
+	if t.itemTransport != nil {
+		return tt == typeOpenTSDB
+	}
+
+	return tt == typeHTTP
+}
+
+// Start - starts this transport
+func (t *UnixTransport) Start(manualMode bool) error {
+
+	return t.core.Start(manualMode)
+}
+
+// Close - closes this transport
+func (t *UnixTransport) Close() {
+
+	t.core.Close()
+	t.unixNetworkConn.closeConnection()
+}
+
+// SendData - releases the point buffer and send all data
+func (t *UnixTransport) SendData() error {
+	return t.core.SendData()
+}
@@ -0,0 +1,61 @@
+package timeline
+
+// DataChannelItemToFlattenerPoint - converts the data channel item to the flattened point one
+func (t *UnixTransport) DataChannelItemToFlattenerPoint(configuration *DataTransformerConfig, instance interface{}, operation FlatOperation) (Hashable, error) {
+
+	if t.itemTransport != nil {
+		return t.itemTransport.dataChannelItemToFlattenerPoint(configuration, instance, operation)
+	}
+
+	return t.serializerTransport.dataChannelItemToFlattenerPoint(configuration, instance, operation)
+}
+
+// FlattenerPointToDataChannelItem - converts the flattened point to the data channel one
+func (t *UnixTransport) FlattenerPointToDataChannelItem(point *FlattenerPoint) (interface{}, error) {
+
+	if t.itemTransport != nil {
+		return t.itemTransport.flattenerPointToDataChannelItem(point)
+	}
+
+	return t.serializerTransport.flattenerPointToDataChannelItem(point)
+}
+
+// DataChannelItemToAccumulatedData - converts the data channel item to the accumulated data
+func (t *UnixTransport) DataChannelItemToAccumulatedData(configuration *DataTransformerConfig, instance interface{}, calculateHash bool) (Hashable, error) {
+
+	if t.itemTransport != nil {
+		return t.itemTransport.dataChannelItemToAccumulatedData(configuration, instance, calculateHash)
+	}
+
+	return t.serializerTransport.dataChannelItemToAccumulatedData(configuration, instance, calculateHash)
+}
+
+// AccumulatedDataToDataChannelItem - converts the accumulated data to the data channel item
+func (t *UnixTransport) AccumulatedDataToDataChannelItem(point *accumulatedData) (interface{}, error) {
+
+	if t.itemTransport != nil {
+		return t.itemTransport.accumulatedDataToDataChannelItem(point)
+	}
+
+	return t.serializerTransport.accumulatedDataToDataChannelItem(point)
+}
+
+// DataChannelItemToSeriesPoint - converts the data channel item to the series point
+func (t *UnixTransport) DataChannelItemToSeriesPoint(instance interface{}) (*SeriesPoint, error) {
+
+	if t.itemTransport != nil {
+		return t.itemTransport.dataChannelItemToSeriesPoint(instance)
+	}
+
+	return t.serializerTransport.dataChannelItemToSeriesPoint(instance)
+}
+
+// DataChannelItemToRollup - returns the data channel item without the tags removed by the rollup (nil if the rollup does not match)
+func (t *UnixTransport) DataChannelItemToRollup(instance interface{}, rollup *FlattenRollup) (interface{}, error) {
+
+	if t.itemTransport != nil {
+		return t.itemTransport.dataChannelItemToRollup(instance, rollup)
+	}
+
+	return t.serializerTransport.dataChannelItemToRollup(instance, rollup)
+}
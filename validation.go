@@ -0,0 +1,328 @@
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/uol/logh"
+	serializer "github.com/uol/serializer/opentsdb"
+)
+
+/**
+* Validates the OpenTSDB points against the OpenTSDB's character set and tag limits.
+* @author rnojiri
+**/
+
+const (
+	// defaultMaxTags - the opentsdb's default value of "tsd.storage.max_tags"
+	defaultMaxTags int = 8
+
+	fieldMetric   string = "metric"
+	fieldValue    string = "value"
+	fieldTags     string = "tags"
+	fieldTagKey   string = "tag key"
+	fieldTagValue string = "tag value"
+)
+
+var (
+	// ErrEmptyMetric - raised when the metric name is empty
+	ErrEmptyMetric error = errors.New("empty metric name")
+
+	// ErrInvalidCharacter - raised when a name contains a character not accepted by the opentsdb
+	ErrInvalidCharacter error = errors.New("invalid character")
+
+	// ErrInvalidValue - raised when the value is NaN or Inf
+	ErrInvalidValue error = errors.New("value is NaN or Inf")
+
+	// ErrOddNumberOfTags - raised when some tag key has no value
+	ErrOddNumberOfTags error = errors.New("the number of tag keys and values must be even")
+
+	// ErrNoTags - raised when the point has no tags
+	ErrNoTags error = errors.New("at least one tag is required")
+
+	// ErrTooManyTags - raised when the number of tags is above the configured limit
+	ErrTooManyTags error = errors.New("too many tags")
+
+	// ErrEmptyTag - raised when a tag key or value is empty
+	ErrEmptyTag error = errors.New("empty tag key or value")
+
+	// ErrInvalidTagType - raised when a tag key is not a string
+	ErrInvalidTagType error = errors.New("tag key must be a string")
+)
+
+// ValidationError - the error returned when a point is not valid
+type ValidationError struct {
+	Metric string
+	Field  string
+	Err    error
+}
+
+// Error - returns the error message
+func (e *ValidationError) Error() string {
+
+	return fmt.Sprintf("invalid point \"%s\" (%s): %s", e.Metric, e.Field, e.Err.Error())
+}
+
+// Unwrap - returns the cause, use errors.Is() to check it
+func (e *ValidationError) Unwrap() error {
+
+	return e.Err
+}
+
+// PointValidator - validates the opentsdb points before sending, flattening or accumulating them (only the opentsdb functions use it)
+type PointValidator struct {
+	configuration *ValidationConfig
+	loggers       *logh.ContextualLogger
+}
+
+// NewPointValidator - creates a new point validator
+func NewPointValidator(configuration *ValidationConfig) (*PointValidator, error) {
+
+	if configuration == nil {
+		return nil, fmt.Errorf("null configuration found")
+	}
+
+	switch configuration.Mode {
+	case ValidationReject, ValidationSanitize, ValidationLogAndPass:
+	case empty:
+		configuration.Mode = ValidationReject
+	default:
+		return nil, fmt.Errorf("invalid validation mode: %s", configuration.Mode)
+	}
+
+	if configuration.MaxTags < 0 {
+		return nil, fmt.Errorf("invalid maximum number of tags: %d", configuration.MaxTags)
+	}
+
+	if configuration.MaxTags == 0 {
+		configuration.MaxTags = defaultMaxTags
+	}
+
+	v := &PointValidator{
+		configuration: configuration,
+	}
+
+	v.BuildContextualLogger()
+
+	return v, nil
+}
+
+// BuildContextualLogger - build the contextual logger using more info
+func (v *PointValidator) BuildContextualLogger(path ...string) {
+
+	logContext := []string{"pkg", "timeline/validation"}
+
+	if len(path) > 0 {
+		logContext = append(logContext, path...)
+	}
+
+	v.loggers = logh.CreateContextualLogger(logContext...)
+}
+
+// ValidateOpenTSDB - validates the point using the configured mode (the item can be changed in sanitize mode)
+func (v *PointValidator) ValidateOpenTSDB(item *serializer.ArrayItem) error {
+
+	err := v.validate(item)
+	if err == nil {
+		return nil
+	}
+
+	switch v.configuration.Mode {
+
+	case ValidationLogAndPass:
+
+		if logh.WarnEnabled {
+			ev := v.loggers.Warn()
+			if v.configuration.PrintStackOnError {
+				ev = ev.Caller()
+			}
+			ev.Err(err).Msg("invalid point will be sent")
+		}
+
+		return nil
+
+	case ValidationSanitize:
+
+		err = v.sanitize(item)
+		if err != nil {
+			return err
+		}
+
+		if logh.DebugEnabled {
+			v.loggers.Debug().Msgf("point was sanitized: %s", item.Metric)
+		}
+
+		return nil
+
+	default:
+
+		return err
+	}
+}
+
+// validate - returns the first problem found in the point
+func (v *PointValidator) validate(item *serializer.ArrayItem) error {
+
+	if len(item.Metric) == 0 {
+		return newValidationError(item, fieldMetric, ErrEmptyMetric)
+	}
+
+	if !isValidName(item.Metric) {
+		return newValidationError(item, fieldMetric, ErrInvalidCharacter)
+	}
+
+	if math.IsNaN(item.Value) || math.IsInf(item.Value, 0) {
+		return newValidationError(item, fieldValue, ErrInvalidValue)
+	}
+
+	numTags := len(item.Tags)
+
+	if numTags%2 != 0 {
+		return newValidationError(item, fieldTags, ErrOddNumberOfTags)
+	}
+
+	if numTags == 0 {
+		return newValidationError(item, fieldTags, ErrNoTags)
+	}
+
+	if numTags/2 > v.configuration.MaxTags {
+		return newValidationError(item, fieldTags, ErrTooManyTags)
+	}
+
+	for i := 0; i < numTags; i += 2 {
+
+		key, ok := item.Tags[i].(string)
+		if !ok {
+			return newValidationError(item, fieldTagKey, ErrInvalidTagType)
+		}
+
+		if len(key) == 0 {
+			return newValidationError(item, fieldTagKey, ErrEmptyTag)
+		}
+
+		if !isValidName(key) {
+			return newValidationError(item, fieldTagKey, ErrInvalidCharacter)
+		}
+
+		if isEmptyTagValue(item.Tags[i+1]) {
+			return newValidationError(item, fieldTagValue, ErrEmptyTag)
+		}
+
+		if value, ok := item.Tags[i+1].(string); ok && !isValidName(value) {
+			return newValidationError(item, fieldTagValue, ErrInvalidCharacter)
+		}
+	}
+
+	return nil
+}
+
+// sanitize - fixes the point when possible, returns an error if not
+func (v *PointValidator) sanitize(item *serializer.ArrayItem) error {
+
+	if len(item.Metric) == 0 {
+		return newValidationError(item, fieldMetric, ErrEmptyMetric)
+	}
+
+	if math.IsNaN(item.Value) || math.IsInf(item.Value, 0) {
+		return newValidationError(item, fieldValue, ErrInvalidValue)
+	}
+
+	item.Metric = sanitizeName(item.Metric)
+
+	// a dangling tag key is discarded
+	numTags := len(item.Tags) - len(item.Tags)%2
+
+	// the tags slice may be shared with the caller, so a new one is created
+	tags := make([]interface{}, 0, numTags)
+
+	for i := 0; i < numTags; i += 2 {
+
+		key, ok := item.Tags[i].(string)
+		if !ok {
+			return newValidationError(item, fieldTagKey, ErrInvalidTagType)
+		}
+
+		if len(key) == 0 || isEmptyTagValue(item.Tags[i+1]) {
+			continue
+		}
+
+		value := item.Tags[i+1]
+		if casted, ok := value.(string); ok {
+			value = sanitizeName(casted)
+		}
+
+		tags = append(tags, sanitizeName(key), value)
+	}
+
+	item.Tags = tags
+
+	if len(tags) == 0 {
+		return newValidationError(item, fieldTags, ErrNoTags)
+	}
+
+	if len(tags)/2 > v.configuration.MaxTags {
+		return newValidationError(item, fieldTags, ErrTooManyTags)
+	}
+
+	return nil
+}
+
+// newValidationError - creates a new validation error
+func newValidationError(item *serializer.ArrayItem, field string, err error) error {
+
+	return &ValidationError{
+		Metric: item.Metric,
+		Field:  field,
+		Err:    err,
+	}
+}
+
+// isValidRune - checks if the character is accepted by the opentsdb
+func isValidRune(r rune) bool {
+
+	return (r >= 'a' && r <= 'z') ||
+		(r >= 'A' && r <= 'Z') ||
+		(r >= '0' && r <= '9') ||
+		r == '-' || r == '_' || r == '.' || r == '/' ||
+		unicode.IsLetter(r)
+}
+
+// isValidName - checks if all characters are accepted by the opentsdb
+func isValidName(name string) bool {
+
+	for _, r := range name {
+		if !isValidRune(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sanitizeName - replaces all invalid characters by an underscore
+func sanitizeName(name string) string {
+
+	return strings.Map(func(r rune) rune {
+		if isValidRune(r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// isEmptyTagValue - checks if the tag value is nil or an empty string
+func isEmptyTagValue(value interface{}) bool {
+
+	if value == nil {
+		return true
+	}
+
+	if casted, ok := value.(string); ok {
+		return len(casted) == 0
+	}
+
+	return false
+}